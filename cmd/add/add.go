@@ -1,94 +1,76 @@
 package add
 
 import (
-	"bytes"
-	"encoding/csv"
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
 
-	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/katiem0/gh-collaborators/internal/data"
-	"github.com/katiem0/gh-collaborators/internal/log"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/runner"
 	"github.com/katiem0/gh-collaborators/internal/utils"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	token    string
-	hostname string
-	fileName string
-	debug    bool
+	fileName       string
+	allowDowngrade bool
+	yes            bool
 }
 
-func NewCmdAdd() *cobra.Command {
+func NewCmdAdd(f *factory.Factory) *cobra.Command {
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	addCmd := &cobra.Command{
 		Use:   "add [flags] <organization>",
 		Short: "Add repo access for repository collaborators.",
 		Long:  "Add repositories and permissions for repository collaborators.",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  f.OrgArgs,
 		RunE: func(addCmd *cobra.Command, args []string) error {
-			var err error
-			var gqlClient *api.GraphQLClient
-			var restClient *api.RESTClient
+			owner := f.Owner(args)
 
-			// Reinitialize logging if debugging was enabled
-			if cmdFlags.debug {
-				logger, _ := log.NewLogger(cmdFlags.debug)
-				defer logger.Sync() // nolint:errcheck
-				zap.ReplaceGlobals(logger)
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
 			}
 
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
+			collabs, err := utils.ReadCollaborators(cmdFlags.fileName, apiGetter.CreateRepoCollaboratorsList)
+			if err != nil {
+				return err
+			}
+			if err := f.ConfirmChanges(addCmd.OutOrStdout(), utils.ChangeSummary("add or update", collabs), cmdFlags.yes); err != nil {
+				return err
 			}
 
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			// Issues and comments opened by the notifier count against the
+			// same rate limits as the run's changes
+			exec := f.Executor()
+			notifier, err := f.Notifier(exec.RateLimited(apiGetter))
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving rest client")
 				return err
 			}
 
-			gqlClient, err = api.NewGraphQLClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github.hawkgirl-preview+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			j, err := f.OpenJournal(addCmd.Context(), "add", owner)
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving graphql client")
 				return err
 			}
-
-			owner := args[0]
-
-			return runCmdAdd(owner, &cmdFlags, utils.NewAPIGetter(gqlClient, restClient))
+			defer func() {
+				closeErr := j.Close()
+				if closeErr != nil {
+					zap.S().Warnf("Error closing journal: %v", closeErr)
+				}
+			}()
+
+			run := &runner.Run{Command: "add", Owner: owner, Exec: exec, Journal: j, Notifier: notifier, Out: addCmd.OutOrStdout()}
+			return runCmdAdd(addCmd.Context(), owner, collabs, &cmdFlags, apiGetter, run)
 		},
 	}
 
 	// Configure flags for command
 
-	addCmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub Personal Access Token (default "gh auth token")`)
-	addCmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	addCmd.Flags().StringVarP(&cmdFlags.fileName, "from-file", "f", "", "Path and Name of CSV file to create access from (required)")
-	addCmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
+	addCmd.Flags().BoolVar(&cmdFlags.allowDowngrade, "allow-downgrade", false, "Apply rows that lower a collaborator's existing permission")
+	addCmd.Flags().BoolVarP(&cmdFlags.yes, "yes", "y", false, "Skip the confirmation prompt, required when not running interactively")
 	err := addCmd.MarkFlagRequired("from-file")
 	if err != nil {
 		zap.S().Errorf("Error marking flag 'from-file' as required: %v", err)
@@ -97,51 +79,13 @@ func NewCmdAdd() *cobra.Command {
 	return addCmd
 }
 
-func runCmdAdd(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
-	var collabData [][]string
-	var importRepoCollabList []data.ImportedRepoCollab
-
-	if len(cmdFlags.fileName) > 0 {
-		f, err := os.Open(cmdFlags.fileName)
-		zap.S().Debugf("Opening up file %s", cmdFlags.fileName)
-		if err != nil {
-			zap.S().Errorf("Error arose opening repository collaborators csv file")
-		}
-		defer func() {
-			closeErr := f.Close()
-			if closeErr != nil {
-				zap.S().Warnf("Error closing file: %v", closeErr)
-			}
-		}()
-		// read csv values using csv.Reader
-		csvReader := csv.NewReader(f)
-		collabData, err = csvReader.ReadAll()
-		zap.S().Debugf("Reading in all lines from csv file")
-		if err != nil {
-			zap.S().Errorf("Error arose reading assignments from csv file")
-		}
-		importRepoCollabList = g.CreateRepoCollaboratorsList(collabData)
-	} else {
-		zap.S().Errorf("Error arose identifying users to add")
-	}
+func runCmdAdd(ctx context.Context, owner string, importRepoCollabList []data.ImportedRepoCollab, cmdFlags *cmdFlags, g utils.Getter, run *runner.Run) error {
 	zap.S().Debugf("Determining permissions to create")
-	for _, importRepoCollab := range importRepoCollabList {
-		zap.S().Debugf("Adding user %s to repo %s", importRepoCollab.Username, importRepoCollab.RepositoryName)
-		repoPermObject := g.CreateRepoPermData(importRepoCollab.Permission)
-		assignRepo, err := json.Marshal(repoPermObject)
-
-		if err != nil {
-			return err
-		}
-		reader := bytes.NewReader(assignRepo)
-		zap.S().Debugf("Creating Repository Assignment for %s with permission %s", importRepoCollab.Username, importRepoCollab.Permission)
-
-		err = g.AddRepoCollaborator(owner, importRepoCollab.RepositoryName, importRepoCollab.Username, reader)
-		if err != nil {
-			zap.S().Errorf("Error arose creating permission for user %s  and repo %s", importRepoCollab.Username, importRepoCollab.RepositoryName)
-		}
-	}
+	g = run.Exec.RateLimited(g)
+	results, err := run.Apply(ctx, len(importRepoCollabList), utils.CollabRows(importRepoCollabList), func(i int) data.MutationResult {
+		return utils.GrantRepoCollaborator(ctx, owner, importRepoCollabList[i], cmdFlags.allowDowngrade, g)
+	})
 
-	fmt.Printf("Successfully created repository assignments for repository collaborators in: %s.", owner)
-	return nil
+	_, _ = fmt.Fprintf(run.Out, "Processed repository assignments for repository collaborators in: %s.\n", owner)
+	return run.Report(ctx, results, err)
 }
@@ -1,17 +1,24 @@
 package add
 
 import (
+	"bytes"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/replay"
 	"github.com/spf13/cobra"
 )
 
 func TestNewCmdAdd(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	if cmd == nil {
-		t.Fatal("NewCmdAdd() returned nil")
+		t.Fatal("NewCmdAdd(factory.New()) returned nil")
 	}
 
 	if cmd.Use != "add [flags] <organization>" {
@@ -33,14 +40,12 @@ func TestNewCmdAdd(t *testing.T) {
 }
 
 func TestAddCommandFlags(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	// Test that all expected flags exist
 	expectedFlags := map[string]string{
-		"token":     "t",
-		"hostname":  "",
 		"from-file": "f",
-		"debug":     "d",
+		"yes":       "y",
 	}
 
 	for flag, shorthand := range expectedFlags {
@@ -57,7 +62,7 @@ func TestAddCommandFlags(t *testing.T) {
 }
 
 func TestAddCommandRequiredFlags(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	// Test that the from-file flag is required
 	fromFileFlag := cmd.Flag("from-file")
@@ -74,48 +79,27 @@ func TestAddCommandRequiredFlags(t *testing.T) {
 }
 
 func TestAddCommandDefaultValues(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
-	// Check default hostname
-	hostnameFlag := cmd.Flag("hostname")
-	if hostnameFlag != nil && hostnameFlag.DefValue != "github.com" {
-		t.Errorf("Expected default hostname to be 'github.com', got %s", hostnameFlag.DefValue)
-	}
-
-	// Check default debug value
-	debugFlag := cmd.Flag("debug")
-	if debugFlag != nil && debugFlag.DefValue != "false" {
-		t.Errorf("Expected default debug to be 'false', got %s", debugFlag.DefValue)
+	// Check that from-file has no default
+	fromFileFlag := cmd.Flag("from-file")
+	if fromFileFlag != nil && fromFileFlag.DefValue != "" {
+		t.Errorf("Expected default from-file to be empty, got %s", fromFileFlag.DefValue)
 	}
 }
 
 func TestCmdFlagsStruct(t *testing.T) {
 	flags := cmdFlags{
-		token:    "test-token",
-		hostname: "github.enterprise.com",
 		fileName: "test.csv",
-		debug:    true,
-	}
-
-	if flags.token != "test-token" {
-		t.Errorf("Expected token to be 'test-token', got %s", flags.token)
-	}
-
-	if flags.hostname != "github.enterprise.com" {
-		t.Errorf("Expected hostname to be 'github.enterprise.com', got %s", flags.hostname)
 	}
 
 	if flags.fileName != "test.csv" {
 		t.Errorf("Expected fileName to be 'test.csv', got %s", flags.fileName)
 	}
-
-	if !flags.debug {
-		t.Error("Expected debug to be true")
-	}
 }
 
 func TestAddCommandArgsValidation(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	// Test with no args - should fail
 	cmd.SetArgs([]string{})
@@ -138,7 +122,7 @@ func TestAddCommandArgsValidation(t *testing.T) {
 }
 
 func TestAddCommandLongDescription(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	expectedLong := "Add repositories and permissions for repository collaborators."
 	if cmd.Long != expectedLong {
@@ -147,7 +131,7 @@ func TestAddCommandLongDescription(t *testing.T) {
 }
 
 func TestAddCommandUsage(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	expectedUse := "add [flags] <organization>"
 	if cmd.Use != expectedUse {
@@ -156,13 +140,11 @@ func TestAddCommandUsage(t *testing.T) {
 }
 
 func TestAddCommandFlagDescriptions(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	expectedDescriptions := map[string]string{
-		"token":     `GitHub Personal Access Token (default "gh auth token")`,
-		"hostname":  "GitHub Enterprise Server hostname",
-		"from-file": "Path and Name of CSV file to create access from (required)",
-		"debug":     "To debug logging",
+		"from-file":       "Path and Name of CSV file to create access from (required)",
+		"allow-downgrade": "Apply rows that lower a collaborator's existing permission",
 	}
 
 	for flagName, expectedDesc := range expectedDescriptions {
@@ -179,10 +161,10 @@ func TestAddCommandFlagDescriptions(t *testing.T) {
 }
 
 func TestAddCommandFlagTypes(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	// Test that flags have correct types
-	stringFlags := []string{"token", "hostname", "from-file"}
+	stringFlags := []string{"from-file"}
 	for _, flagName := range stringFlags {
 		flag := cmd.Flag(flagName)
 		if flag == nil {
@@ -195,15 +177,13 @@ func TestAddCommandFlagTypes(t *testing.T) {
 		}
 	}
 
-	// Test debug flag is boolean
-	debugFlag := cmd.Flag("debug")
-	if debugFlag != nil && debugFlag.Value.Type() != "bool" {
-		t.Errorf("Expected debug flag to be bool type, got %s", debugFlag.Value.Type())
+	if flag := cmd.Flag("allow-downgrade"); flag == nil || flag.Value.Type() != "bool" || flag.DefValue != "false" {
+		t.Error("Expected 'allow-downgrade' to be a bool flag defaulting to false")
 	}
 }
 
 func TestAddCommandHelpText(t *testing.T) {
-	cmd := NewCmdAdd()
+	cmd := NewCmdAdd(factory.New())
 
 	// Test that help can be generated without errors
 	help := cmd.UsageString()
@@ -221,16 +201,7 @@ func TestAddCommandHelpText(t *testing.T) {
 }
 
 func TestAddCommandPersistentFlags(t *testing.T) {
-	cmd := NewCmdAdd()
-
-	// Test that certain flags are persistent
-	persistentFlags := []string{"token", "hostname", "debug"}
-	for _, flagName := range persistentFlags {
-		flag := cmd.PersistentFlags().Lookup(flagName)
-		if flag == nil {
-			t.Errorf("Expected '%s' to be a persistent flag", flagName)
-		}
-	}
+	cmd := NewCmdAdd(factory.New())
 
 	// Test that from-file is not persistent (it's a local flag)
 	flag := cmd.PersistentFlags().Lookup("from-file")
@@ -248,19 +219,172 @@ func TestAddCommandPersistentFlags(t *testing.T) {
 func TestCmdFlagsEmptyValues(t *testing.T) {
 	flags := cmdFlags{}
 
-	if flags.token != "" {
-		t.Errorf("Expected empty token, got %s", flags.token)
+	if flags.fileName != "" {
+		t.Errorf("Expected empty fileName, got %s", flags.fileName)
 	}
+}
 
-	if flags.hostname != "" {
-		t.Errorf("Expected empty hostname, got %s", flags.hostname)
+func TestAddCommandUsesRootFlags(t *testing.T) {
+	cmd := NewCmdAdd(factory.New())
+
+	// Authentication, hostname and debug flags are inherited from the root command
+	for _, flagName := range []string{"token", "hostname", "debug", "app-id", "private-key-path", "installation-id"} {
+		if cmd.Flags().Lookup(flagName) != nil {
+			t.Errorf("Expected '%s' to be defined on the root command, not on add", flagName)
+		}
 	}
+}
 
-	if flags.fileName != "" {
-		t.Errorf("Expected empty fileName, got %s", flags.fileName)
+func TestAddEndToEnd(t *testing.T) {
+	server := replay.NewServer(t, "testdata/add.json")
+
+	var out bytes.Buffer
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+	cmd := NewCmdAdd(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/add.csv", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// Every row is read first, only changes that are neither identical nor
+	// downgrades are written
+	var puts []string
+	for _, request := range server.Requests() {
+		if request.Method == "PUT" {
+			puts = append(puts, request.Path)
+		}
+	}
+	if len(puts) != 2 || puts[0] != "/repos/test-org/repo1/collaborators/alice" || puts[1] != "/repos/test-org/repo2/collaborators/bob" {
+		t.Errorf("Expected PUTs for alice and bob only, got %v", puts)
+	}
+
+	summary := out.String()
+	for _, expected := range []string{
+		"Summary: 1 added, 1 updated, 1 skipped, 1 blocked, 1 failed",
+		"skipped: dave on repo3 (already has write)",
+		"blocked: erin on repo4 (would downgrade admin to read, use --allow-downgrade to apply)",
+		"failed: carol on missing-repo",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", expected, summary)
+		}
 	}
 
-	if flags.debug {
-		t.Error("Expected debug to be false")
+	// alice was invited, the journal keeps the invitation for rollback
+	files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one journal file, got %v", files)
+	}
+	entries, err := journal.Load(f.JournalDir, strings.TrimSuffix(filepath.Base(files[0]), ".jsonl"))
+	if err != nil {
+		t.Fatalf("Expected no error loading journal, got %v", err)
+	}
+	for _, entry := range entries {
+		if invited := entry.Username == "alice"; invited != (entry.InvitationID == 1) {
+			t.Errorf("Expected an invitation ID for alice only, got %+v", entry)
+		}
+	}
+}
+
+func TestAddEndToEndConcurrent(t *testing.T) {
+	server := replay.NewServer(t, "testdata/add.json")
+
+	var out bytes.Buffer
+	f := factorytest.New(server)
+	f.Concurrency = 4
+	f.RateLimit = 1000
+	cmd := NewCmdAdd(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/add.csv", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// Rows finish in any order but are reported in file order
+	summary := out.String()
+	if !strings.Contains(summary, "Summary: 1 added, 1 updated, 1 skipped, 1 blocked, 1 failed") {
+		t.Errorf("Unexpected summary:\n%s", summary)
+	}
+	failed := strings.Index(summary, "failed: carol")
+	skipped := strings.Index(summary, "skipped: dave")
+	blocked := strings.Index(summary, "blocked: erin")
+	if failed < 0 || !(failed < skipped && skipped < blocked) {
+		t.Errorf("Expected details in file order, got:\n%s", summary)
+	}
+}
+
+func TestAddEndToEndAllowDowngrade(t *testing.T) {
+	server := replay.NewServer(t, "testdata/add_allow_downgrade.json")
+
+	var out bytes.Buffer
+	cmd := NewCmdAdd(factorytest.New(server))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/add.csv", "--allow-downgrade", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if !strings.Contains(out.String(), "Summary: 1 added, 2 updated, 1 skipped, 1 failed") {
+		t.Errorf("Expected downgrade to be applied, got:\n%s", out.String())
+	}
+}
+
+func TestAddEndToEndMissingFile(t *testing.T) {
+	server := replay.NewServer(t)
+
+	cmd := NewCmdAdd(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/does-not-exist.csv"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no requests, got %d", len(server.Requests()))
+	}
+}
+
+func TestAddEndToEndNotifies(t *testing.T) {
+	server := replay.NewServer(t, "testdata/add.json")
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "POST", Path: "/collaborators"},
+			Response: replay.Response{Status: 204},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "POST", Path: "/repos/test-org/repo1/issues"},
+			Response: replay.Response{Status: 201, Body: []byte(`{"number":1}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "POST", Path: "/repos/test-org/repo2/issues"},
+			Response: replay.Response{Status: 201, Body: []byte(`{"number":2}`)},
+		},
+	)
+
+	var out bytes.Buffer
+	f := factorytest.New(server)
+	f.Profile = &config.Profile{Notify: config.Notify{
+		Webhook: &config.WebhookNotify{URL: "https://hooks.example.com/collaborators"},
+		GitHub:  &config.GitHubNotify{Issues: true},
+	}}
+	cmd := NewCmdAdd(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/add.csv", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// One digest for the run, an issue only on the repositories that changed
+	for _, request := range server.Requests() {
+		if request.Path == "/collaborators" && !strings.Contains(string(request.Body), `"summary":{"added":1,"blocked":1,"failed":1,"skipped":1,"updated":1}`) {
+			t.Errorf("Expected the run's totals in the webhook payload, got %s", request.Body)
+		}
+	}
+	if strings.Contains(out.String(), "Failed to send notifications") {
+		t.Errorf("Expected notifications to be sent, got:\n%s", out.String())
 	}
 }
@@ -0,0 +1,18 @@
+package analyze
+
+import (
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdAnalyze(f *factory.Factory) *cobra.Command {
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze <command>",
+		Short: "Analyze repository collaborator access.",
+		Long:  "Analyze the repository access of collaborators in a report generated by list.",
+	}
+
+	analyzeCmd.AddCommand(NewCmdTeams(f))
+
+	return analyzeCmd
+}
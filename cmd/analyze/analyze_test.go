@@ -0,0 +1,18 @@
+package analyze
+
+import (
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+)
+
+func TestNewCmdAnalyze(t *testing.T) {
+	cmd := NewCmdAnalyze(factory.New())
+
+	if cmd.Use != "analyze <command>" {
+		t.Errorf("Expected Use to be 'analyze <command>', got %s", cmd.Use)
+	}
+	if len(cmd.Commands()) != 1 || cmd.Commands()[0].Name() != "teams" {
+		t.Errorf("Expected the teams subcommand, got %v", cmd.Commands())
+	}
+}
@@ -0,0 +1,189 @@
+package analyze
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/report"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const (
+	// ActionReplace marks a direct grant the team gives the same access as
+	ActionReplace = "replace"
+	// ActionKeep marks a direct grant above the team's access, it stays
+	ActionKeep = "keep"
+)
+
+// PlanHeader is the first row of the migration plan CSV.
+var PlanHeader = []string{
+	"TeamName",
+	"ExistingTeam",
+	"Username",
+	"RepositoryName",
+	"TeamPermission",
+	"DirectPermission",
+	"Action",
+}
+
+type teamsFlags struct {
+	reportFile       string
+	planFile         string
+	minCollaborators int
+}
+
+func NewCmdTeams(f *factory.Factory) *cobra.Command {
+	cmdFlags := teamsFlags{}
+
+	teamsCmd := &cobra.Command{
+		Use:   "teams [flags] <organization>",
+		Short: "Suggest teams to replace direct collaborator grants.",
+		Long: "Suggest teams to replace direct collaborator grants: groups of collaborators in a list report sharing " +
+			"the same repository permissions, and existing organization teams whose repository access matches theirs. " +
+			"The suggestions are written to a migration plan CSV.",
+		Args: f.OrgArgs,
+		RunE: func(teamsCmd *cobra.Command, args []string) error {
+			owner := f.Owner(args)
+			if cmdFlags.minCollaborators < 1 {
+				return fmt.Errorf("--min-collaborators must be at least 1")
+			}
+
+			if _, err := os.Stat(cmdFlags.planFile); err == nil {
+				return fmt.Errorf("output file %s already exists", cmdFlags.planFile)
+			}
+
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
+
+			return runCmdTeams(teamsCmd.Context(), owner, &cmdFlags, apiGetter, teamsCmd.OutOrStdout())
+		},
+	}
+
+	planFileDefault := fmt.Sprintf("TeamMigrationPlan-%s.csv", time.Now().Format("20060102150405"))
+
+	// Configure flags for command
+	teamsCmd.Flags().StringVarP(&cmdFlags.reportFile, "from-file", "f", "", "Path of a report generated by list (required)")
+	teamsCmd.Flags().StringVarP(&cmdFlags.planFile, "output-file", "o", planFileDefault, "Name of file to write the migration plan CSV to")
+	teamsCmd.Flags().IntVarP(&cmdFlags.minCollaborators, "min-collaborators", "", 2, "Smallest group of collaborators to suggest a new team for")
+	err := teamsCmd.MarkFlagRequired("from-file")
+	if err != nil {
+		zap.S().Errorf("Error marking flag 'from-file' as required: %v", err)
+	}
+
+	return teamsCmd
+}
+
+func runCmdTeams(ctx context.Context, owner string, cmdFlags *teamsFlags, g utils.Getter, out io.Writer) error {
+	rows, err := report.ReadCSV(cmdFlags.reportFile)
+	if err != nil {
+		return err
+	}
+	grants := map[string]map[string]string{}
+	for _, row := range rows {
+		if grants[row.Username] == nil {
+			grants[row.Username] = map[string]string{}
+		}
+		grants[row.Username][row.Repository] = utils.NormalizePermission(row.Permission)
+	}
+	zap.S().Debugf("Read %d direct grants for %d collaborators", len(rows), len(grants))
+
+	teams, err := utils.GetTeamAccess(ctx, owner, g)
+	if err != nil {
+		return err
+	}
+	suggestions := utils.SuggestTeams(grants, teams, cmdFlags.minCollaborators)
+
+	plan := planRecords(suggestions, grants)
+	if err := writePlan(cmdFlags.planFile, plan); err != nil {
+		return err
+	}
+
+	newTeams, existingTeams := 0, 0
+	for _, suggestion := range suggestions {
+		if suggestion.Existing {
+			existingTeams++
+		} else {
+			newTeams++
+		}
+	}
+	replaced := map[string]bool{}
+	for _, record := range plan {
+		if record[6] == ActionReplace {
+			replaced[record[2]+"/"+record[3]] = true
+		}
+	}
+	_, _ = fmt.Fprintf(out, "Suggested %d new and %d existing teams for %s, replacing %d of %d direct grants.\n",
+		newTeams, existingTeams, owner, len(replaced), len(rows))
+	_, _ = fmt.Fprintf(out, "Migration plan written to: %s\n", cmdFlags.planFile)
+	return nil
+}
+
+// planRecords lists, for every suggested team and collaborator, each
+// repository the team grants and whether the collaborator's direct grant
+// can be replaced by the team.
+func planRecords(suggestions []utils.TeamSuggestion, grants map[string]map[string]string) [][]string {
+	var records [][]string
+	suggested := 0
+	for _, suggestion := range suggestions {
+		name := suggestion.Team.Slug
+		if !suggestion.Existing {
+			suggested++
+			name = fmt.Sprintf("suggested-team-%d", suggested)
+		}
+		repos := make([]string, 0, len(suggestion.Repositories))
+		for repo := range suggestion.Repositories {
+			repos = append(repos, repo)
+		}
+		sort.Strings(repos)
+
+		for _, username := range suggestion.Collaborators {
+			for _, repo := range repos {
+				teamPermission := suggestion.Repositories[repo]
+				direct := grants[username][repo]
+				action := ActionKeep
+				if utils.CoversPermission(teamPermission, direct) {
+					action = ActionReplace
+				}
+				records = append(records, []string{
+					name,
+					strconv.FormatBool(suggestion.Existing),
+					username,
+					repo,
+					strings.ToUpper(teamPermission),
+					strings.ToUpper(direct),
+					action,
+				})
+			}
+		}
+	}
+	return records
+}
+
+func writePlan(path string, records [][]string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create migration plan: %w", err)
+	}
+	csvWriter := csv.NewWriter(file)
+	if err := csvWriter.Write(PlanHeader); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write migration plan: %w", err)
+	}
+	if err := csvWriter.WriteAll(records); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write migration plan: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,88 @@
+package analyze
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCmdTeams(t *testing.T) {
+	cmd := NewCmdTeams(factory.New())
+
+	if cmd.Use != "teams [flags] <organization>" {
+		t.Errorf("Expected Use to be 'teams [flags] <organization>', got %s", cmd.Use)
+	}
+	for flag, shorthand := range map[string]string{"from-file": "f", "output-file": "o", "min-collaborators": ""} {
+		f := cmd.Flag(flag)
+		if f == nil {
+			t.Errorf("Expected flag '%s' to exist", flag)
+			continue
+		}
+		if f.Shorthand != shorthand {
+			t.Errorf("Expected flag '%s' to have shorthand '%s', got '%s'", flag, shorthand, f.Shorthand)
+		}
+	}
+	if _, ok := cmd.Flag("from-file").Annotations[cobra.BashCompOneRequiredFlag]; !ok {
+		t.Error("Expected 'from-file' flag to be marked as required")
+	}
+	if !strings.HasPrefix(cmd.Flag("output-file").DefValue, "TeamMigrationPlan-") {
+		t.Errorf("Expected a timestamped default plan file, got %s", cmd.Flag("output-file").DefValue)
+	}
+}
+
+func TestTeamsMigrationPlan(t *testing.T) {
+	server := replay.NewServer(t, "testdata/teams.json")
+	planPath := filepath.Join(t.TempDir(), "plan.csv")
+
+	var out bytes.Buffer
+	cmd := NewCmdTeams(factorytest.New(server))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/report.csv", "--output-file", planPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if !strings.Contains(out.String(), "Suggested 1 new and 1 existing teams for test-org, replacing 5 of 7 direct grants.") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+
+	plan, err := os.ReadFile(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// alice and bob share their access, carol fits within ops but keeps her
+	// admin grant on repo3, nobody shares dave's access
+	expected := strings.Join([]string{
+		"TeamName,ExistingTeam,Username,RepositoryName,TeamPermission,DirectPermission,Action",
+		"suggested-team-1,false,alice,repo1,WRITE,WRITE,replace",
+		"suggested-team-1,false,alice,repo2,READ,READ,replace",
+		"suggested-team-1,false,bob,repo1,WRITE,WRITE,replace",
+		"suggested-team-1,false,bob,repo2,READ,READ,replace",
+		"ops,true,carol,repo3,MAINTAIN,ADMIN,keep",
+		"ops,true,carol,repo4,WRITE,WRITE,replace",
+	}, "\n") + "\n"
+	if string(plan) != expected {
+		t.Errorf("Expected plan:\n%s\ngot:\n%s", expected, plan)
+	}
+}
+
+func TestTeamsExistingPlanFile(t *testing.T) {
+	server := replay.NewServer(t)
+
+	cmd := NewCmdTeams(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/report.csv", "--output-file", "testdata/report.csv"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an error for an existing plan file, got %v", err)
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no requests, got %d", len(server.Requests()))
+	}
+}
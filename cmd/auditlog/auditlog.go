@@ -0,0 +1,267 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const (
+	// DeclaredYes marks a grant listed in a source file
+	DeclaredYes = "yes"
+	// DeclaredNo marks a grant made outside the source files
+	DeclaredNo = "no"
+)
+
+// Actions are the audit log actions for changes to repository collaborators
+var Actions = []string{"repo.add_member", "repo.update_member", "repo.remove_member"}
+
+// ReportHeader is the first row of the audit log report CSV.
+var ReportHeader = []string{
+	"Timestamp",
+	"Action",
+	"RepositoryName",
+	"Username",
+	"Actor",
+	"Permission",
+	"Declared",
+}
+
+type cmdFlags struct {
+	sources        []string
+	since          string
+	includeMembers bool
+	outputFile     string
+}
+
+// event is an audit log event for one collaborator on one repository, with
+// whether a source file declares the grant.
+type event struct {
+	data.AuditLogEvent
+	repository string
+	declared   string
+}
+
+func NewCmdAuditLog(f *factory.Factory) *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	auditLogCmd := &cobra.Command{
+		Use:   "audit-log [flags] <organization>",
+		Short: "Show who granted repository collaborators access and when.",
+		Long: "Read the organization audit log for repository collaborators being added, updated and removed, " +
+			"showing who made each change and when. Grants that are not in the add CSV files given with --source " +
+			"are flagged as made outside the source of truth.",
+		Args: f.OrgArgs,
+		RunE: func(auditLogCmd *cobra.Command, args []string) error {
+			owner := f.Owner(args)
+			if cmdFlags.since != "" {
+				if _, err := time.Parse(time.DateOnly, cmdFlags.since); err != nil {
+					return fmt.Errorf("invalid --since %q, must be a date such as 2026-01-31", cmdFlags.since)
+				}
+			}
+			if _, err := os.Stat(cmdFlags.outputFile); err == nil {
+				return fmt.Errorf("output file %s already exists", cmdFlags.outputFile)
+			}
+
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
+
+			return runCmdAuditLog(auditLogCmd.Context(), owner, &cmdFlags, apiGetter, auditLogCmd.OutOrStdout())
+		},
+	}
+
+	reportFileDefault := fmt.Sprintf("AuditLogReport-%s.csv", time.Now().Format("20060102150405"))
+
+	// Configure flags for command
+	auditLogCmd.Flags().StringSliceVarP(&cmdFlags.sources, "source", "s", nil, "Add CSV files declaring the grants that should exist, grants not in them are flagged")
+	auditLogCmd.Flags().StringVarP(&cmdFlags.since, "since", "", "", "Only read events on or after this date, as YYYY-MM-DD")
+	auditLogCmd.Flags().BoolVarP(&cmdFlags.includeMembers, "include-members", "", false, "Include events for current organization members, not only outside collaborators")
+	auditLogCmd.Flags().StringVarP(&cmdFlags.outputFile, "output-file", "o", reportFileDefault, "Name of file to write the CSV report to")
+
+	return auditLogCmd
+}
+
+func runCmdAuditLog(ctx context.Context, owner string, cmdFlags *cmdFlags, g utils.Getter, out io.Writer) error {
+	declared, err := readSources(cmdFlags.sources, g)
+	if err != nil {
+		return err
+	}
+
+	// Events are kept for everyone who is not a member now, so outside
+	// collaborators who have since been removed are still reported
+	members := map[string]bool{}
+	if !cmdFlags.includeMembers {
+		users, err := g.ListOrgMembers(ctx, owner)
+		if err != nil {
+			return fmt.Errorf("failed to list members of %s: %w", owner, err)
+		}
+		for _, user := range users {
+			members[strings.ToLower(user.Login)] = true
+		}
+	}
+
+	var events []event
+	for _, action := range Actions {
+		phrase := "action:" + action
+		if cmdFlags.since != "" {
+			phrase += " created:>=" + cmdFlags.since
+		}
+		actionEvents, err := g.ListOrgAuditLog(ctx, owner, phrase)
+		if err != nil {
+			return auditLogError(owner, err)
+		}
+		zap.S().Debugf("Read %d %s events", len(actionEvents), action)
+		for _, auditEvent := range actionEvents {
+			if members[strings.ToLower(auditEvent.User)] {
+				continue
+			}
+			e := event{AuditLogEvent: auditEvent, repository: auditEvent.Repo}
+			if i := strings.Index(auditEvent.Repo, "/"); i >= 0 {
+				e.repository = auditEvent.Repo[i+1:]
+			}
+			if len(cmdFlags.sources) > 0 && auditEvent.Action != "repo.remove_member" {
+				e.declared = DeclaredNo
+				if isDeclared(declared, e) {
+					e.declared = DeclaredYes
+				}
+			}
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+
+	if err := writeReport(cmdFlags.outputFile, events); err != nil {
+		return err
+	}
+
+	users := map[string]bool{}
+	var undeclared []event
+	for _, e := range events {
+		users[strings.ToLower(e.User)] = true
+		if e.declared == DeclaredNo {
+			undeclared = append(undeclared, e)
+		}
+	}
+	who := "outside collaborators"
+	if cmdFlags.includeMembers {
+		who = "users"
+	}
+	_, _ = fmt.Fprintf(out, "Found %d collaborator changes for %d %s in %s.\n", len(events), len(users), who, owner)
+	if len(cmdFlags.sources) > 0 {
+		_, _ = fmt.Fprintf(out, "%d grants were made outside the source files", len(undeclared))
+		if len(undeclared) == 0 {
+			_, _ = fmt.Fprintln(out, ".")
+		} else {
+			_, _ = fmt.Fprintln(out, ":")
+		}
+		for _, e := range undeclared {
+			_, _ = fmt.Fprintf(out, "  %s  %s granted %s %s on %s\n", timestamp(e.Timestamp), e.Actor, e.User, permission(e.AuditLogEvent), e.repository)
+		}
+	}
+	_, _ = fmt.Fprintf(out, "Audit log report written to: %s\n", cmdFlags.outputFile)
+	return nil
+}
+
+// readSources returns the permission of every grant in the add CSV files,
+// keyed by repository and user.
+func readSources(sources []string, g utils.Getter) (map[string]string, error) {
+	declared := map[string]string{}
+	for _, source := range sources {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", source, err)
+		}
+		records, err := csv.NewReader(file).ReadAll()
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("no rows found in %s", source)
+		}
+		for _, collab := range g.CreateRepoCollaboratorsList(records) {
+			declared[grantKey(collab.RepositoryName, collab.Username)] = utils.NormalizePermission(collab.Permission)
+		}
+	}
+	return declared, nil
+}
+
+// isDeclared reports whether a source file grants the event's user the
+// permission it gave on the repository. Events that carry no permission only
+// need the user declared on the repository.
+func isDeclared(declared map[string]string, e event) bool {
+	want, ok := declared[grantKey(e.repository, e.User)]
+	if !ok {
+		return false
+	}
+	got := permission(e.AuditLogEvent)
+	return got == "" || got == want
+}
+
+func grantKey(repo string, username string) string {
+	return strings.ToLower(repo) + "/" + strings.ToLower(username)
+}
+
+// auditLogError explains the audit log being unavailable, which needs an
+// enterprise plan or server and a token allowed to read it.
+func auditLogError(owner string, err error) error {
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusNotFound || httpErr.StatusCode == http.StatusForbidden) {
+		return fmt.Errorf("cannot read the audit log of %s, it requires GitHub Enterprise Cloud or Server and an organization owner's token with the read:audit_log scope: %w", owner, err)
+	}
+	return fmt.Errorf("failed to read the audit log of %s: %w", owner, err)
+}
+
+func timestamp(milliseconds int64) string {
+	return time.UnixMilli(milliseconds).UTC().Format(time.RFC3339)
+}
+
+// permission returns the event's permission as a role name, empty for
+// events that do not carry one.
+func permission(e data.AuditLogEvent) string {
+	if e.Permission == "" {
+		return ""
+	}
+	return utils.NormalizePermission(e.Permission)
+}
+
+func writeReport(path string, events []event) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log report: %w", err)
+	}
+	csvWriter := csv.NewWriter(file)
+	records := [][]string{ReportHeader}
+	for _, e := range events {
+		records = append(records, []string{
+			timestamp(e.Timestamp),
+			e.Action,
+			e.repository,
+			e.User,
+			e.Actor,
+			strings.ToUpper(permission(e.AuditLogEvent)),
+			e.declared,
+		})
+	}
+	if err := csvWriter.WriteAll(records); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write audit log report: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,127 @@
+package auditlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+)
+
+func TestNewCmdAuditLog(t *testing.T) {
+	cmd := NewCmdAuditLog(factory.New())
+
+	if cmd.Use != "audit-log [flags] <organization>" {
+		t.Errorf("Expected Use to be 'audit-log [flags] <organization>', got %s", cmd.Use)
+	}
+	for flag, shorthand := range map[string]string{"source": "s", "since": "", "include-members": "", "output-file": "o"} {
+		f := cmd.Flag(flag)
+		if f == nil {
+			t.Errorf("Expected flag '%s' to exist", flag)
+			continue
+		}
+		if f.Shorthand != shorthand {
+			t.Errorf("Expected flag '%s' to have shorthand '%s', got '%s'", flag, shorthand, f.Shorthand)
+		}
+	}
+	if !strings.HasPrefix(cmd.Flag("output-file").DefValue, "AuditLogReport-") {
+		t.Errorf("Expected a timestamped default report file, got %s", cmd.Flag("output-file").DefValue)
+	}
+}
+
+func TestAuditLogFlagsUndeclaredGrants(t *testing.T) {
+	server := replay.NewServer(t, "testdata/audit_log.json")
+	reportPath := filepath.Join(t.TempDir(), "audit.csv")
+
+	var out bytes.Buffer
+	cmd := NewCmdAuditLog(factorytest.New(server))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--source", "testdata/add.csv", "--since", "2026-01-01", "--output-file", reportPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	for _, expected := range []string{
+		// erin was removed from the organization and is still reported,
+		// dave is a member
+		"Found 5 collaborator changes for 3 outside collaborators in test-org.",
+		// alice was declared with write, not the read she was first given
+		"2 grants were made outside the source files:\n" +
+			"  2026-01-01T00:00:00Z  admin granted alice read on repo1\n" +
+			"  2026-01-02T00:00:00Z  carol granted bob admin on repo2\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	report, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Timestamp,Action,RepositoryName,Username,Actor,Permission,Declared\n" +
+		"2026-01-01T00:00:00Z,repo.add_member,repo1,alice,admin,READ,no\n" +
+		"2026-01-02T00:00:00Z,repo.add_member,repo2,bob,carol,ADMIN,no\n" +
+		"2026-01-03T00:00:00Z,repo.update_member,repo1,alice,admin,WRITE,yes\n" +
+		"2026-01-04T00:00:00Z,repo.remove_member,repo3,bob,carol,,\n" +
+		"2026-01-05T00:00:00Z,repo.remove_member,repo4,erin,carol,,\n"
+	if string(report) != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, report)
+	}
+}
+
+func TestAuditLogEnterpriseServer(t *testing.T) {
+	server := replay.NewServer(t)
+	for _, action := range Actions {
+		server.Add(replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/audit-log", Query: "phrase=action%3A" + action + "&per_page=100&page=1"},
+			Response: replay.Response{Body: []byte(`[{"action":"` + action + `","actor":"admin","user":"dave","repo":"test-org/repo1","@timestamp":1767225600000}]`)},
+		})
+	}
+	f := factorytest.New(server)
+	f.Hostname = "github.example.com"
+
+	var out bytes.Buffer
+	cmd := NewCmdAuditLog(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--include-members", "--output-file", filepath.Join(t.TempDir(), "audit.csv")})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+	if !strings.Contains(out.String(), "Found 3 collaborator changes for 1 users in test-org.") {
+		t.Errorf("Expected every event for members, got:\n%s", out.String())
+	}
+}
+
+func TestAuditLogUnavailable(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/audit-log", Query: "phrase=action%3Arepo.add_member&per_page=100&page=1"},
+		Response: replay.Response{Status: 404, Body: []byte(`{"message":"Not Found"}`)},
+	})
+
+	cmd := NewCmdAuditLog(factorytest.New(server))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"test-org", "--include-members", "--output-file", filepath.Join(t.TempDir(), "audit.csv")})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "requires GitHub Enterprise Cloud or Server") {
+		t.Errorf("Expected an audit log availability error, got %v", err)
+	}
+}
+
+func TestAuditLogInvalidSince(t *testing.T) {
+	cmd := NewCmdAuditLog(factory.New())
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"test-org", "--since", "last week"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --since") {
+		t.Errorf("Expected an invalid date error, got %v", err)
+	}
+}
@@ -0,0 +1,356 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/cache"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/report"
+	"github.com/katiem0/gh-collaborators/internal/runner"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const (
+	ToMember  = "member"
+	ToOutside = "outside"
+)
+
+type cmdFlags struct {
+	username   string
+	to         string
+	listFile   string
+	addToTeams bool
+	keepDirect bool
+	dryRun     bool
+	yes        bool
+}
+
+// conversion is what converting a user changes, worked out before any of it
+// is made so it can be confirmed first.
+type conversion struct {
+	username   string
+	to         string
+	membership *data.OrgMembership
+	// inviteeID is set when the user is invited to become a member
+	inviteeID int
+	grants    map[string]string
+	teams     []utils.TeamAccess
+	// redundant are the direct grants covered by teams or the base permission
+	redundant  []data.ImportedRepoCollab
+	keepDirect bool
+}
+
+func NewCmdConvert(f *factory.Factory) *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	convertCmd := &cobra.Command{
+		Use:   "convert [flags] <organization>",
+		Short: "Convert outside collaborators to org members and back.",
+		Long: "Convert an outside collaborator to an organization member by inviting them, optionally adding them " +
+			"to teams that grant the repository access they hold directly and removing the direct grants this makes " +
+			"redundant. With --to outside, convert an organization member back to an outside collaborator.",
+		Args: f.OrgArgs,
+		RunE: func(convertCmd *cobra.Command, args []string) error {
+			if cmdFlags.to != ToMember && cmdFlags.to != ToOutside {
+				return fmt.Errorf("invalid --to %q, must be one of: %s, %s", cmdFlags.to, ToMember, ToOutside)
+			}
+			owner := f.Owner(args)
+
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
+
+			c, err := planConversion(convertCmd.Context(), owner, &cmdFlags, apiGetter)
+			if err != nil {
+				return err
+			}
+			changes := c.changes(owner)
+			if cmdFlags.dryRun {
+				writeDryRun(convertCmd.OutOrStdout(), c, changes)
+				return nil
+			}
+			if changes != "" {
+				if err := f.ConfirmChanges(convertCmd.OutOrStdout(), "About to "+changes, cmdFlags.yes); err != nil {
+					return err
+				}
+			}
+
+			j, err := f.OpenJournal(convertCmd.Context(), "convert", owner)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				closeErr := j.Close()
+				if closeErr != nil {
+					zap.S().Warnf("Error closing journal: %v", closeErr)
+				}
+			}()
+
+			run := &runner.Run{Command: "convert", Owner: owner, Exec: f.Executor(), Journal: j, Out: convertCmd.OutOrStdout()}
+			return runCmdConvert(convertCmd.Context(), owner, c, apiGetter, run)
+		},
+	}
+
+	// Configure flags for command
+
+	convertCmd.Flags().StringVarP(&cmdFlags.username, "username", "u", "", "Username of the collaborator to convert (required)")
+	convertCmd.Flags().StringVar(&cmdFlags.to, "to", ToMember, "Convert to an organization member or an outside collaborator: member, outside")
+	convertCmd.Flags().StringVarP(&cmdFlags.listFile, "from-file", "f", "", "Read direct grants from a list report instead of querying them")
+	convertCmd.Flags().BoolVar(&cmdFlags.addToTeams, "add-to-teams", false, "Add the member to teams granting the same repository access as their direct grants")
+	convertCmd.Flags().BoolVar(&cmdFlags.keepDirect, "keep-direct", false, "Keep direct grants made redundant by teams or the base permission")
+	convertCmd.Flags().BoolVar(&cmdFlags.dryRun, "dry-run", false, "Show the changes without applying them")
+	convertCmd.Flags().BoolVarP(&cmdFlags.yes, "yes", "y", false, "Skip the confirmation prompt, required when not running interactively")
+	err := convertCmd.MarkFlagRequired("username")
+	if err != nil {
+		zap.S().Errorf("Error marking flag 'username' as required: %v", err)
+	}
+
+	return convertCmd
+}
+
+// planConversion reads the user's membership, direct grants and the teams
+// covering them, everything deciding what the conversion changes.
+func planConversion(ctx context.Context, owner string, cmdFlags *cmdFlags, g utils.Getter) (*conversion, error) {
+	// Membership, grants and team access decide what is removed, they are
+	// never read from the cache
+	ctx = cache.Live(ctx)
+	username := cmdFlags.username
+	membership, err := g.GetOrgMembership(ctx, owner, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read membership of %s in %s: %w", username, owner, err)
+	}
+	c := &conversion{username: username, to: cmdFlags.to, membership: membership, keepDirect: cmdFlags.keepDirect}
+	if cmdFlags.to == ToOutside {
+		if membership == nil || membership.State != "active" {
+			return nil, fmt.Errorf("%s is not a member of %s", username, owner)
+		}
+		return c, nil
+	}
+	if membership != nil && membership.State == "pending" {
+		return c, nil
+	}
+
+	c.grants, err = directGrants(ctx, owner, cmdFlags, g)
+	if err != nil {
+		return nil, err
+	}
+	zap.S().Debugf("Found %d direct grants for %s", len(c.grants), username)
+
+	covered := map[string]bool{}
+	if cmdFlags.addToTeams {
+		access, err := utils.GetTeamAccess(ctx, owner, g)
+		if err != nil {
+			return nil, err
+		}
+		c.teams, covered = utils.CoveringTeams(c.grants, access)
+	}
+	org, err := g.GetOrganization(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read organization %s: %w", owner, err)
+	}
+	for repo, permission := range c.grants {
+		if utils.CoversPermission(org.DefaultRepositoryPermission, permission) {
+			covered[repo] = true
+		}
+	}
+	for repo := range covered {
+		c.redundant = append(c.redundant, data.ImportedRepoCollab{RepositoryName: repo, Username: username})
+	}
+	sort.Slice(c.redundant, func(i, k int) bool { return c.redundant[i].RepositoryName < c.redundant[k].RepositoryName })
+
+	if membership == nil {
+		user, err := g.GetUser(ctx, username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user %s: %w", username, err)
+		}
+		c.inviteeID = user.ID
+	}
+	return c, nil
+}
+
+// changes describes what the conversion changes, such as "invite carol to
+// acme as a member of teams: dev", it is empty when nothing changes.
+func (c *conversion) changes(owner string) string {
+	switch {
+	case c.to == ToOutside:
+		return fmt.Sprintf("convert %s to an outside collaborator in %s", c.username, owner)
+	case c.membership == nil:
+		invite := fmt.Sprintf("invite %s to %s as a member", c.username, owner)
+		if len(c.teams) > 0 {
+			invite += " of teams: " + teamSlugs(c.teams)
+		}
+		return invite
+	case c.membership.State == "pending":
+		return ""
+	}
+
+	var changes []string
+	if len(c.teams) > 0 {
+		changes = append(changes, fmt.Sprintf("add %s to teams: %s", c.username, teamSlugs(c.teams)))
+	}
+	if !c.keepDirect && len(c.redundant) > 0 {
+		changes = append(changes, fmt.Sprintf("remove %s made redundant", utils.CountOf(len(c.redundant), "direct grant", "direct grants")))
+	}
+	return strings.Join(changes, " and ")
+}
+
+func writeDryRun(out io.Writer, c *conversion, changes string) {
+	if changes == "" {
+		_, _ = fmt.Fprintf(out, "Dry run, no changes made, converting %s would change nothing.\n", c.username)
+		return
+	}
+	_, _ = fmt.Fprintf(out, "Dry run, no changes made, would %s.\n", changes)
+	if c.membership == nil || c.keepDirect {
+		return
+	}
+	for _, grant := range c.redundant {
+		_, _ = fmt.Fprintf(out, "  remove %s from %s with %s\n", c.username, grant.RepositoryName, c.grants[grant.RepositoryName])
+	}
+}
+
+func runCmdConvert(ctx context.Context, owner string, c *conversion, g utils.Getter, run *runner.Run) error {
+	if c.to == ToOutside {
+		return convertToOutside(ctx, owner, c, run.Exec.RateLimited(g), run)
+	}
+	return convertToMember(ctx, owner, c, g, run)
+}
+
+// applyChanges makes n membership changes through the run, so they are
+// journaled and audited like the repository grants, and returns the first
+// that failed.
+func applyChanges(ctx context.Context, run *runner.Run, n int, row func(i int) data.MutationResult, task func(i int) data.MutationResult) error {
+	results, err := run.Apply(ctx, n, row, task)
+	if err != nil {
+		return fmt.Errorf("%s stopped after failing to journal a change: %w", run.Command, err)
+	}
+	for _, result := range results {
+		if result.Status == utils.StatusFailed {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// userRow names the user of a membership change that is never started.
+func userRow(username string) func(i int) data.MutationResult {
+	return func(int) data.MutationResult { return data.MutationResult{Username: username} }
+}
+
+func convertToOutside(ctx context.Context, owner string, c *conversion, g utils.Getter, run *runner.Run) error {
+	username := c.username
+	zap.S().Debugf("Converting %s to an outside collaborator", username)
+	err := applyChanges(ctx, run, 1, userRow(username), func(int) data.MutationResult {
+		return utils.ConvertOrgMember(ctx, owner, username, c.membership.Role, g)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to convert %s to an outside collaborator: %w", username, err)
+	}
+	_, _ = fmt.Fprintf(run.Out, "Converted %s to an outside collaborator in %s, their team access is kept as direct repository grants.\n", username, owner)
+	return nil
+}
+
+func convertToMember(ctx context.Context, owner string, c *conversion, g utils.Getter, run *runner.Run) error {
+	username := c.username
+	if c.membership != nil && c.membership.State == "pending" {
+		_, _ = fmt.Fprintf(run.Out, "%s already has a pending invitation to %s, rerun convert once it is accepted.\n", username, owner)
+		return nil
+	}
+
+	rg := run.Exec.RateLimited(g)
+	if c.membership == nil {
+		teamIDs := make([]int, 0, len(c.teams))
+		for _, team := range c.teams {
+			teamIDs = append(teamIDs, team.Team.ID)
+		}
+		err := applyChanges(ctx, run, 1, userRow(username), func(int) data.MutationResult {
+			return utils.InviteOrgMember(ctx, owner, username, c.inviteeID, teamIDs, rg)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to invite %s to %s: %w", username, owner, err)
+		}
+		_, _ = fmt.Fprintf(run.Out, "Invited %s to %s as a member", username, owner)
+		if len(c.teams) > 0 {
+			_, _ = fmt.Fprintf(run.Out, " of teams: %s", teamSlugs(c.teams))
+		}
+		_, _ = fmt.Fprintf(run.Out, ".\nDirect grants are kept until the invitation is accepted, rerun convert afterwards to remove the %d made redundant.\n", len(c.redundant))
+		return nil
+	}
+
+	teamRow := func(i int) data.MutationResult {
+		return data.MutationResult{Username: username, Team: c.teams[i].Team.Slug}
+	}
+	err := applyChanges(ctx, run, len(c.teams), teamRow, func(i int) data.MutationResult {
+		return utils.AddTeamMembership(ctx, owner, c.teams[i].Team.Slug, username, rg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to teams: %w", username, err)
+	}
+	if len(c.teams) > 0 {
+		_, _ = fmt.Fprintf(run.Out, "Added %s to teams: %s.\n", username, teamSlugs(c.teams))
+	}
+
+	if c.keepDirect || len(c.redundant) == 0 {
+		_, _ = fmt.Fprintf(run.Out, "%s is a member of %s, kept %d direct grants.\n", username, owner, len(c.grants))
+		return nil
+	}
+
+	results, err := run.Apply(ctx, len(c.redundant), utils.CollabRows(c.redundant), func(i int) data.MutationResult {
+		return utils.RevokeRepoCollaborator(ctx, owner, c.redundant[i], rg)
+	})
+
+	_, _ = fmt.Fprintf(run.Out, "Removed direct grants of %s in %s covered by teams or the base permission, kept %d not covered.\n", username, owner, len(c.grants)-len(c.redundant))
+	return run.Report(ctx, results, err)
+}
+
+// directGrants returns the user's direct repository permissions keyed by
+// repository name, read from a list report when one is given.
+func directGrants(ctx context.Context, owner string, cmdFlags *cmdFlags, g utils.Getter) (map[string]string, error) {
+	grants := map[string]string{}
+	if len(cmdFlags.listFile) > 0 {
+		rows, err := report.ReadCSV(cmdFlags.listFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if strings.EqualFold(row.Username, cmdFlags.username) {
+				grants[row.Repository] = utils.NormalizePermission(row.Permission)
+			}
+		}
+		return grants, nil
+	}
+
+	var reposCursor *string
+	for {
+		permissions, err := g.GetOrgRepositoryPermissionsBatch(ctx, owner, []string{cmdFlags.username}, reposCursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repository permissions for %s: %w", cmdFlags.username, err)
+		}
+		for _, repo := range permissions.Organization.Repositories.Nodes {
+			if permission, ok := utils.BatchPermission(repo, 0, cmdFlags.username); ok {
+				grants[repo.Name] = utils.NormalizePermission(permission)
+			}
+		}
+		pageInfo := permissions.Organization.Repositories.PageInfo
+		if !pageInfo.HasNextPage {
+			return grants, nil
+		}
+		endCursor := pageInfo.EndCursor
+		reposCursor = &endCursor
+	}
+}
+
+func teamSlugs(teams []utils.TeamAccess) string {
+	slugs := make([]string, 0, len(teams))
+	for _, team := range teams {
+		slugs = append(slugs, team.Team.Slug)
+	}
+	return strings.Join(slugs, ", ")
+}
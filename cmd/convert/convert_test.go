@@ -0,0 +1,240 @@
+package convert
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCmdConvert(t *testing.T) {
+	cmd := NewCmdConvert(factory.New())
+
+	if cmd.Use != "convert [flags] <organization>" {
+		t.Errorf("Expected Use to be 'convert [flags] <organization>', got %s", cmd.Use)
+	}
+
+	expectedFlags := map[string]string{
+		"username":     "u",
+		"to":           "",
+		"from-file":    "f",
+		"add-to-teams": "",
+		"keep-direct":  "",
+		"dry-run":      "",
+		"yes":          "y",
+	}
+	for flag, shorthand := range expectedFlags {
+		f := cmd.Flag(flag)
+		if f == nil {
+			t.Errorf("Expected flag '%s' to exist", flag)
+			continue
+		}
+		if f.Shorthand != shorthand {
+			t.Errorf("Expected flag '%s' to have shorthand '%s', got '%s'", flag, shorthand, f.Shorthand)
+		}
+	}
+
+	if cmd.Flag("to").DefValue != ToMember {
+		t.Errorf("Expected default --to to be %s, got %s", ToMember, cmd.Flag("to").DefValue)
+	}
+	if _, ok := cmd.Flag("username").Annotations[cobra.BashCompOneRequiredFlag]; !ok {
+		t.Error("Expected 'username' flag to be marked as required")
+	}
+}
+
+func runConvert(t *testing.T, f *factory.Factory, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := NewCmdConvert(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestConvertActiveMember(t *testing.T) {
+	server := replay.NewServer(t, "testdata/convert_member.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+
+	out, err := runConvert(t, f, "test-org", "--username", "alice", "--add-to-teams", "--yes")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// dev grants repo1 as alice holds it, platform would add repo9; the
+	// read base permission covers repo2 but not the admin grant on repo3
+	for _, expected := range []string{
+		"Added alice to teams: dev.",
+		"kept 1 not covered",
+		"Summary: 2 removed",
+		"undo with: gh collaborators rollback",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	entries := loadJournal(t, f.JournalDir)
+	// The team membership is journaled ahead of the grants it made redundant
+	if len(entries) != 3 || entries[0].Team != "dev" || entries[0].RepositoryName != "" || entries[0].Status != "added" {
+		t.Fatalf("Unexpected journal entries: %+v", entries)
+	}
+	if entries[1].Command != "convert" || entries[1].PreviousPermission != "write" {
+		t.Errorf("Unexpected journal entries: %+v", entries)
+	}
+}
+
+// loadJournal returns the entries of the only run journaled in dir.
+func loadJournal(t *testing.T, dir string) []journal.Entry {
+	t.Helper()
+	files, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one journal file, got %v", files)
+	}
+	entries, err := journal.Load(dir, strings.TrimSuffix(filepath.Base(files[0]), ".jsonl"))
+	if err != nil {
+		t.Fatalf("Expected no error loading journal, got %v", err)
+	}
+	return entries
+}
+
+func TestConvertConfirmation(t *testing.T) {
+	server := replay.NewServer(t, "testdata/convert_member.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+	f.IsInteractive = func() bool { return true }
+	f.Confirm = func(question string) (bool, error) { return false, nil }
+
+	out, err := runConvert(t, f, "test-org", "-u", "alice", "--add-to-teams")
+	if err == nil || err.Error() != "aborted, no changes made" {
+		t.Fatalf("Expected the conversion to be aborted, got %v", err)
+	}
+	if !strings.Contains(out, "About to add alice to teams: dev and remove 2 direct grants made redundant.") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+	// Only the reads planning the conversion are made
+	if len(server.Requests()) != 6 {
+		t.Errorf("Expected 6 requests, got %d", len(server.Requests()))
+	}
+	if files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl")); len(files) != 0 {
+		t.Errorf("Expected no journal, got %v", files)
+	}
+}
+
+func TestConvertDryRun(t *testing.T) {
+	server := replay.NewServer(t, "testdata/convert_member.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+
+	out, err := runConvert(t, f, "test-org", "-u", "alice", "--add-to-teams", "--dry-run")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, expected := range []string{
+		"Dry run, no changes made, would add alice to teams: dev and remove 2 direct grants made redundant.",
+		"  remove alice from repo1 with write\n",
+		"  remove alice from repo2 with read\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if len(server.Requests()) != 6 {
+		t.Errorf("Expected 6 requests, got %d", len(server.Requests()))
+	}
+	if files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl")); len(files) != 0 {
+		t.Errorf("Expected no journal, got %v", files)
+	}
+}
+
+func TestConvertInvitesWithTeams(t *testing.T) {
+	server := replay.NewServer(t, "testdata/convert_invite.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+
+	out, err := runConvert(t, f, "test-org", "-u", "carol", "--from-file", "testdata/report.csv", "--add-to-teams", "--yes")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if !strings.Contains(out, "Invited carol to test-org as a member of teams: dev.") || !strings.Contains(out, "remove the 2 made redundant") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+	entries := loadJournal(t, f.JournalDir)
+	if len(entries) != 1 || entries[0].Username != "carol" || entries[0].Permission != "member" || entries[0].Status != "added" {
+		t.Errorf("Expected the invitation to be journaled, got %+v", entries)
+	}
+}
+
+func TestConvertPendingInvitation(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/carol"},
+		Response: replay.Response{Status: 200, Body: []byte(`{"state": "pending", "role": "member"}`)},
+	})
+
+	out, err := runConvert(t, factorytest.New(server), "test-org", "-u", "carol")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+	if !strings.Contains(out, "already has a pending invitation") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+}
+
+func TestConvertToOutside(t *testing.T) {
+	server := replay.NewServer(t, "testdata/convert_outside.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+
+	out, err := runConvert(t, f, "test-org", "-u", "alice", "--to", ToOutside, "--yes")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+	if !strings.Contains(out, "Converted alice to an outside collaborator in test-org") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+	entries := loadJournal(t, f.JournalDir)
+	if len(entries) != 1 || entries[0].Permission != "outside_collaborator" || entries[0].PreviousPermission != "member" || entries[0].Status != "updated" {
+		t.Errorf("Expected the conversion to be journaled, got %+v", entries)
+	}
+}
+
+func TestConvertToOutsideNotMember(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/carol"},
+		Response: replay.Response{Status: 404, Body: []byte(`{"message": "Not Found"}`)},
+	})
+
+	_, err := runConvert(t, factorytest.New(server), "test-org", "-u", "carol", "--to", ToOutside)
+	if err == nil || !strings.Contains(err.Error(), "carol is not a member of test-org") {
+		t.Errorf("Expected not a member error, got %v", err)
+	}
+	if len(server.Requests()) != 1 {
+		t.Errorf("Expected only the membership check, got %d requests", len(server.Requests()))
+	}
+}
+
+func TestConvertInvalidDirection(t *testing.T) {
+	server := replay.NewServer(t)
+
+	_, err := runConvert(t, factorytest.New(server), "test-org", "-u", "alice", "--to", "admin")
+	if err == nil || !strings.Contains(err.Error(), "invalid --to") {
+		t.Errorf("Expected invalid --to error, got %v", err)
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no requests, got %d", len(server.Requests()))
+	}
+}
@@ -0,0 +1,171 @@
+package copyrepoaccess
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/cache"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/runner"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// Affiliations are the collaborator filters the collaborators endpoint takes
+var Affiliations = []string{"outside", "direct", "all"}
+
+type cmdFlags struct {
+	fromRepo       string
+	toRepos        []string
+	targetOrg      string
+	affiliation    string
+	allowDowngrade bool
+	dryRun         bool
+	yes            bool
+}
+
+func NewCmdCopyRepoAccess(f *factory.Factory) *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	copyCmd := &cobra.Command{
+		Use:   "copy-repo-access [flags] <organization>",
+		Short: "Copy repository collaborators from one repo to others.",
+		Long:  "Copy the collaborators of a repository, with their permissions, to one or more other repositories in the same or another organization.",
+		Args:  f.OrgArgs,
+		RunE: func(copyCmd *cobra.Command, args []string) error {
+			owner := f.Owner(args)
+			if !validAffiliation(cmdFlags.affiliation) {
+				return fmt.Errorf("invalid --affiliation %q, must be one of: %s", cmdFlags.affiliation, strings.Join(Affiliations, ", "))
+			}
+			targetOwner := cmdFlags.targetOrg
+			if targetOwner == "" {
+				targetOwner = owner
+			}
+			for _, repo := range cmdFlags.toRepos {
+				if targetOwner == owner && repo == cmdFlags.fromRepo {
+					return fmt.Errorf("cannot copy %s to itself", cmdFlags.fromRepo)
+				}
+			}
+
+			sourceGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
+			targetGetter := sourceGetter
+			if targetOwner != owner {
+				// App installations are per organization
+				targetGetter, err = f.NewGetter(targetOwner)
+				if err != nil {
+					return err
+				}
+			}
+
+			grants, err := copyGrants(copyCmd.Context(), owner, &cmdFlags, sourceGetter)
+			if err != nil {
+				return err
+			}
+			if len(grants) == 0 {
+				_, _ = fmt.Fprintf(copyCmd.OutOrStdout(), "No %s collaborators found on %s/%s.\n", cmdFlags.affiliation, owner, cmdFlags.fromRepo)
+				return nil
+			}
+
+			var j *journal.Journal
+			if !cmdFlags.dryRun {
+				if err := f.ConfirmChanges(copyCmd.OutOrStdout(), utils.ChangeSummary("copy", grants), cmdFlags.yes); err != nil {
+					return err
+				}
+				j, err = f.OpenJournal(copyCmd.Context(), "copy-repo-access", targetOwner)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					closeErr := j.Close()
+					if closeErr != nil {
+						zap.S().Warnf("Error closing journal: %v", closeErr)
+					}
+				}()
+			}
+
+			run := &runner.Run{Command: "copy-repo-access", Owner: targetOwner, Exec: f.Executor(), Journal: j, Out: copyCmd.OutOrStdout()}
+			return runCmdCopyRepoAccess(copyCmd.Context(), owner, targetOwner, grants, &cmdFlags, targetGetter, run)
+		},
+	}
+
+	// Configure flags for command
+
+	copyCmd.Flags().StringVar(&cmdFlags.fromRepo, "from-repo", "", "Repository to copy collaborators from (required)")
+	copyCmd.Flags().StringSliceVar(&cmdFlags.toRepos, "to-repo", nil, "Comma separated repositories to copy collaborators to (required)")
+	copyCmd.Flags().StringVar(&cmdFlags.targetOrg, "target-org", "", "Organization of the target repositories (default the source organization)")
+	copyCmd.Flags().StringVar(&cmdFlags.affiliation, "affiliation", "outside", fmt.Sprintf("Collaborators to copy: %s", strings.Join(Affiliations, ", ")))
+	copyCmd.Flags().BoolVar(&cmdFlags.allowDowngrade, "allow-downgrade", false, "Lower a collaborator's existing permission on a target repository")
+	copyCmd.Flags().BoolVar(&cmdFlags.dryRun, "dry-run", false, "Show the changes without applying them")
+	copyCmd.Flags().BoolVarP(&cmdFlags.yes, "yes", "y", false, "Skip the confirmation prompt, required when not running interactively")
+	for _, flag := range []string{"from-repo", "to-repo"} {
+		if err := copyCmd.MarkFlagRequired(flag); err != nil {
+			zap.S().Errorf("Error marking flag '%s' as required: %v", flag, err)
+		}
+	}
+
+	return copyCmd
+}
+
+// copyGrants lists the collaborators of the source repository as the grants
+// to make on each target repository.
+func copyGrants(ctx context.Context, owner string, cmdFlags *cmdFlags, source utils.Getter) ([]data.ImportedRepoCollab, error) {
+	collaborators, err := source.ListRepoCollaborators(cache.Live(ctx), owner, cmdFlags.fromRepo, cmdFlags.affiliation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collaborators of %s/%s: %w", owner, cmdFlags.fromRepo, err)
+	}
+	zap.S().Debugf("Found %d %s collaborators on %s", len(collaborators), cmdFlags.affiliation, cmdFlags.fromRepo)
+
+	var grants []data.ImportedRepoCollab
+	for _, repo := range cmdFlags.toRepos {
+		for _, collaborator := range collaborators {
+			grants = append(grants, data.ImportedRepoCollab{
+				RepositoryName: repo,
+				Username:       collaborator.Login,
+				Permission:     utils.RESTPermission(collaborator.RoleName),
+			})
+		}
+	}
+	return grants, nil
+}
+
+func runCmdCopyRepoAccess(ctx context.Context, owner string, targetOwner string, grants []data.ImportedRepoCollab, cmdFlags *cmdFlags, target utils.Getter, run *runner.Run) error {
+	if cmdFlags.dryRun {
+		target = utils.DryRun(target)
+	} else {
+		target = run.Exec.RateLimited(target)
+	}
+	results, err := run.Apply(ctx, len(grants), utils.CollabRows(grants), func(i int) data.MutationResult {
+		return utils.GrantRepoCollaborator(ctx, targetOwner, grants[i], cmdFlags.allowDowngrade, target)
+	})
+
+	if cmdFlags.dryRun {
+		_, _ = fmt.Fprintf(run.Out, "Dry run, no changes made. Copying %s/%s to %s would:\n", owner, cmdFlags.fromRepo, targetOwner)
+		for _, result := range results {
+			switch result.Status {
+			case utils.StatusAdded:
+				_, _ = fmt.Fprintf(run.Out, "  add %s to %s with %s\n", result.Username, result.RepositoryName, utils.NormalizePermission(result.Permission))
+			case utils.StatusUpdated:
+				_, _ = fmt.Fprintf(run.Out, "  update %s on %s from %s to %s\n", result.Username, result.RepositoryName, result.PreviousPermission, utils.NormalizePermission(result.Permission))
+			}
+		}
+	} else {
+		_, _ = fmt.Fprintf(run.Out, "Copied collaborators of %s/%s to repositories in: %s.\n", owner, cmdFlags.fromRepo, targetOwner)
+	}
+	return run.Report(ctx, results, err)
+}
+
+func validAffiliation(affiliation string) bool {
+	for _, known := range Affiliations {
+		if affiliation == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package copyrepoaccess
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCmdCopyRepoAccess(t *testing.T) {
+	cmd := NewCmdCopyRepoAccess(factory.New())
+
+	if cmd.Use != "copy-repo-access [flags] <organization>" {
+		t.Errorf("Expected Use to be 'copy-repo-access [flags] <organization>', got %s", cmd.Use)
+	}
+	for _, flag := range []string{"from-repo", "to-repo", "target-org", "affiliation", "allow-downgrade", "dry-run", "yes"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("Expected flag '%s' to exist", flag)
+		}
+	}
+	for _, flag := range []string{"from-repo", "to-repo"} {
+		if _, ok := cmd.Flag(flag).Annotations[cobra.BashCompOneRequiredFlag]; !ok {
+			t.Errorf("Expected '%s' flag to be marked as required", flag)
+		}
+	}
+	if cmd.Flag("affiliation").DefValue != "outside" {
+		t.Errorf("Expected default affiliation outside, got %s", cmd.Flag("affiliation").DefValue)
+	}
+}
+
+func runCopy(t *testing.T, f *factory.Factory, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := NewCmdCopyRepoAccess(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestCopyRepoAccess(t *testing.T) {
+	server := replay.NewServer(t, "testdata/copy.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+
+	out, err := runCopy(t, f, "test-org", "--from-repo", "template", "--to-repo", "repo2,repo3", "--yes")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// alice keeps admin on repo3 without --allow-downgrade
+	for _, expected := range []string{
+		"Summary: 1 added, 1 updated, 1 skipped, 1 blocked",
+		"blocked: alice on repo3",
+		"undo with: gh collaborators rollback",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one journal file, got %v", files)
+	}
+	entries, err := journal.Load(f.JournalDir, strings.TrimSuffix(filepath.Base(files[0]), ".jsonl"))
+	if err != nil || len(entries) != 4 || entries[0].Command != "copy-repo-access" || entries[0].Owner != "test-org" {
+		t.Errorf("Unexpected journal entries: %+v, %v", entries, err)
+	}
+}
+
+func TestCopyRepoAccessConfirmation(t *testing.T) {
+	server := replay.NewServer(t, "testdata/copy.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+
+	out, err := runCopy(t, f, "test-org", "--from-repo", "template", "--to-repo", "repo2,repo3")
+	if err == nil || !strings.Contains(err.Error(), "rerun with --yes") {
+		t.Fatalf("Expected confirmation to be required, got %v", err)
+	}
+	if !strings.Contains(out, "About to copy 4 grants for 2 users across 2 repos.") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+	// Only the source collaborators are read
+	if len(server.Requests()) != 1 {
+		t.Errorf("Expected 1 request, got %d", len(server.Requests()))
+	}
+	if entries, _ := os.ReadDir(f.JournalDir); len(entries) != 0 {
+		t.Errorf("Expected no journal files, got %d", len(entries))
+	}
+}
+
+func TestCopyRepoAccessDryRunCrossOrg(t *testing.T) {
+	server := replay.NewServer(t, "testdata/copy_cross_org.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+
+	out, err := runCopy(t, f, "test-org", "--from-repo", "template", "--to-repo", "repo1", "--target-org", "other-org", "--affiliation", "direct", "--dry-run")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if !strings.Contains(out, "Dry run, no changes made.") || !strings.Contains(out, "  add alice to repo1 with maintain\n") {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+	if strings.Contains(out, "Run ID") {
+		t.Errorf("Expected no journal for a dry run, got:\n%s", out)
+	}
+	if entries, _ := os.ReadDir(f.JournalDir); len(entries) != 0 {
+		t.Errorf("Expected no journal files, got %d", len(entries))
+	}
+}
+
+func TestCopyRepoAccessValidation(t *testing.T) {
+	server := replay.NewServer(t)
+
+	for _, args := range [][]string{
+		{"test-org", "--from-repo", "template", "--to-repo", "repo1", "--affiliation", "members"},
+		{"test-org", "--from-repo", "template", "--to-repo", "repo1,template"},
+	} {
+		if _, err := runCopy(t, factorytest.New(server), args...); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no requests, got %d", len(server.Requests()))
+	}
+}
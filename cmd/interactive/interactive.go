@@ -0,0 +1,109 @@
+package interactive
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/report"
+	"github.com/katiem0/gh-collaborators/internal/runner"
+	"github.com/katiem0/gh-collaborators/internal/tui"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// runReview shows the review screen, replaced in tests to drive it without
+// a terminal
+var runReview = tui.Run
+
+type cmdFlags struct {
+	fileName string
+}
+
+func NewCmdInteractive(f *factory.Factory) *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	interactiveCmd := &cobra.Command{
+		Use:   "interactive [flags] <organization>",
+		Short: "Review and change collaborator access in a terminal UI.",
+		Long: "Browse the collaborators in a report generated by list, drill down into their repositories, mark " +
+			"grants for removal or a new permission, then review and apply the pending changes.",
+		Args: f.OrgArgs,
+		RunE: func(interactiveCmd *cobra.Command, args []string) error {
+			owner := f.Owner(args)
+			out := interactiveCmd.OutOrStdout()
+
+			rows, err := report.ReadCSV(cmdFlags.fileName)
+			if err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return fmt.Errorf("no rows found in %s", cmdFlags.fileName)
+			}
+
+			model := tui.NewModel(owner, rows)
+			if err := runReview(os.Stdin, out, model); err != nil {
+				return err
+			}
+			if !model.Apply() {
+				_, _ = fmt.Fprintln(out, "No changes applied.")
+				return nil
+			}
+
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
+
+			j, err := f.OpenJournal(interactiveCmd.Context(), "interactive", owner)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				closeErr := j.Close()
+				if closeErr != nil {
+					zap.S().Warnf("Error closing journal: %v", closeErr)
+				}
+			}()
+
+			run := &runner.Run{Command: "interactive", Owner: owner, Exec: f.Executor(), Journal: j, Out: out}
+			return applyChanges(interactiveCmd.Context(), owner, model.Changes(), apiGetter, run)
+		},
+	}
+
+	// Configure flags for command
+
+	interactiveCmd.Flags().StringVarP(&cmdFlags.fileName, "from-file", "f", "", "Path of a report generated by list to review (required)")
+	err := interactiveCmd.MarkFlagRequired("from-file")
+	if err != nil {
+		zap.S().Errorf("Error marking flag 'from-file' as required: %v", err)
+	}
+
+	return interactiveCmd
+}
+
+// applyChanges applies the confirmed plan. Permission changes were chosen
+// one grant at a time, so lowering a permission is allowed.
+func applyChanges(ctx context.Context, owner string, changes []tui.Change, g utils.Getter, run *runner.Run) error {
+	g = run.Exec.RateLimited(g)
+	row := func(i int) data.MutationResult {
+		return data.MutationResult{RepositoryName: changes[i].Repository, Username: changes[i].Username}
+	}
+	results, err := run.Apply(ctx, len(changes), row, func(i int) data.MutationResult {
+		collab := data.ImportedRepoCollab{
+			RepositoryName: changes[i].Repository,
+			Username:       changes[i].Username,
+		}
+		if changes[i].Removal() {
+			return utils.RevokeRepoCollaborator(ctx, owner, collab, g)
+		}
+		collab.Permission = utils.RESTPermission(changes[i].To)
+		return utils.GrantRepoCollaborator(ctx, owner, collab, true, g)
+	})
+
+	_, _ = fmt.Fprintf(run.Out, "Applied reviewed changes for repository collaborators in: %s.\n", owner)
+	return run.Report(ctx, results, err)
+}
@@ -0,0 +1,83 @@
+package interactive
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"github.com/katiem0/gh-collaborators/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+func TestNewCmdInteractive(t *testing.T) {
+	cmd := NewCmdInteractive(factory.New())
+
+	if cmd.Use != "interactive [flags] <organization>" {
+		t.Errorf("Expected Use to be 'interactive [flags] <organization>', got %s", cmd.Use)
+	}
+	f := cmd.Flag("from-file")
+	if f == nil || f.Shorthand != "f" {
+		t.Fatal("Expected 'from-file' flag with shorthand 'f'")
+	}
+	if _, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; !ok {
+		t.Error("Expected 'from-file' flag to be marked as required")
+	}
+}
+
+// reviewWith replaces the terminal with a fixed sequence of key presses
+func reviewWith(t *testing.T, input string) {
+	t.Helper()
+	original := runReview
+	t.Cleanup(func() { runReview = original })
+	runReview = func(in *os.File, out io.Writer, m *tui.Model) error {
+		for _, key := range tui.ParseKeys([]byte(input)) {
+			if m.Update(key) {
+				return nil
+			}
+		}
+		t.Fatal("Expected the review to end")
+		return nil
+	}
+}
+
+func TestInteractiveApply(t *testing.T) {
+	server := replay.NewServer(t, "testdata/apply.json")
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+	// Remove alice from repo1, lower repo3 to write, confirm
+	reviewWith(t, "\rdj3py")
+
+	var out bytes.Buffer
+	cmd := NewCmdInteractive(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/report.csv"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if !strings.Contains(out.String(), "Summary: 1 updated, 1 removed") || !strings.Contains(out.String(), "undo with: gh collaborators rollback") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestInteractiveQuit(t *testing.T) {
+	server := replay.NewServer(t)
+	reviewWith(t, "\rdq")
+
+	var out bytes.Buffer
+	cmd := NewCmdInteractive(factorytest.New(server))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/report.csv"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "No changes applied.") || len(server.Requests()) != 0 {
+		t.Errorf("Expected nothing applied, got %d requests and output:\n%s", len(server.Requests()), out.String())
+	}
+}
@@ -0,0 +1,130 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// checkpoint records how far a list run got, so an interrupted run can
+// continue without repeating finished users. CurrentUsers is the batch that
+// was being paged through and Cursor the page to continue it from. Offset is
+// the size of the report when the checkpoint was saved, rows written after
+// it are dropped on resume since the checkpoint does not account for them.
+// Repositories holds every repository paged through, so a resumed matrix
+// still has columns for those without collaborators in the report.
+type checkpoint struct {
+	Owner          string    `json:"owner"`
+	Username       string    `json:"username,omitempty"`
+	OutputFile     string    `json:"output_file"`
+	CompletedUsers []string  `json:"completed_users"`
+	CurrentUsers   []string  `json:"current_users,omitempty"`
+	Cursor         string    `json:"cursor,omitempty"`
+	Offset         int64     `json:"offset"`
+	Repositories   []string  `json:"repositories,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	path      string
+	completed map[string]bool
+}
+
+func checkpointPath(outputFile string) string {
+	return outputFile + ".checkpoint.json"
+}
+
+func newCheckpoint(owner string, username string, outputFile string) *checkpoint {
+	return &checkpoint{
+		Owner:          owner,
+		Username:       username,
+		OutputFile:     outputFile,
+		CompletedUsers: []string{},
+		path:           checkpointPath(outputFile),
+		completed:      map[string]bool{},
+	}
+}
+
+func loadCheckpoint(path string) (*checkpoint, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+	cp := new(checkpoint)
+	if err := json.Unmarshal(content, cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	if cp.OutputFile == "" {
+		return nil, fmt.Errorf("checkpoint %s does not name a report file", path)
+	}
+	cp.path = path
+	cp.completed = map[string]bool{}
+	for _, user := range cp.CompletedUsers {
+		cp.completed[user] = true
+	}
+	return cp, nil
+}
+
+func (cp *checkpoint) isCompleted(user string) bool {
+	return cp.completed[user]
+}
+
+func (cp *checkpoint) isCurrent(user string) bool {
+	for _, current := range cp.CurrentUsers {
+		if current == user {
+			return true
+		}
+	}
+	return false
+}
+
+// resumeBatch returns the batch that was in progress and the page cursor to
+// continue it from, no users when the run stopped between batches.
+func (cp *checkpoint) resumeBatch() ([]string, *string) {
+	if len(cp.CurrentUsers) == 0 || cp.Cursor == "" {
+		return nil, nil
+	}
+	cursor := cp.Cursor
+	return cp.CurrentUsers, &cursor
+}
+
+func (cp *checkpoint) pageDone(users []string, cursor string, offset int64, repositories []string) error {
+	cp.CurrentUsers = users
+	cp.Cursor = cursor
+	cp.Offset = offset
+	cp.Repositories = repositories
+	return cp.save()
+}
+
+func (cp *checkpoint) batchDone(users []string, offset int64, repositories []string) error {
+	for _, user := range users {
+		cp.completed[user] = true
+		cp.CompletedUsers = append(cp.CompletedUsers, user)
+	}
+	cp.CurrentUsers = nil
+	cp.Cursor = ""
+	cp.Offset = offset
+	cp.Repositories = repositories
+	return cp.save()
+}
+
+// save replaces the checkpoint file atomically so a crash never leaves a
+// half written checkpoint behind.
+func (cp *checkpoint) save() error {
+	cp.UpdatedAt = time.Now().UTC()
+	content, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := cp.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint %s: %w", cp.path, err)
+	}
+	return os.Rename(tmp, cp.path)
+}
+
+func (cp *checkpoint) remove() error {
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package list
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointSaveAndLoad(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	cp := newCheckpoint("test-org", "", outputFile)
+
+	if err := cp.pageDone([]string{"alice", "bob"}, "cursor1", 120, []string{"repo1"}); err != nil {
+		t.Fatalf("pageDone failed: %v", err)
+	}
+	if err := cp.batchDone([]string{"alice", "bob"}, 180, []string{"repo1", "repo2"}); err != nil {
+		t.Fatalf("batchDone failed: %v", err)
+	}
+	if err := cp.pageDone([]string{"carol"}, "cursor2", 240, []string{"repo1", "repo2"}); err != nil {
+		t.Fatalf("pageDone failed: %v", err)
+	}
+
+	loaded, err := loadCheckpoint(checkpointPath(outputFile))
+	if err != nil {
+		t.Fatalf("loadCheckpoint failed: %v", err)
+	}
+	if loaded.Owner != "test-org" || loaded.OutputFile != outputFile || loaded.Offset != 240 || !reflect.DeepEqual(loaded.Repositories, []string{"repo1", "repo2"}) {
+		t.Errorf("Unexpected checkpoint %+v", loaded)
+	}
+	if !reflect.DeepEqual(loaded.CompletedUsers, []string{"alice", "bob"}) || !loaded.isCompleted("bob") || loaded.isCompleted("carol") {
+		t.Errorf("Expected alice and bob to be completed, got %v", loaded.CompletedUsers)
+	}
+	users, cursor := loaded.resumeBatch()
+	if !reflect.DeepEqual(users, []string{"carol"}) || cursor == nil || *cursor != "cursor2" {
+		t.Errorf("Expected carol to resume from cursor2, got %v %v", users, cursor)
+	}
+	if !loaded.isCurrent("carol") || loaded.isCurrent("alice") {
+		t.Error("Expected only carol to be in the current batch")
+	}
+
+	if err := loaded.batchDone(users, 300, loaded.Repositories); err != nil {
+		t.Fatalf("batchDone failed: %v", err)
+	}
+	if users, cursor := loaded.resumeBatch(); users != nil || cursor != nil {
+		t.Errorf("Expected no batch to resume between batches, got %v %v", users, cursor)
+	}
+
+	info, err := os.Stat(checkpointPath(outputFile))
+	if err != nil {
+		t.Fatalf("Failed to stat checkpoint: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected checkpoint mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if err := loaded.remove(); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if err := loaded.remove(); err != nil {
+		t.Errorf("Expected removing a missing checkpoint to succeed, got %v", err)
+	}
+}
+
+func TestLoadCheckpointErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := loadCheckpoint(filepath.Join(dir, "missing.json")); err == nil || !strings.Contains(err.Error(), "failed to read checkpoint") {
+		t.Errorf("Expected read error, got %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCheckpoint(invalid); err == nil || !strings.Contains(err.Error(), "failed to parse checkpoint") {
+		t.Errorf("Expected parse error, got %v", err)
+	}
+
+	noReport := filepath.Join(dir, "noreport.json")
+	if err := os.WriteFile(noReport, []byte(`{"owner":"test-org"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCheckpoint(noReport); err == nil || !strings.Contains(err.Error(), "does not name a report file") {
+		t.Errorf("Expected missing report error, got %v", err)
+	}
+}
@@ -1,96 +1,85 @@
 package list
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
-	"github.com/katiem0/gh-collaborators/internal/data"
-	"github.com/katiem0/gh-collaborators/internal/log"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/report"
 	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/katiem0/gh-collaborators/internal/version"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	token    string
+	listFile      string
+	username      string
+	resume        string
+	summary       bool
+	summaryFormat string
+	saveSummary   bool
+	format        string
+	matrix        report.MatrixOptions
+
+	// hostname is recorded in the HTML report, it comes from the factory
+	// rather than a flag of its own
 	hostname string
-	listFile string
-	username string
-	debug    bool
 }
 
-func NewCmdList() *cobra.Command {
+func NewCmdList(f *factory.Factory) *cobra.Command {
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	listCmd := &cobra.Command{
 		Use:   "list [flags] <organization>",
 		Short: "Generate a report of repos that repository collaborators have access to.",
 		Long:  "Generate a report of repos that repository collaborators have access to.",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  f.OrgArgs,
 		RunE: func(listCmd *cobra.Command, args []string) error {
-			var err error
-			var gqlClient *api.GraphQLClient
-			var restClient *api.RESTClient
-
-			// Reinitialize logging if debugging was enabled
-			if cmdFlags.debug {
-				logger, _ := log.NewLogger(cmdFlags.debug)
-				defer logger.Sync() // nolint:errcheck
-				zap.ReplaceGlobals(logger)
-			}
+			owner := f.Owner(args)
+			cmdFlags.hostname = f.Hostname
 
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
+			if !listCmd.Flags().Changed("format") && f.Profile != nil && f.Profile.OutputFormat != "" {
+				cmdFlags.format = f.Profile.OutputFormat
 			}
-
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
-			if err != nil {
-				zap.S().Errorf("Error arose retrieving rest client")
+			if err := report.ValidateReportFormat(cmdFlags.format); err != nil {
 				return err
 			}
-
-			gqlClient, err = api.NewGraphQLClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github.hawkgirl-preview+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
-			if err != nil {
-				zap.S().Errorf("Error arose retrieving graphql client")
+			if err := report.ValidateSummaryFormat(cmdFlags.summaryFormat); err != nil {
 				return err
 			}
 
-			owner := args[0]
-
 			// Check if file exists, but don't fail if it doesn't
-			if _, err := os.Stat(cmdFlags.listFile); err == nil {
+			if _, err := os.Stat(cmdFlags.listFile); err == nil && len(cmdFlags.resume) == 0 {
 				return fmt.Errorf("output file %s already exists", cmdFlags.listFile)
 			}
+			// The other formats are written once the report is complete,
+			// check now rather than after listing every collaborator
+			if len(cmdFlags.resume) == 0 {
+				for _, path := range report.FormatPaths(cmdFlags.listFile, cmdFlags.format) {
+					if _, err := os.Stat(path); err == nil {
+						return fmt.Errorf("output file %s already exists", path)
+					}
+				}
+			}
 
-			// Create APIGetter
-			apiGetter := utils.NewAPIGetter(gqlClient, restClient)
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
 
-			// Collect all data first, don't create file yet
-			if err := runCmdList(owner, &cmdFlags, apiGetter); err != nil {
+			if err := runCmdList(listCmd.Context(), owner, &cmdFlags, apiGetter); err != nil {
 				return err
 			}
 			return nil
@@ -100,136 +89,318 @@ func NewCmdList() *cobra.Command {
 	reportFileDefault := fmt.Sprintf("RepoCollaboratorsReport-%s.csv", time.Now().Format("20060102150405"))
 
 	// Configure flags for command
-	listCmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub Personal Access Token (default "gh auth token")`)
-	listCmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	listCmd.Flags().StringVarP(&cmdFlags.listFile, "output-file", "o", reportFileDefault, "Name of file to write CSV list to")
+	listCmd.Flags().StringVarP(&cmdFlags.resume, "resume", "", "", "Path of a checkpoint file to continue an interrupted report from")
+	listCmd.Flags().StringVarP(&cmdFlags.format, "format", "f", report.ReportFormatCSV, fmt.Sprintf("Report format: %s", strings.Join(report.ReportFormats, ", ")))
+	listCmd.Flags().BoolVarP(&cmdFlags.matrix.Transpose, "transpose", "", false, "Put repositories in rows and collaborators in columns of the matrix")
+	listCmd.Flags().BoolVarP(&cmdFlags.matrix.DropEmpty, "drop-empty-repos", "", false, "Leave repositories without outside collaborators out of the matrix")
+	listCmd.Flags().BoolVarP(&cmdFlags.summary, "summary", "", false, "Print summary statistics for the report once it is complete")
+	listCmd.Flags().StringVarP(&cmdFlags.summaryFormat, "summary-format", "", report.FormatTable, fmt.Sprintf("Summary format: %s", strings.Join(report.SummaryFormats, ", ")))
+	listCmd.Flags().BoolVarP(&cmdFlags.saveSummary, "save-summary", "", false, "Write the summary next to the report, implies --summary")
 	listCmd.PersistentFlags().StringVarP(&cmdFlags.username, "username", "u", "", "Username of single repo collaborator to generate report for")
-	listCmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
 
 	return listCmd
 }
 
-func runCmdList(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
-	var reposCursor *string
-	var csvData [][]string
-
-	// Add header to data slice
-	csvData = append(csvData, []string{
-		"RepositoryName",
-		"RepositoryID",
-		"Visibility",
-		"Username",
-		"AccessLevel",
-	})
+func runCmdList(ctx context.Context, owner string, cmdFlags *cmdFlags, g utils.Getter) error {
+	var cp *checkpoint
+	if len(cmdFlags.resume) > 0 {
+		var err error
+		cp, err = loadCheckpoint(cmdFlags.resume)
+		if err != nil {
+			return err
+		}
+		if cp.Owner != owner || cp.Username != cmdFlags.username {
+			return fmt.Errorf("checkpoint %s is for organization %s and username %q, not %s and %q", cmdFlags.resume, cp.Owner, cp.Username, owner, cmdFlags.username)
+		}
+		zap.S().Debugf("Resuming report %s after %d users", cp.OutputFile, len(cp.CompletedUsers))
+	} else {
+		cp = newCheckpoint(owner, cmdFlags.username, cmdFlags.listFile)
+	}
 
 	zap.S().Debugf("Gathering repositories and access for %s", owner)
-	repoCollabList, err := g.GetOrgGuestCollaborators(owner)
+	repoCollaborators, err := g.ListOrgOutsideCollaborators(ctx, owner)
 	if err != nil {
 		zap.S().Errorf("Failed to get organization collaborators for '%s'", owner)
+		var httpErr *api.HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("insufficient permissions: you must be an owner of the organization '%s' to list outside collaborators", owner)
+		}
 		return err
 	}
 
-	var repoCollaborators []data.RepoCollaborators
-	err = json.Unmarshal(repoCollabList, &repoCollaborators)
+	reportFile, err := openReport(cp, len(cmdFlags.resume) > 0)
 	if err != nil {
-		zap.S().Errorf("Failed to parse collaborators data: %v", err)
-		return fmt.Errorf("failed to parse collaborators data: %w", err)
+		return err
 	}
+	defer func() {
+		closeErr := reportFile.close()
+		if closeErr != nil {
+			zap.S().Warnf("Error closing file: %v", closeErr)
+		}
+	}()
 
 	if len(cmdFlags.username) > 0 {
 		zap.S().Debugf("Checking if username %s is in list of repository collaborators", cmdFlags.username)
-		for _, repoCollab := range repoCollaborators {
-			if cmdFlags.username == repoCollab.Login {
-				zap.S().Debugf("Gathering repositories for specified username %s", cmdFlags.username)
-				var allRepoPerms []data.RepoInfo
-				for {
-					repoUserPermissions, err := g.GetOrgRepositoryPermissions(owner, cmdFlags.username, reposCursor)
-					if err != nil {
-						zap.S().Errorf("Failed to get repository permissions for user '%s' in organization '%s': %v", cmdFlags.username, owner, err)
-						return fmt.Errorf("failed to get repository permissions for user %s: %w", cmdFlags.username, err)
-					}
+	}
+	var pending []string
+	for _, repoCollab := range repoCollaborators {
+		if len(cmdFlags.username) > 0 && cmdFlags.username != repoCollab.Login {
+			continue
+		}
+		if cp.isCompleted(repoCollab.Login) || cp.isCurrent(repoCollab.Login) {
+			zap.S().Debugf("Skipping username %s, completed before resuming", repoCollab.Login)
+			continue
+		}
+		pending = append(pending, repoCollab.Login)
+	}
 
-					allRepoPerms = append(allRepoPerms, repoUserPermissions.Organization.Repositories.Nodes...)
-					if !repoUserPermissions.Organization.Repositories.PageInfo.HasNextPage {
-						break
-					}
-					reposCursor = &repoUserPermissions.Organization.Repositories.PageInfo.EndCursor
-				}
-				for _, repo := range allRepoPerms {
-					if len(repo.Collaborators.Edges) > 0 {
-						csvData = append(csvData, []string{
-							repo.Name,
-							strconv.Itoa(repo.DatabaseId),
-							repo.Visibility,
-							cmdFlags.username,
-							repo.Collaborators.Edges[0].Permission,
-						})
-					}
-				}
+	sizer := utils.NewBatchSizer(utils.DefaultBatchSize, utils.MaxBatchSize, utils.DefaultBatchCost)
+	batch, cursor := cp.resumeBatch()
+	for len(batch) > 0 || len(pending) > 0 {
+		if len(batch) == 0 {
+			size := min(sizer.Size(), len(pending))
+			batch, pending = pending[:size], pending[size:]
+		}
+		zap.S().Debugf("Gathering repositories for usernames %s", strings.Join(batch, ", "))
+		if err := streamBatchRows(ctx, owner, batch, cursor, g, sizer, reportFile, cp); err != nil {
+			// The rows written so far stay in the report, along with the
+			// checkpoint to continue from
+			if ctx.Err() != nil {
+				fmt.Printf("Interrupted, partial report saved to: %s\n", cp.OutputFile)
+				fmt.Printf("Resume with: gh collaborators list %s --resume %s\n", owner, cp.path)
+				return fmt.Errorf("list interrupted: %w", ctx.Err())
 			}
+			zap.S().Errorf("Failed to get repository permissions for users '%s' in organization '%s': %v", strings.Join(batch, ", "), owner, err)
+			fmt.Printf("Resume with: gh collaborators list %s --resume %s\n", owner, cp.path)
+			return fmt.Errorf("failed to get repository permissions for users %s: %w", strings.Join(batch, ", "), err)
 		}
-	} else {
-		for _, repoCollab := range repoCollaborators {
-			zap.S().Debugf("Gathering repositories for username %s", repoCollab.Login)
-			var allRepoPerms []data.RepoInfo
-			for {
-				repoUserPermissions, err := g.GetOrgRepositoryPermissions(owner, repoCollab.Login, reposCursor)
-				if err != nil {
-					zap.S().Errorf("Failed to get repository permissions for user '%s' in organization '%s': %v", repoCollab.Login, owner, err)
-					return fmt.Errorf("failed to get repository permissions for user %s: %w", repoCollab.Login, err)
-				}
+		batch, cursor = nil, nil
+	}
 
-				allRepoPerms = append(allRepoPerms, repoUserPermissions.Organization.Repositories.Nodes...)
-				if !repoUserPermissions.Organization.Repositories.PageInfo.HasNextPage {
-					break
-				}
-				reposCursor = &repoUserPermissions.Organization.Repositories.PageInfo.EndCursor
-			}
-			for _, repo := range allRepoPerms {
-				if len(repo.Collaborators.Edges) > 0 {
-					csvData = append(csvData, []string{
+	if reportFile.empty() {
+		// Only header, no actual data
+		if err := reportFile.discard(); err != nil {
+			zap.S().Warnf("Error removing empty report: %v", err)
+		}
+		if err := cp.remove(); err != nil {
+			zap.S().Warnf("Error removing checkpoint: %v", err)
+		}
+		return fmt.Errorf("no collaborator data found for organization %s", owner)
+	}
+	if err := cp.remove(); err != nil {
+		zap.S().Warnf("Error removing checkpoint: %v", err)
+	}
+
+	fmt.Printf("Successfully listed repository collaborator permissions for repositories in %s\n", owner)
+	fmt.Printf("Report saved to: %s\n", cp.OutputFile)
+
+	metadata := report.Metadata{
+		Organization: owner,
+		Hostname:     cmdFlags.hostname,
+		GeneratedAt:  time.Now().UTC(),
+		Version:      version.String(),
+	}
+	switch cmdFlags.format {
+	case report.ReportFormatMatrix:
+		written, err := report.WriteMatrixFiles(cp.OutputFile, reportFile.seenRepositories(), cmdFlags.matrix)
+		if err != nil {
+			return fmt.Errorf("failed to write permission matrix: %w", err)
+		}
+		for _, path := range written {
+			fmt.Printf("Matrix saved to: %s\n", path)
+		}
+	case report.ReportFormatHTML:
+		htmlPath, err := report.WriteHTMLFile(cp.OutputFile, metadata)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("HTML report saved to: %s\n", htmlPath)
+	case report.ReportFormatMarkdown:
+		markdownPath, err := report.WriteMarkdownFile(cp.OutputFile, metadata)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Markdown report saved to: %s\n", markdownPath)
+	}
+
+	// Running in GitHub Actions, add the summary to the job page
+	if stepSummary := os.Getenv("GITHUB_STEP_SUMMARY"); stepSummary != "" {
+		if err := report.AppendStepSummary(stepSummary, cp.OutputFile, metadata); err != nil {
+			zap.S().Warnf("Error writing step summary: %v", err)
+		}
+	}
+
+	if cmdFlags.summary || cmdFlags.saveSummary {
+		fmt.Println()
+		saved, err := report.SummarizeReport(cp.OutputFile, cmdFlags.summaryFormat, report.DefaultTop, cmdFlags.saveSummary, os.Stdout)
+		if err != nil {
+			return fmt.Errorf("failed to summarize report: %w", err)
+		}
+		if saved != "" {
+			fmt.Printf("Summary saved to: %s\n", saved)
+		}
+	}
+
+	return nil
+}
+
+// streamBatchRows pages through the organization's repositories checking a
+// batch of users at once, writing a report row for each repository a user is
+// a collaborator on as every page arrives and checkpointing after it. The
+// cost of each page sizes the batches that follow.
+func streamBatchRows(ctx context.Context, owner string, users []string, reposCursor *string, g utils.Getter, sizer *utils.BatchSizer, reportFile *reportWriter, cp *checkpoint) error {
+	for {
+		repoUserPermissions, err := g.GetOrgRepositoryPermissionsBatch(ctx, owner, users, reposCursor)
+		if err != nil {
+			return err
+		}
+		sizer.Observe(len(users), repoUserPermissions.RateLimit.Cost)
+
+		var rows [][]string
+		for _, repo := range repoUserPermissions.Organization.Repositories.Nodes {
+			reportFile.repositories[repo.Name] = true
+			for i, username := range users {
+				if permission, ok := utils.BatchPermission(repo, i, username); ok {
+					rows = append(rows, []string{
 						repo.Name,
 						strconv.Itoa(repo.DatabaseId),
 						repo.Visibility,
-						repoCollab.Login,
-						repo.Collaborators.Edges[0].Permission,
+						username,
+						permission,
 					})
 				}
 			}
 		}
-	}
+		offset, err := reportFile.write(rows)
+		if err != nil {
+			return err
+		}
 
-	// Only create and write to file after all data is successfully collected
-	if len(csvData) <= 1 { // Only header, no actual data
-		return fmt.Errorf("no collaborator data found for organization %s", owner)
+		pageInfo := repoUserPermissions.Organization.Repositories.PageInfo
+		if !pageInfo.HasNextPage {
+			return cp.batchDone(users, offset, reportFile.seenRepositories())
+		}
+		endCursor := pageInfo.EndCursor
+		if err := cp.pageDone(users, endCursor, offset, reportFile.seenRepositories()); err != nil {
+			return err
+		}
+		reposCursor = &endCursor
 	}
+}
 
-	zap.S().Debugf("Creating output file %s", cmdFlags.listFile)
-	reportWriter, err := os.OpenFile(cmdFlags.listFile, os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+type reportWriter struct {
+	file       *os.File
+	csvWriter  *csv.Writer
+	headerSize int64
+	offset     int64
+
+	// repositories holds every repository paged through, including ones no
+	// collaborator in the report can access
+	repositories map[string]bool
+}
+
+// openReport creates the report with its header, or when resuming reopens it
+// and drops anything written after the checkpoint was saved.
+func openReport(cp *checkpoint, resume bool) (*reportWriter, error) {
+	var header bytes.Buffer
+	headerWriter := csv.NewWriter(&header)
+	_ = headerWriter.Write(report.Header)
+	headerWriter.Flush()
+	reportFile := &reportWriter{
+		headerSize:   int64(header.Len()),
+		repositories: map[string]bool{},
 	}
-	defer func() {
-		closeErr := reportWriter.Close()
-		if closeErr != nil {
-			zap.S().Warnf("Error closing file: %v", closeErr)
+
+	if resume {
+		zap.S().Debugf("Reopening output file %s at offset %d", cp.OutputFile, cp.Offset)
+		file, err := os.OpenFile(cp.OutputFile, os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open output file to resume: %w", err)
 		}
-	}()
+		if err := file.Truncate(cp.Offset); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to rewind output file: %w", err)
+		}
+		if _, err := file.Seek(cp.Offset, io.SeekStart); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to rewind output file: %w", err)
+		}
+		reportFile.file = file
+		reportFile.csvWriter = csv.NewWriter(file)
+		reportFile.offset = cp.Offset
+		for _, repo := range cp.Repositories {
+			reportFile.repositories[repo] = true
+		}
+		return reportFile, nil
+	}
 
-	csvWriter := csv.NewWriter(reportWriter)
-	defer csvWriter.Flush()
+	zap.S().Debugf("Creating output file %s", cp.OutputFile)
+	file, err := os.OpenFile(cp.OutputFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	reportFile.file = file
+	reportFile.csvWriter = csv.NewWriter(file)
+	offset, err := reportFile.write([][]string{report.Header})
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	cp.Offset = offset
+	if err := cp.save(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return reportFile, nil
+}
 
-	// Write all collected data to CSV
-	for _, row := range csvData {
-		err = csvWriter.Write(row)
-		if err != nil {
+// write appends rows and flushes them to the file, returning the new size of
+// the report.
+func (r *reportWriter) write(rows [][]string) (int64, error) {
+	for _, row := range rows {
+		if err := r.csvWriter.Write(row); err != nil {
 			zap.S().Error("Error raised in writing output", zap.Error(err))
-			return fmt.Errorf("failed to write CSV data: %w", err)
+			return 0, fmt.Errorf("failed to write CSV data: %w", err)
 		}
 	}
+	r.csvWriter.Flush()
+	if err := r.csvWriter.Error(); err != nil {
+		return 0, fmt.Errorf("failed to write CSV data: %w", err)
+	}
+	offset, err := r.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	r.offset = offset
+	return offset, nil
+}
 
-	fmt.Printf("Successfully listed repository collaborator permissions for repositories in %s\n", owner)
-	fmt.Printf("Report saved to: %s\n", cmdFlags.listFile)
+func (r *reportWriter) seenRepositories() []string {
+	repositories := make([]string, 0, len(r.repositories))
+	for repo := range r.repositories {
+		repositories = append(repositories, repo)
+	}
+	sort.Strings(repositories)
+	return repositories
+}
 
-	return nil
+func (r *reportWriter) empty() bool {
+	return r.offset <= r.headerSize
+}
+
+func (r *reportWriter) discard() error {
+	path := r.file.Name()
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	return os.Remove(path)
+}
+
+func (r *reportWriter) close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
 }
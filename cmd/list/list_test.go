@@ -1,15 +1,35 @@
 package list
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/replay"
 )
 
+func TestMain(m *testing.M) {
+	// Keep runs in GitHub Actions from adding test reports to the job summary
+	_ = os.Unsetenv("GITHUB_STEP_SUMMARY")
+	os.Exit(m.Run())
+}
+
 func TestNewCmdList(t *testing.T) {
-	cmd := NewCmdList()
+	cmd := NewCmdList(factory.New())
 
 	if cmd == nil {
-		t.Fatal("NewCmdList() returned nil")
+		t.Fatal("NewCmdList(factory.New()) returned nil")
 	}
 
 	if cmd.Use != "list [flags] <organization>" {
@@ -28,15 +48,12 @@ func TestNewCmdList(t *testing.T) {
 }
 
 func TestListCommandFlags(t *testing.T) {
-	cmd := NewCmdList()
+	cmd := NewCmdList(factory.New())
 
 	// Test that all expected flags exist - using actual flag names from the implementation
 	expectedFlags := map[string]string{
-		"token":       "t",
-		"hostname":    "",
 		"username":    "u",
 		"output-file": "o",
-		"debug":       "d",
 	}
 
 	for flag, shorthand := range expectedFlags {
@@ -53,7 +70,7 @@ func TestListCommandFlags(t *testing.T) {
 }
 
 func TestListCommandRequiredFlags(t *testing.T) {
-	cmd := NewCmdList()
+	cmd := NewCmdList(factory.New())
 
 	// The output-file flag has a default value so it's not marked as required
 	// Let's test that it exists and has a default value
@@ -69,19 +86,7 @@ func TestListCommandRequiredFlags(t *testing.T) {
 }
 
 func TestListCommandDefaultValues(t *testing.T) {
-	cmd := NewCmdList()
-
-	// Check default hostname
-	hostnameFlag := cmd.Flag("hostname")
-	if hostnameFlag != nil && hostnameFlag.DefValue != "github.com" {
-		t.Errorf("Expected default hostname to be 'github.com', got %s", hostnameFlag.DefValue)
-	}
-
-	// Check default debug value
-	debugFlag := cmd.Flag("debug")
-	if debugFlag != nil && debugFlag.DefValue != "false" {
-		t.Errorf("Expected default debug to be 'false', got %s", debugFlag.DefValue)
-	}
+	cmd := NewCmdList(factory.New())
 
 	// Check that output-file has a default value
 	outputFlag := cmd.Flag("output-file")
@@ -94,29 +99,12 @@ func TestListCommandDefaultValues(t *testing.T) {
 	if usernameFlag != nil && usernameFlag.DefValue != "" {
 		t.Errorf("Expected default username to be empty, got %s", usernameFlag.DefValue)
 	}
-
-	// Check that token flag has empty default
-	tokenFlag := cmd.Flag("token")
-	if tokenFlag != nil && tokenFlag.DefValue != "" {
-		t.Errorf("Expected default token to be empty, got %s", tokenFlag.DefValue)
-	}
 }
 
 func TestCmdFlagsStruct(t *testing.T) {
 	flags := cmdFlags{
-		token:    "test-token",
-		hostname: "github.enterprise.com",
 		listFile: "test-output.csv",
 		username: "testuser",
-		debug:    true,
-	}
-
-	if flags.token != "test-token" {
-		t.Errorf("Expected token to be 'test-token', got %s", flags.token)
-	}
-
-	if flags.hostname != "github.enterprise.com" {
-		t.Errorf("Expected hostname to be 'github.enterprise.com', got %s", flags.hostname)
 	}
 
 	if flags.listFile != "test-output.csv" {
@@ -126,14 +114,10 @@ func TestCmdFlagsStruct(t *testing.T) {
 	if flags.username != "testuser" {
 		t.Errorf("Expected username to be 'testuser', got %s", flags.username)
 	}
-
-	if !flags.debug {
-		t.Error("Expected debug to be true")
-	}
 }
 
 func TestListCommandArgsValidation(t *testing.T) {
-	cmd := NewCmdList()
+	cmd := NewCmdList(factory.New())
 
 	// Test with no args - should fail
 	cmd.SetArgs([]string{})
@@ -156,10 +140,471 @@ func TestListCommandArgsValidation(t *testing.T) {
 }
 
 func TestListCommandLongDescription(t *testing.T) {
-	cmd := NewCmdList()
+	cmd := NewCmdList(factory.New())
 
 	// Check the long description
 	if cmd.Long != "Generate a report of repos that repository collaborators have access to." {
 		t.Errorf("Expected Long description 'Generate a report of repos that repository collaborators have access to.', got %s", cmd.Long)
 	}
 }
+
+func TestListCommandUsesRootFlags(t *testing.T) {
+	cmd := NewCmdList(factory.New())
+
+	// Authentication, hostname and debug flags are inherited from the root command
+	for _, flagName := range []string{"token", "hostname", "debug", "app-id", "private-key-path", "installation-id"} {
+		if cmd.Flags().Lookup(flagName) != nil {
+			t.Errorf("Expected '%s' to be defined on the root command, not on list", flagName)
+		}
+	}
+}
+
+func readReport(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open report: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	return rows
+}
+
+func TestListEndToEndPaginated(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	expected := [][]string{
+		{"RepositoryName", "RepositoryID", "Visibility", "Username", "AccessLevel"},
+		{"repo1", "101", "PRIVATE", "alice", "WRITE"},
+		{"repo1", "101", "PRIVATE", "bob", "READ"},
+		{"repo3", "103", "PUBLIC", "alice", "ADMIN"},
+	}
+	if rows := readReport(t, outputFile); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected report %v, got %v", expected, rows)
+	}
+
+	// Both users are checked in one query per page of repositories
+	if requests := len(server.Requests()); requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+}
+
+func TestListEndToEndSaveSummary(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile, "--save-summary", "--summary-format", "json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, err := os.ReadFile(strings.TrimSuffix(outputFile, ".csv") + "-summary.json")
+	if err != nil {
+		t.Fatalf("Expected summary next to the report, got %v", err)
+	}
+	if !strings.Contains(string(content), `"collaborators": 2`) || !strings.Contains(string(content), `"admin_grants": 1`) {
+		t.Errorf("Unexpected summary:\n%s", content)
+	}
+}
+
+func TestListInvalidSummaryFormat(t *testing.T) {
+	server := replay.NewServer(t)
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", filepath.Join(t.TempDir(), "report.csv"), "--summary-format", "yaml"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown summary format") {
+		t.Errorf("Expected unknown format error, got %v", err)
+	}
+}
+
+func TestListEndToEndMatrix(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected [][]string
+	}{
+		{
+			name: "default",
+			expected: [][]string{
+				{"Username", "repo1", "repo2", "repo3"},
+				{"alice", "WRITE", "", "ADMIN"},
+				{"bob", "READ", "", ""},
+			},
+		},
+		{
+			name: "transposed without empty repositories",
+			args: []string{"--transpose", "--drop-empty-repos"},
+			expected: [][]string{
+				{"RepositoryName", "alice", "bob"},
+				{"repo1", "WRITE", "READ"},
+				{"repo3", "ADMIN", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := replay.NewServer(t, "testdata/list_paginated.json")
+			outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+			cmd := NewCmdList(factorytest.New(server))
+			cmd.SetArgs(append([]string{"test-org", "--output-file", outputFile, "--format", "matrix"}, tt.args...))
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			matrixFile := strings.TrimSuffix(outputFile, ".csv") + "-matrix.csv"
+			if rows := readReport(t, matrixFile); !reflect.DeepEqual(rows, tt.expected) {
+				t.Errorf("Expected matrix %v, got %v", tt.expected, rows)
+			}
+			if _, err := os.Stat(strings.TrimSuffix(outputFile, ".csv") + "-matrix.xlsx"); err != nil {
+				t.Errorf("Expected XLSX matrix, got %v", err)
+			}
+		})
+	}
+}
+
+func TestListEndToEndHTML(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile, "--format", "html"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, err := os.ReadFile(strings.TrimSuffix(outputFile, ".csv") + ".html")
+	if err != nil {
+		t.Fatalf("Expected HTML report next to the CSV, got %v", err)
+	}
+	for _, expected := range []string{"<dt>Organization</dt><dd>test-org</dd>", "<dt>Host</dt><dd>github.com</dd>", "<td>repo3</td>"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected HTML report to contain %q", expected)
+		}
+	}
+}
+
+func TestListExistingFormatFile(t *testing.T) {
+	server := replay.NewServer(t)
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	matrixFile := strings.TrimSuffix(outputFile, ".csv") + "-matrix.xlsx"
+	if err := os.WriteFile(matrixFile, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile, "--format", "matrix"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), matrixFile+" already exists") {
+		t.Errorf("Expected the existing matrix to be refused, got %v", err)
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no requests, got %d", len(server.Requests()))
+	}
+}
+
+func TestListEndToEndMarkdownStepSummary(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "report.csv")
+	stepSummary := filepath.Join(dir, "step_summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", stepSummary)
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile, "--format", "markdown"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	markdown, err := os.ReadFile(filepath.Join(dir, "report.md"))
+	if err != nil {
+		t.Fatalf("Expected markdown report next to the CSV, got %v", err)
+	}
+	if !strings.Contains(string(markdown), "<summary><strong>alice</strong>: 2 repositories (1 admin)</summary>") {
+		t.Errorf("Expected a section for alice, got:\n%s", markdown)
+	}
+
+	summary, err := os.ReadFile(stepSummary)
+	if err != nil {
+		t.Fatalf("Expected a step summary, got %v", err)
+	}
+	if !strings.Contains(string(summary), "| Outside collaborators | 2 |") || strings.Contains(string(summary), "<details>") {
+		t.Errorf("Expected a condensed step summary, got:\n%s", summary)
+	}
+}
+
+func TestListFormatFromProfile(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	f := factorytest.New(server)
+	f.Profile = &config.Profile{OutputFormat: "matrix"}
+	cmd := NewCmdList(f)
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(outputFile, ".csv") + "-matrix.csv"); err != nil {
+		t.Errorf("Expected the profile's output format to be used, got %v", err)
+	}
+}
+
+func TestListInvalidFormat(t *testing.T) {
+	server := replay.NewServer(t)
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", filepath.Join(t.TempDir(), "report.csv"), "--format", "pdf"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown report format") {
+		t.Errorf("Expected unknown format error, got %v", err)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// interruptOn returns a factory whose requests are cancelled, as an
+// interrupt would, once a request body contains match.
+func interruptOn(ctx context.Context, cancel context.CancelFunc, server *replay.Server, match string) *factory.Factory {
+	f := factorytest.New(server)
+	base := f.Transport
+	f.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Body != nil {
+			body, _ := io.ReadAll(req.Body)
+			if strings.Contains(string(body), match) {
+				cancel()
+				return nil, ctx.Err()
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		return base.RoundTrip(req)
+	})
+	return f
+}
+
+// interruptSecondPage runs list until the second page of repositories is
+// requested, leaving the report and checkpoint of the first page behind.
+func interruptSecondPage(t *testing.T, outputFile string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	cmd := NewCmdList(interruptOn(ctx, cancel, server, `"endCursor":"Y3Vyc29yOnYyOpHOAAE="`))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile})
+	if err := cmd.ExecuteContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected interrupted error, got %v", err)
+	}
+}
+
+func TestListEndToEndInterrupted(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	interruptSecondPage(t, outputFile)
+
+	// The first page was written before the interrupt, so its rows are kept
+	expected := [][]string{
+		{"RepositoryName", "RepositoryID", "Visibility", "Username", "AccessLevel"},
+		{"repo1", "101", "PRIVATE", "alice", "WRITE"},
+		{"repo1", "101", "PRIVATE", "bob", "READ"},
+	}
+	if rows := readReport(t, outputFile); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected partial report %v, got %v", expected, rows)
+	}
+
+	cp, err := loadCheckpoint(checkpointPath(outputFile))
+	if err != nil {
+		t.Fatalf("Expected a checkpoint to be kept, got %v", err)
+	}
+	if !reflect.DeepEqual(cp.CurrentUsers, []string{"alice", "bob"}) || cp.Cursor != "Y3Vyc29yOnYyOpHOAAE=" || len(cp.CompletedUsers) != 0 || !reflect.DeepEqual(cp.Repositories, []string{"repo1", "repo2"}) {
+		t.Errorf("Expected checkpoint after the first page, got %+v", cp)
+	}
+}
+
+func TestListEndToEndResume(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	interruptSecondPage(t, outputFile)
+
+	// Resuming requests only the second page
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--resume", checkpointPath(outputFile)})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected resumed run to succeed, got %v", err)
+	}
+	if unused := server.Unused(); len(unused) != 1 {
+		t.Errorf("Expected only the first page to be skipped, got %d unused interactions", len(unused))
+	}
+
+	expected := [][]string{
+		{"RepositoryName", "RepositoryID", "Visibility", "Username", "AccessLevel"},
+		{"repo1", "101", "PRIVATE", "alice", "WRITE"},
+		{"repo1", "101", "PRIVATE", "bob", "READ"},
+		{"repo3", "103", "PUBLIC", "alice", "ADMIN"},
+	}
+	if rows := readReport(t, outputFile); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected report %v, got %v", expected, rows)
+	}
+	if _, err := os.Stat(checkpointPath(outputFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint to be removed after a complete run, got %v", err)
+	}
+}
+
+func TestListEndToEndResumeMatrix(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	interruptSecondPage(t, outputFile)
+
+	// repo2 was on the page before the interrupt with no collaborators, the
+	// checkpoint keeps it as a column
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--resume", checkpointPath(outputFile), "--format", "matrix"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected resumed run to succeed, got %v", err)
+	}
+
+	expected := [][]string{
+		{"Username", "repo1", "repo2", "repo3"},
+		{"alice", "WRITE", "", "ADMIN"},
+		{"bob", "READ", "", ""},
+	}
+	if rows := readReport(t, strings.TrimSuffix(outputFile, ".csv")+"-matrix.csv"); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected matrix %v, got %v", expected, rows)
+	}
+}
+
+func TestListEndToEndResumeSkipsCompletedUsers(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	report := "RepositoryName,RepositoryID,Visibility,Username,AccessLevel\nrepo1,101,PRIVATE,alice,WRITE\n"
+	// A row written after the checkpoint was saved is dropped on resume
+	if err := os.WriteFile(outputFile, []byte(report+"repo9,109,PRIVATE,alice,READ\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cp := newCheckpoint("test-org", "", outputFile)
+	if err := cp.batchDone([]string{"alice"}, int64(len(report)), []string{"repo1"}); err != nil {
+		t.Fatalf("Failed to save checkpoint: %v", err)
+	}
+
+	server := replay.NewServer(t, "testdata/list_single_user.json")
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--resume", checkpointPath(outputFile)})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected resumed run to succeed, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	expected := [][]string{
+		{"RepositoryName", "RepositoryID", "Visibility", "Username", "AccessLevel"},
+		{"repo1", "101", "PRIVATE", "alice", "WRITE"},
+		{"repo1", "101", "PRIVATE", "bob", "READ"},
+	}
+	if rows := readReport(t, outputFile); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected report %v, got %v", expected, rows)
+	}
+}
+
+func TestListResumeWrongOrganization(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+	cp := newCheckpoint("other-org", "", outputFile)
+	if err := cp.save(); err != nil {
+		t.Fatalf("Failed to save checkpoint: %v", err)
+	}
+
+	server := replay.NewServer(t, "testdata/list_paginated.json")
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--resume", checkpointPath(outputFile)})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "is for organization other-org") {
+		t.Errorf("Expected organization mismatch error, got %v", err)
+	}
+}
+
+func TestListEndToEndSingleUser(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_single_user.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile, "--username", "bob"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	rows := readReport(t, outputFile)
+	if len(rows) != 2 || rows[1][3] != "bob" {
+		t.Errorf("Expected only bob's access in the report, got %v", rows)
+	}
+}
+
+func TestListEndToEndPrefixMatch(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_prefix_match.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// The search for al ranks alice ahead of al, each user's own edge is
+	// reported
+	expected := [][]string{
+		{"RepositoryName", "RepositoryID", "Visibility", "Username", "AccessLevel"},
+		{"repo1", "101", "PRIVATE", "al", "READ"},
+		{"repo1", "101", "PRIVATE", "Alice", "WRITE"},
+	}
+	if rows := readReport(t, outputFile); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected report %v, got %v", expected, rows)
+	}
+}
+
+func TestListEndToEndNotOwner(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_not_owner.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "insufficient permissions") {
+		t.Errorf("Expected insufficient permissions error, got %v", err)
+	}
+	if _, statErr := os.Stat(outputFile); statErr == nil {
+		t.Error("Expected no report to be written")
+	}
+}
+
+func TestListEndToEndGraphQLError(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_graphql_error.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "failed to get repository permissions for users alice") {
+		t.Errorf("Expected repository permissions error, got %v", err)
+	}
+}
+
+func TestListEndToEndNoCollaborators(t *testing.T) {
+	server := replay.NewServer(t, "testdata/list_empty.json")
+	outputFile := filepath.Join(t.TempDir(), "report.csv")
+
+	cmd := NewCmdList(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--output-file", outputFile})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "no collaborator data found") {
+		t.Errorf("Expected no collaborator data error, got %v", err)
+	}
+}
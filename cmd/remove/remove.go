@@ -1,92 +1,74 @@
 package remove
 
 import (
-	"encoding/csv"
+	"context"
 	"fmt"
-	"os"
 
-	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/katiem0/gh-collaborators/internal/data"
-	"github.com/katiem0/gh-collaborators/internal/log"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/runner"
 	"github.com/katiem0/gh-collaborators/internal/utils"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	token    string
-	hostname string
 	fileName string
-	debug    bool
+	yes      bool
 }
 
-func NewCmdRemove() *cobra.Command {
+func NewCmdRemove(f *factory.Factory) *cobra.Command {
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	removeCmd := &cobra.Command{
 		Use:   "remove [flags] <organization>",
 		Short: "Remove repo access for repository collaborators.",
 		Long:  "Remove repositories and permissions for repository collaborators.",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  f.OrgArgs,
 		RunE: func(removeCmd *cobra.Command, args []string) error {
-			var err error
-			var gqlClient *api.GraphQLClient
-			var restClient *api.RESTClient
+			owner := f.Owner(args)
 
-			// Reinitialize logging if debugging was enabled
-			if cmdFlags.debug {
-				logger, _ := log.NewLogger(cmdFlags.debug)
-				defer logger.Sync() // nolint:errcheck
-				zap.ReplaceGlobals(logger)
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
 			}
 
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
+			collabs, err := utils.ReadCollaborators(cmdFlags.fileName, apiGetter.DeleteRepoCollaboratorsList)
+			if err != nil {
+				return err
+			}
+			if err := f.ConfirmChanges(removeCmd.OutOrStdout(), utils.ChangeSummary("remove", collabs), cmdFlags.yes); err != nil {
+				return err
 			}
 
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			// Issues and comments opened by the notifier count against the
+			// same rate limits as the run's changes
+			exec := f.Executor()
+			notifier, err := f.Notifier(exec.RateLimited(apiGetter))
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving rest client")
 				return err
 			}
 
-			gqlClient, err = api.NewGraphQLClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github.hawkgirl-preview+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			j, err := f.OpenJournal(removeCmd.Context(), "remove", owner)
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving graphql client")
 				return err
 			}
-
-			owner := args[0]
-
-			return runCmdRemove(owner, &cmdFlags, utils.NewAPIGetter(gqlClient, restClient))
+			defer func() {
+				closeErr := j.Close()
+				if closeErr != nil {
+					zap.S().Warnf("Error closing journal: %v", closeErr)
+				}
+			}()
+
+			run := &runner.Run{Command: "remove", Owner: owner, Exec: exec, Journal: j, Notifier: notifier, Out: removeCmd.OutOrStdout()}
+			return runCmdRemove(removeCmd.Context(), owner, collabs, &cmdFlags, apiGetter, run)
 		},
 	}
 
 	// Configure flags for command
 
-	removeCmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub Personal Access Token (default "gh auth token")`)
-	removeCmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	removeCmd.Flags().StringVarP(&cmdFlags.fileName, "from-file", "f", "", "Path and Name of CSV file to remove access from (required)")
-	removeCmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
+	removeCmd.Flags().BoolVarP(&cmdFlags.yes, "yes", "y", false, "Skip the confirmation prompt, required when not running interactively")
 	err := removeCmd.MarkFlagRequired("from-file")
 	if err != nil {
 		zap.S().Errorf("Error marking flag 'from-file' as required: %v", err)
@@ -95,44 +77,13 @@ func NewCmdRemove() *cobra.Command {
 	return removeCmd
 }
 
-func runCmdRemove(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
-	var collabData [][]string
-	var importRepoCollabList []data.ImportedRepoCollab
-
-	if len(cmdFlags.fileName) > 0 {
-		f, err := os.Open(cmdFlags.fileName)
-		zap.S().Debugf("Opening up file %s", cmdFlags.fileName)
-		if err != nil {
-			zap.S().Errorf("Error arose opening repository collaborators csv file")
-		}
-		// remember to close the file at the end of the program
-		defer func() {
-			closeErr := f.Close()
-			if closeErr != nil {
-				zap.S().Warnf("Error closing file: %v", closeErr)
-			}
-		}()
-		// read csv values using csv.Reader
-		csvReader := csv.NewReader(f)
-		collabData, err = csvReader.ReadAll()
-		zap.S().Debugf("Reading in all lines from csv file")
-		if err != nil {
-			zap.S().Errorf("Error arose reading collaborators to remove from csv file")
-		}
-		importRepoCollabList = g.DeleteRepoCollaboratorsList(collabData)
-	} else {
-		zap.S().Errorf("Error arose identifying users to add")
-	}
+func runCmdRemove(ctx context.Context, owner string, importRepoCollabList []data.ImportedRepoCollab, cmdFlags *cmdFlags, g utils.Getter, run *runner.Run) error {
 	zap.S().Debugf("Determining users to remove")
-	for _, importRepoCollab := range importRepoCollabList {
-		zap.S().Debugf("Removing Repository Assignment for %s", importRepoCollab.Username)
-
-		err := g.RemoveRepoCollaborator(owner, importRepoCollab.RepositoryName, importRepoCollab.Username)
-		if err != nil {
-			zap.S().Errorf("Error arose removing permission for user %s  and repo %s", importRepoCollab.Username, importRepoCollab.RepositoryName)
-		}
-	}
+	g = run.Exec.RateLimited(g)
+	results, err := run.Apply(ctx, len(importRepoCollabList), utils.CollabRows(importRepoCollabList), func(i int) data.MutationResult {
+		return utils.RevokeRepoCollaborator(ctx, owner, importRepoCollabList[i], g)
+	})
 
-	fmt.Printf("Successfully removed repository assignments for repository collaborators in: %s.", owner)
-	return nil
+	_, _ = fmt.Fprintf(run.Out, "Processed repository assignment removals for repository collaborators in: %s.\n", owner)
+	return run.Report(ctx, results, err)
 }
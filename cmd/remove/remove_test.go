@@ -1,16 +1,24 @@
 package remove
 
 import (
+	"bytes"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/replay"
 	"github.com/spf13/cobra"
 )
 
 func TestNewCmdRemove(t *testing.T) {
-	cmd := NewCmdRemove()
+	cmd := NewCmdRemove(factory.New())
 
 	if cmd == nil {
-		t.Fatal("NewCmdRemove() returned nil")
+		t.Fatal("NewCmdRemove(factory.New()) returned nil")
 	}
 
 	if cmd.Use != "remove [flags] <organization>" {
@@ -29,14 +37,12 @@ func TestNewCmdRemove(t *testing.T) {
 }
 
 func TestRemoveCommandFlags(t *testing.T) {
-	cmd := NewCmdRemove()
+	cmd := NewCmdRemove(factory.New())
 
 	// Test that all expected flags exist
 	expectedFlags := map[string]string{
-		"token":     "t",
-		"hostname":  "",
 		"from-file": "f",
-		"debug":     "d",
+		"yes":       "y",
 	}
 
 	for flag, shorthand := range expectedFlags {
@@ -53,7 +59,7 @@ func TestRemoveCommandFlags(t *testing.T) {
 }
 
 func TestRemoveCommandRequiredFlags(t *testing.T) {
-	cmd := NewCmdRemove()
+	cmd := NewCmdRemove(factory.New())
 
 	// Test that the from-file flag is required
 	fromFileFlag := cmd.Flag("from-file")
@@ -70,48 +76,27 @@ func TestRemoveCommandRequiredFlags(t *testing.T) {
 }
 
 func TestRemoveCommandDefaultValues(t *testing.T) {
-	cmd := NewCmdRemove()
+	cmd := NewCmdRemove(factory.New())
 
-	// Check default hostname
-	hostnameFlag := cmd.Flag("hostname")
-	if hostnameFlag != nil && hostnameFlag.DefValue != "github.com" {
-		t.Errorf("Expected default hostname to be 'github.com', got %s", hostnameFlag.DefValue)
-	}
-
-	// Check default debug value
-	debugFlag := cmd.Flag("debug")
-	if debugFlag != nil && debugFlag.DefValue != "false" {
-		t.Errorf("Expected default debug to be 'false', got %s", debugFlag.DefValue)
+	// Check that from-file has no default
+	fromFileFlag := cmd.Flag("from-file")
+	if fromFileFlag != nil && fromFileFlag.DefValue != "" {
+		t.Errorf("Expected default from-file to be empty, got %s", fromFileFlag.DefValue)
 	}
 }
 
 func TestRemoveCmdFlagsStruct(t *testing.T) {
 	flags := cmdFlags{
-		token:    "test-token",
-		hostname: "github.enterprise.com",
 		fileName: "test.csv",
-		debug:    true,
-	}
-
-	if flags.token != "test-token" {
-		t.Errorf("Expected token to be 'test-token', got %s", flags.token)
-	}
-
-	if flags.hostname != "github.enterprise.com" {
-		t.Errorf("Expected hostname to be 'github.enterprise.com', got %s", flags.hostname)
 	}
 
 	if flags.fileName != "test.csv" {
 		t.Errorf("Expected fileName to be 'test.csv', got %s", flags.fileName)
 	}
-
-	if !flags.debug {
-		t.Error("Expected debug to be true")
-	}
 }
 
 func TestRemoveCommandArgsValidation(t *testing.T) {
-	cmd := NewCmdRemove()
+	cmd := NewCmdRemove(factory.New())
 
 	// Test with no args - should fail
 	cmd.SetArgs([]string{})
@@ -132,3 +117,147 @@ func TestRemoveCommandArgsValidation(t *testing.T) {
 		t.Errorf("Expected no error with multiple arguments, got %v", err)
 	}
 }
+
+func TestRemoveCommandUsesRootFlags(t *testing.T) {
+	cmd := NewCmdRemove(factory.New())
+
+	// Authentication, hostname and debug flags are inherited from the root command
+	for _, flagName := range []string{"token", "hostname", "debug", "app-id", "private-key-path", "installation-id"} {
+		if cmd.Flags().Lookup(flagName) != nil {
+			t.Errorf("Expected '%s' to be defined on the root command, not on remove", flagName)
+		}
+	}
+}
+
+func TestRemoveEndToEnd(t *testing.T) {
+	server := replay.NewServer(t, "testdata/remove.json")
+
+	var out bytes.Buffer
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+	cmd := NewCmdRemove(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/remove.csv", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if len(server.Requests()) != 5 {
+		t.Errorf("Expected 5 requests, got %d", len(server.Requests()))
+	}
+	if !strings.Contains(out.String(), "About to remove 3 grants for 3 users across 3 repos.") {
+		t.Errorf("Expected the pre-flight summary, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Summary: 1 removed, 1 skipped, 1 failed") {
+		t.Errorf("Unexpected summary:\n%s", out.String())
+	}
+
+	// The journal keeps the permission each collaborator had before removal
+	files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one journal file, got %v", files)
+	}
+	runID := strings.TrimSuffix(filepath.Base(files[0]), ".jsonl")
+	if !strings.Contains(out.String(), "Run ID: "+runID) {
+		t.Errorf("Expected run ID %s in output, got:\n%s", runID, out.String())
+	}
+	entries, err := journal.Load(f.JournalDir, runID)
+	if err != nil {
+		t.Fatalf("Expected no error loading journal, got %v", err)
+	}
+	if len(entries) != 3 || entries[0].Status != "removed" || entries[0].PreviousPermission != "write" || entries[0].Command != "remove" {
+		t.Errorf("Unexpected journal entries: %+v", entries)
+	}
+}
+
+func TestRemoveEndToEndMissingFile(t *testing.T) {
+	server := replay.NewServer(t)
+
+	cmd := NewCmdRemove(factorytest.New(server))
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/does-not-exist.csv"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
+
+func TestRemoveConfirmation(t *testing.T) {
+	tests := []struct {
+		name        string
+		interactive bool
+		answer      bool
+		requests    int
+		err         string
+	}{
+		{name: "confirmed", interactive: true, answer: true, requests: 5},
+		{name: "declined", interactive: true, answer: false, err: "aborted, no changes made"},
+		{name: "not interactive", interactive: false, err: "rerun with --yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := replay.NewServer(t, "testdata/remove.json")
+			f := factorytest.New(server)
+			f.JournalDir = t.TempDir()
+			f.IsInteractive = func() bool { return tt.interactive }
+			var asked string
+			f.Confirm = func(question string) (bool, error) {
+				asked = question
+				return tt.answer, nil
+			}
+
+			cmd := NewCmdRemove(f)
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetArgs([]string{"test-org", "--from-file", "testdata/remove.csv"})
+			err := cmd.Execute()
+			if tt.err == "" && err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("Expected error containing %q, got %v", tt.err, err)
+			}
+			if tt.interactive && asked != "Continue?" {
+				t.Errorf("Expected to be asked to continue, got %q", asked)
+			}
+			if len(server.Requests()) != tt.requests {
+				t.Errorf("Expected %d requests, got %d", tt.requests, len(server.Requests()))
+			}
+			// Nothing is journaled for a run that never started
+			if files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl")); tt.err != "" && len(files) != 0 {
+				t.Errorf("Expected no journal, got %v", files)
+			}
+		})
+	}
+}
+
+func TestRemoveEndToEndNotificationFailure(t *testing.T) {
+	server := replay.NewServer(t, "testdata/remove.json")
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "POST", Path: "/services/T000/B000/secret"},
+		Response: replay.Response{Status: 500, Body: []byte("unavailable")},
+	})
+
+	var out bytes.Buffer
+	f := factorytest.New(server)
+	f.JournalDir = t.TempDir()
+	f.Profile = &config.Profile{Notify: config.Notify{
+		Slack: &config.SlackNotify{WebhookURL: "https://hooks.slack.example.com/services/T000/B000/secret"},
+	}}
+	cmd := NewCmdRemove(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--from-file", "testdata/remove.csv", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected a failed notification not to fail the run, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// The message names the run so it can be followed up by hand
+	requests := server.Requests()
+	message := string(requests[len(requests)-1].Body)
+	if !strings.Contains(message, "gh collaborators remove in test-org: 1 removed, 1 skipped, 1 failed") || !strings.Contains(message, "run ID ") {
+		t.Errorf("Unexpected slack message %s", message)
+	}
+	if !strings.Contains(out.String(), "Failed to send notifications: slack: webhook responded 500 Internal Server Error: unavailable") {
+		t.Errorf("Expected the notification failure, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,153 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/runner"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewCmdRollback(f *factory.Factory) *cobra.Command {
+	var yes bool
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback [flags] <run-id>",
+		Short: "Undo the changes made by an add or remove run.",
+		Long:  "Restore repository collaborators to the permissions they had before an add, remove or rollback run, using the journal written for that run.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(rollbackCmd *cobra.Command, args []string) error {
+			if f.JournalDir == "" {
+				return fmt.Errorf("no journal directory set, use --journal-dir")
+			}
+			entries, err := journal.Load(f.JournalDir, args[0])
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("journal for run %s has no entries", args[0])
+			}
+			owner := entries[0].Owner
+
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
+
+			reversible := reversibleEntries(entries)
+			reverts := make([]data.ImportedRepoCollab, 0, len(reversible))
+			for _, entry := range reversible {
+				reverts = append(reverts, data.ImportedRepoCollab{RepositoryName: entry.RepositoryName, Username: entry.Username})
+			}
+			summary := utils.ChangeSummary("revert", reverts) + " made by run " + args[0]
+			if err := f.ConfirmChanges(rollbackCmd.OutOrStdout(), summary, yes); err != nil {
+				return err
+			}
+
+			j, err := f.OpenJournal(rollbackCmd.Context(), "rollback", owner)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				closeErr := j.Close()
+				if closeErr != nil {
+					zap.S().Warnf("Error closing journal: %v", closeErr)
+				}
+			}()
+
+			run := &runner.Run{Command: "rollback", Owner: owner, Exec: f.Executor(), Journal: j, Out: rollbackCmd.OutOrStdout()}
+			return runCmdRollback(rollbackCmd.Context(), owner, args[0], reversible, apiGetter, run)
+		},
+	}
+
+	// Configure flags for command
+
+	rollbackCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation prompt, required when not running interactively")
+
+	return rollbackCmd
+}
+
+// reversibleEntries returns the journaled changes to revert, newest first.
+func reversibleEntries(entries []journal.Entry) []journal.Entry {
+	var reversible []journal.Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		// Organization and team membership changes made by convert are
+		// journaled for the record, only repository grants are reverted
+		if entries[i].RepositoryName == "" {
+			continue
+		}
+		switch entries[i].Status {
+		case utils.StatusAdded, utils.StatusUpdated, utils.StatusRemoved:
+			reversible = append(reversible, entries[i])
+		}
+		// Skipped, blocked and failed rows changed nothing
+	}
+	return reversible
+}
+
+// revertWaves splits the entries into waves holding at most one entry for
+// each collaborator on a repository, keeping their order. Waves run one
+// after another, so a collaborator changed more than once by a run is
+// reverted one change at a time while the rows of a wave run concurrently.
+func revertWaves(entries []journal.Entry) [][]journal.Entry {
+	var waves [][]journal.Entry
+	seen := map[string]int{}
+	for _, entry := range entries {
+		key := strings.ToLower(entry.RepositoryName + "/" + entry.Username)
+		wave := seen[key]
+		seen[key]++
+		if wave == len(waves) {
+			waves = append(waves, nil)
+		}
+		waves[wave] = append(waves[wave], entry)
+	}
+	return waves
+}
+
+// runCmdRollback reverts journaled changes in the order given. Each
+// collaborator is only touched while they still hold the permission the run
+// left them with, so later manual changes are not overwritten.
+func runCmdRollback(ctx context.Context, owner string, runID string, reversible []journal.Entry, g utils.Getter, run *runner.Run) error {
+	g = run.Exec.RateLimited(g)
+	var results []data.MutationResult
+	var err error
+	for _, wave := range revertWaves(reversible) {
+		var waveResults []data.MutationResult
+		row := func(i int) data.MutationResult { return wave[i].MutationResult }
+		waveResults, err = run.Apply(ctx, len(wave), row, func(i int) data.MutationResult {
+			return revertEntry(ctx, owner, wave[i], g)
+		})
+		results = append(results, waveResults...)
+		if err != nil {
+			break
+		}
+	}
+
+	_, _ = fmt.Fprintf(run.Out, "Rolled back run %s in: %s.\n", runID, owner)
+	return run.Report(ctx, results, err)
+}
+
+// revertEntry undoes one journaled change.
+func revertEntry(ctx context.Context, owner string, entry journal.Entry, g utils.Getter) data.MutationResult {
+	switch entry.Status {
+	case utils.StatusAdded:
+		if entry.InvitationID != 0 {
+			zap.S().Debugf("Withdrawing invitation of %s to repo %s", entry.Username, entry.RepositoryName)
+			return utils.WithdrawRepoInvitation(ctx, owner, entry.RepositoryName, entry.Username, entry.Permission, entry.InvitationID, g)
+		}
+		zap.S().Debugf("Reverting grant of %s to %s on repo %s", entry.Permission, entry.Username, entry.RepositoryName)
+		return utils.RestoreRepoCollaborator(ctx, owner, entry.RepositoryName, entry.Username, entry.Permission, utils.PermissionNone, g)
+	case utils.StatusUpdated:
+		zap.S().Debugf("Restoring %s for %s on repo %s", entry.PreviousPermission, entry.Username, entry.RepositoryName)
+		return utils.RestoreRepoCollaborator(ctx, owner, entry.RepositoryName, entry.Username, entry.Permission, entry.PreviousPermission, g)
+	default:
+		zap.S().Debugf("Re-adding %s to repo %s with %s", entry.Username, entry.RepositoryName, entry.PreviousPermission)
+		return utils.RestoreRepoCollaborator(ctx, owner, entry.RepositoryName, entry.Username, utils.PermissionNone, entry.PreviousPermission, g)
+	}
+}
@@ -0,0 +1,225 @@
+package rollback
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+)
+
+func TestNewCmdRollback(t *testing.T) {
+	cmd := NewCmdRollback(factory.New())
+
+	if cmd.Use != "rollback [flags] <run-id>" {
+		t.Errorf("Expected Use to be 'rollback [flags] <run-id>', got '%s'", cmd.Use)
+	}
+	if cmd.Short != "Undo the changes made by an add or remove run." {
+		t.Errorf("Unexpected Short description: %s", cmd.Short)
+	}
+	if cmd.RunE == nil {
+		t.Error("Expected RunE to be set")
+	}
+}
+
+func TestRollbackCommandArgsValidation(t *testing.T) {
+	cmd := NewCmdRollback(factory.New())
+
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected error with no run ID, got nil")
+	}
+	if err := cmd.Args(cmd, []string{"a", "b"}); err == nil {
+		t.Error("Expected error with two run IDs, got nil")
+	}
+	if err := cmd.Args(cmd, []string{"20261018T120000-a1b2c3"}); err != nil {
+		t.Errorf("Expected no error with one run ID, got %v", err)
+	}
+}
+
+// newRollbackFactory copies the journal fixtures into a temporary directory,
+// since rolling back writes a journal of its own.
+func newRollbackFactory(t *testing.T, server *replay.Server) *factory.Factory {
+	t.Helper()
+	dir := t.TempDir()
+	fixtures, _ := filepath.Glob("testdata/journal/*.jsonl")
+	for _, fixture := range fixtures {
+		content, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", fixture, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(fixture)), content, 0600); err != nil {
+			t.Fatalf("Failed to copy %s: %v", fixture, err)
+		}
+	}
+
+	f := factorytest.New(server)
+	f.JournalDir = dir
+	return f
+}
+
+func TestRollbackAddRun(t *testing.T) {
+	server := replay.NewServer(t, "testdata/rollback_add.json")
+	f := newRollbackFactory(t, server)
+
+	var out bytes.Buffer
+	cmd := NewCmdRollback(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"20261018T120000-a1b2c3", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// Newest changes are reverted first
+	requests := server.Requests()
+	if len(requests) == 0 || requests[0].Path != "/repos/test-org/repo4/collaborators/erin/permission" {
+		t.Errorf("Expected rollback to start with the last entry, got %+v", requests)
+	}
+
+	output := out.String()
+	for _, expected := range []string{
+		"Summary: 1 updated, 1 removed, 1 skipped",
+		"skipped: erin on repo4 (changed since run, now admin)",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	// The rollback is journaled so it can be undone as well
+	files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl"))
+	if len(files) != 5 {
+		t.Fatalf("Expected a new journal for the rollback, got %v", files)
+	}
+}
+
+func TestRollbackRemoveRun(t *testing.T) {
+	server := replay.NewServer(t, "testdata/rollback_remove.json")
+	f := newRollbackFactory(t, server)
+
+	var out bytes.Buffer
+	cmd := NewCmdRollback(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"20261018T130000-d4e5f6", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if !strings.Contains(out.String(), "Summary: 1 added") {
+		t.Errorf("Expected removed collaborator to be re-added, got:\n%s", out.String())
+	}
+}
+
+func TestRollbackRepeatedCollaborator(t *testing.T) {
+	server := replay.NewServer(t, "testdata/rollback_repeated.json")
+	f := newRollbackFactory(t, server)
+
+	var out bytes.Buffer
+	cmd := NewCmdRollback(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"20261018T150000-b7c8d9", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	// The later update is reverted before the grant it was made over
+	var methods []string
+	for _, request := range server.Requests() {
+		if request.Method != "GET" {
+			methods = append(methods, request.Method)
+		}
+	}
+	if strings.Join(methods, ",") != "PUT,DELETE" {
+		t.Errorf("Expected the update to be reverted before the grant, got %v", methods)
+	}
+	if !strings.Contains(out.String(), "Summary: 1 updated, 1 removed") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestRollbackInvitations(t *testing.T) {
+	server := replay.NewServer(t, "testdata/rollback_invite.json")
+	f := newRollbackFactory(t, server)
+
+	var out bytes.Buffer
+	cmd := NewCmdRollback(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"20261018T140000-0a1b2c", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The pending invitation is withdrawn, the accepted one is removed as a
+	// collaborator
+	server.AssertAllUsed()
+	if !strings.Contains(out.String(), "Summary: 2 removed") {
+		t.Errorf("Expected both invitations to be undone, got:\n%s", out.String())
+	}
+}
+
+func TestRollbackConfirmation(t *testing.T) {
+	server := replay.NewServer(t)
+	f := newRollbackFactory(t, server)
+	f.IsInteractive = func() bool { return true }
+	f.Confirm = func(question string) (bool, error) { return false, nil }
+
+	var out bytes.Buffer
+	cmd := NewCmdRollback(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"20261018T120000-a1b2c3"})
+	if err := cmd.Execute(); err == nil || err.Error() != "aborted, no changes made" {
+		t.Fatalf("Expected the rollback to be aborted, got %v", err)
+	}
+	if !strings.Contains(out.String(), "About to revert 3 grants for 3 users across 3 repos made by run 20261018T120000-a1b2c3.") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no requests, got %d", len(server.Requests()))
+	}
+	// Only the fixture journals, the declined rollback wrote none
+	if files, _ := filepath.Glob(filepath.Join(f.JournalDir, "*.jsonl")); len(files) != 4 {
+		t.Errorf("Expected no new journal, got %v", files)
+	}
+}
+
+func TestRollbackUnknownRun(t *testing.T) {
+	server := replay.NewServer(t)
+
+	cmd := NewCmdRollback(newRollbackFactory(t, server))
+	cmd.SetArgs([]string{"20990101T000000-000000"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for unknown run, got nil")
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no requests, got %d", len(server.Requests()))
+	}
+}
+
+func TestRollbackJournalEntries(t *testing.T) {
+	entries, err := journal.Load("testdata/journal", "20261018T120000-a1b2c3")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 4 || entries[1].PreviousPermission != "write" {
+		t.Errorf("Unexpected fixture entries: %+v", entries)
+	}
+}
+
+func TestReversibleEntriesSkipsMemberships(t *testing.T) {
+	entries := []journal.Entry{
+		{MutationResult: data.MutationResult{Username: "alice", Team: "dev", Permission: "member", Status: "added"}},
+		{MutationResult: data.MutationResult{RepositoryName: "repo1", Username: "alice", PreviousPermission: "write", Status: "removed"}},
+		{MutationResult: data.MutationResult{Username: "bob", Permission: "outside_collaborator", Status: "updated"}},
+	}
+	reversible := reversibleEntries(entries)
+	if len(reversible) != 1 || reversible[0].RepositoryName != "repo1" {
+		t.Errorf("Expected only the repository grant to be reversible, got %+v", reversible)
+	}
+}
@@ -4,21 +4,53 @@ import (
 	"github.com/spf13/cobra"
 
 	addCmd "github.com/katiem0/gh-collaborators/cmd/add"
+	analyzeCmd "github.com/katiem0/gh-collaborators/cmd/analyze"
+	auditLogCmd "github.com/katiem0/gh-collaborators/cmd/auditlog"
+	convertCmd "github.com/katiem0/gh-collaborators/cmd/convert"
+	copyRepoAccessCmd "github.com/katiem0/gh-collaborators/cmd/copyrepoaccess"
+	interactiveCmd "github.com/katiem0/gh-collaborators/cmd/interactive"
 	listCmd "github.com/katiem0/gh-collaborators/cmd/list"
 	removeCmd "github.com/katiem0/gh-collaborators/cmd/remove"
+	rollbackCmd "github.com/katiem0/gh-collaborators/cmd/rollback"
+	serveCmd "github.com/katiem0/gh-collaborators/cmd/serve"
+	summaryCmd "github.com/katiem0/gh-collaborators/cmd/summary"
+	verifyAuditCmd "github.com/katiem0/gh-collaborators/cmd/verifyaudit"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"go.uber.org/zap"
 )
 
 func NewCmdRoot() *cobra.Command {
+	return NewCmdRootWithFactory(factory.New())
+}
+
+func NewCmdRootWithFactory(f *factory.Factory) *cobra.Command {
 
 	cmdRoot := &cobra.Command{
 		Use:   "collaborators <command> [flags]",
 		Short: "List and maintain repository collaborators and their repos.",
 		Long:  "List and maintain repository collaborators and their assigned repositories.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return f.Init(cmd)
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			_ = zap.L().Sync()
+		},
 	}
 
-	cmdRoot.AddCommand(addCmd.NewCmdAdd())
-	cmdRoot.AddCommand(listCmd.NewCmdList())
-	cmdRoot.AddCommand(removeCmd.NewCmdRemove())
+	f.AddFlags(cmdRoot)
+
+	cmdRoot.AddCommand(addCmd.NewCmdAdd(f))
+	cmdRoot.AddCommand(analyzeCmd.NewCmdAnalyze(f))
+	cmdRoot.AddCommand(auditLogCmd.NewCmdAuditLog(f))
+	cmdRoot.AddCommand(convertCmd.NewCmdConvert(f))
+	cmdRoot.AddCommand(copyRepoAccessCmd.NewCmdCopyRepoAccess(f))
+	cmdRoot.AddCommand(interactiveCmd.NewCmdInteractive(f))
+	cmdRoot.AddCommand(listCmd.NewCmdList(f))
+	cmdRoot.AddCommand(removeCmd.NewCmdRemove(f))
+	cmdRoot.AddCommand(rollbackCmd.NewCmdRollback(f))
+	cmdRoot.AddCommand(serveCmd.NewCmdServe(f))
+	cmdRoot.AddCommand(summaryCmd.NewCmdSummary(f))
+	cmdRoot.AddCommand(verifyAuditCmd.NewCmdVerifyAudit(f))
 	cmdRoot.CompletionOptions.DisableDefaultCmd = true
 	cmdRoot.SetHelpCommand(&cobra.Command{
 		Use:    "no-help",
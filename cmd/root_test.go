@@ -1,7 +1,19 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/audit"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/utils"
 )
 
 func TestNewCmdRoot(t *testing.T) {
@@ -27,7 +39,7 @@ func TestNewCmdRoot(t *testing.T) {
 func TestRootCommandHasSubcommands(t *testing.T) {
 	cmd := NewCmdRoot()
 
-	expectedCommands := []string{"add", "list", "remove"}
+	expectedCommands := []string{"add", "analyze", "audit-log", "convert", "copy-repo-access", "interactive", "list", "remove", "rollback", "serve", "summary", "verify-audit"}
 
 	for _, expectedCmd := range expectedCommands {
 		found := false
@@ -78,11 +90,11 @@ func TestRootCommandHelpDisabled(t *testing.T) {
 func TestRootCommandSubcommandCount(t *testing.T) {
 	cmd := NewCmdRoot()
 
-	// Should have 3 visible commands (add, list, remove)
+	// Should have 4 visible commands (add, list, remove, rollback)
 	// The help command set via SetHelpCommand doesn't appear in Commands()
 	commands := cmd.Commands()
-	if len(commands) != 3 {
-		t.Errorf("Expected 3 commands, got %d", len(commands))
+	if len(commands) != 12 {
+		t.Errorf("Expected 12 commands, got %d", len(commands))
 	}
 
 	// Count visible commands
@@ -93,8 +105,8 @@ func TestRootCommandSubcommandCount(t *testing.T) {
 		}
 	}
 
-	if visibleCount != 3 {
-		t.Errorf("Expected 3 visible commands, got %d", visibleCount)
+	if visibleCount != 12 {
+		t.Errorf("Expected 12 visible commands, got %d", visibleCount)
 	}
 }
 
@@ -110,3 +122,149 @@ func TestRootCommandExecuteWithoutArgs(t *testing.T) {
 		t.Logf("Execution without args returned: %v", err)
 	}
 }
+
+func TestRootCommandPersistentFlags(t *testing.T) {
+	cmd := NewCmdRoot()
+
+	expectedDefaults := map[string]string{
+		"token":            "",
+		"hostname":         "github.com",
+		"debug":            "false",
+		"app-id":           "0",
+		"private-key-path": "",
+		"installation-id":  "0",
+		"profile":          "",
+		"no-cache":         "false",
+		"cache-ttl":        "1h0m0s",
+		"offline":          "false",
+		"journal-dir":      journal.DefaultDir(),
+		"audit-log":        audit.DefaultPath(),
+		"concurrency":      "4",
+		"rate-limit":       "80",
+		"timeout":          "0s",
+	}
+
+	for flagName, expectedDefault := range expectedDefaults {
+		flag := cmd.PersistentFlags().Lookup(flagName)
+		if flag == nil {
+			t.Errorf("Expected '%s' to be a persistent flag", flagName)
+			continue
+		}
+		if flag.DefValue != expectedDefault {
+			t.Errorf("Expected flag '%s' default to be '%s', got '%s'", flagName, expectedDefault, flag.DefValue)
+		}
+	}
+
+	if cmd.PersistentFlags().Lookup("token").Shorthand != "t" {
+		t.Error("Expected token flag to have shorthand 't'")
+	}
+	if cmd.PersistentFlags().Lookup("debug").Shorthand != "d" {
+		t.Error("Expected debug flag to have shorthand 'd'")
+	}
+}
+
+func TestSubcommandsInheritRootFlags(t *testing.T) {
+	cmd := NewCmdRoot()
+
+	for _, subCmd := range cmd.Commands() {
+		for _, flagName := range []string{"token", "hostname", "debug"} {
+			if subCmd.InheritedFlags().Lookup(flagName) == nil {
+				t.Errorf("Expected subcommand '%s' to inherit flag '%s'", subCmd.Name(), flagName)
+			}
+		}
+	}
+}
+
+type fakeGetter struct {
+	*utils.APIGetter
+	removed []string
+}
+
+func (f *fakeGetter) RemoveRepoCollaborator(ctx context.Context, owner string, repo string, username string) error {
+	f.removed = append(f.removed, fmt.Sprintf("%s/%s/%s", owner, repo, username))
+	return nil
+}
+
+func (f *fakeGetter) GetRepoCollaboratorPermission(ctx context.Context, owner string, repo string, username string) (string, error) {
+	return "write", nil
+}
+
+func (f *fakeGetter) ListRepoCollaborators(ctx context.Context, owner string, repo string, affiliation string) ([]data.RepoCollaborator, error) {
+	return []data.RepoCollaborator{{Login: "user1", RoleName: "write"}}, nil
+}
+
+func (f *fakeGetter) GetAuthenticatedUser(ctx context.Context) (*data.User, error) {
+	return &data.User{Login: "admin"}, nil
+}
+
+func TestRootCommandInjectsGetter(t *testing.T) {
+	fake := &fakeGetter{APIGetter: &utils.APIGetter{}}
+	var gotOwner string
+	f := factory.New()
+	f.ConfigPath = ""
+	f.NewGetter = func(owner string) (utils.Getter, error) {
+		gotOwner = owner
+		return fake, nil
+	}
+
+	csvFile := filepath.Join(t.TempDir(), "remove.csv")
+	if err := os.WriteFile(csvFile, []byte("RepositoryName,Username\nrepo1,user1\n"), 0600); err != nil {
+		t.Fatalf("Failed to write csv: %v", err)
+	}
+
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	cmd := NewCmdRootWithFactory(f)
+	cmd.SetArgs([]string{"remove", "test-org", "--from-file", csvFile, "--hostname", "github.example.com", "--journal-dir", t.TempDir(), "--audit-log", auditLog, "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if gotOwner != "test-org" {
+		t.Errorf("Expected getter for 'test-org', got %s", gotOwner)
+	}
+	if f.Hostname != "github.example.com" {
+		t.Errorf("Expected hostname 'github.example.com', got %s", f.Hostname)
+	}
+	if len(fake.removed) != 1 || fake.removed[0] != "test-org/repo1/user1" {
+		t.Errorf("Expected one removal of test-org/repo1/user1, got %v", fake.removed)
+	}
+
+	entries, err := audit.Read(auditLog)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one audit entry, got %v, %v", entries, err)
+	}
+	if entry := entries[0]; entry.Actor != "admin" || entry.Host != "github.example.com" || entry.Org != "test-org" ||
+		entry.Repository != "repo1" || entry.Username != "user1" || entry.OldPermission != "write" || entry.NewPermission != "none" {
+		t.Errorf("Expected the removal attributed to admin, got %+v", entry)
+	}
+}
+
+func TestRootCommandAuditsWithoutJournal(t *testing.T) {
+	fake := &fakeGetter{APIGetter: &utils.APIGetter{}}
+	f := factory.New()
+	f.ConfigPath = ""
+	f.NewGetter = func(owner string) (utils.Getter, error) { return fake, nil }
+
+	csvFile := filepath.Join(t.TempDir(), "remove.csv")
+	if err := os.WriteFile(csvFile, []byte("RepositoryName,Username\nrepo1,user1\n"), 0600); err != nil {
+		t.Fatalf("Failed to write csv: %v", err)
+	}
+
+	var out bytes.Buffer
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	cmd := NewCmdRootWithFactory(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"remove", "test-org", "--from-file", csvFile, "--journal-dir", "", "--audit-log", auditLog, "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The removal cannot be rolled back but is still audited
+	if strings.Contains(out.String(), "undo with") {
+		t.Errorf("Expected no rollback hint without a journal, got:\n%s", out.String())
+	}
+	entries, err := audit.Read(auditLog)
+	if err != nil || len(entries) != 1 || entries[0].RunID == "" || entries[0].Command != "remove" {
+		t.Errorf("Expected one audit entry for the run, got %+v, %v", entries, err)
+	}
+}
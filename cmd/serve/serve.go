@@ -0,0 +1,18 @@
+package serve
+
+import (
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdServe(f *factory.Factory) *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve <command>",
+		Short: "Run a server reacting to collaborator changes.",
+		Long:  "Run a server that reacts to collaborator changes as GitHub reports them.",
+	}
+
+	serveCmd.AddCommand(NewCmdWebhooks(f))
+
+	return serveCmd
+}
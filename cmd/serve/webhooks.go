@@ -0,0 +1,119 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/policy"
+	"github.com/katiem0/gh-collaborators/internal/webhook"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// SecretEnv holds the webhook secret, so it stays out of the process list
+const SecretEnv = "GH_COLLABORATORS_WEBHOOK_SECRET"
+
+// shutdownTimeout bounds how long deliveries in progress get to finish
+const shutdownTimeout = 30 * time.Second
+
+type webhooksFlags struct {
+	addr       string
+	path       string
+	policyFile string
+}
+
+func NewCmdWebhooks(f *factory.Factory) *cobra.Command {
+	cmdFlags := webhooksFlags{}
+
+	webhooksCmd := &cobra.Command{
+		Use:   "webhooks [flags] <organization>",
+		Short: "Check new collaborator grants against the policy as they happen.",
+		Long: "Listen for organization member and repository webhooks and check each grant to an outside collaborator " +
+			"against the collaborator policy. A grant breaking the policy is logged, reported in an issue on the " +
+			"repository, or reverted, as the policy says. Payloads must be signed with the secret in " + SecretEnv + ".",
+		Args: f.OrgArgs,
+		RunE: func(webhooksCmd *cobra.Command, args []string) error {
+			owner := f.Owner(args)
+			secret := os.Getenv(SecretEnv)
+			if secret == "" {
+				return fmt.Errorf("set the webhook secret in %s", SecretEnv)
+			}
+			policyFile := cmdFlags.policyFile
+			if policyFile == "" && f.Profile != nil {
+				policyFile = f.Profile.PolicyFile
+			}
+			if policyFile == "" {
+				return fmt.Errorf("no policy to check grants against, set --policy-file or policy_file in the profile")
+			}
+			p, err := policy.Load(policyFile)
+			if err != nil {
+				return err
+			}
+
+			apiGetter, err := f.NewGetter(owner)
+			if err != nil {
+				return err
+			}
+
+			handler := &webhook.Handler{
+				Secret: []byte(secret),
+				Owner:  owner,
+				Policy: p,
+				Getter: f.Executor().RateLimited(apiGetter),
+				OpenJournal: func(ctx context.Context) (*journal.Journal, error) {
+					return f.OpenJournal(ctx, "serve-webhooks", owner)
+				},
+				Out: webhooksCmd.OutOrStdout(),
+			}
+			return serve(webhooksCmd.Context(), &cmdFlags, handler, webhooksCmd)
+		},
+	}
+
+	// Configure flags for command
+	webhooksCmd.Flags().StringVarP(&cmdFlags.addr, "addr", "", ":8080", "Address to listen on")
+	webhooksCmd.Flags().StringVarP(&cmdFlags.path, "path", "", "/webhook", "URL path webhooks are delivered to")
+	webhooksCmd.Flags().StringVarP(&cmdFlags.policyFile, "policy-file", "", "", "Collaborator policy file (default the profile's policy_file)")
+
+	return webhooksCmd
+}
+
+// serve handles deliveries until ctx is done, then waits for the ones in
+// progress to finish.
+func serve(ctx context.Context, cmdFlags *webhooksFlags, handler http.Handler, cmd *cobra.Command) error {
+	listener, err := net.Listen("tcp", cmdFlags.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cmdFlags.addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(cmdFlags.path, handler)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Listening for webhooks on http://%s%s\n", listener.Addr(), cmdFlags.path)
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	zap.L().Debug("Shutting down webhook server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/factory/factorytest"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"github.com/katiem0/gh-collaborators/internal/webhook"
+)
+
+func TestNewCmdWebhooks(t *testing.T) {
+	cmd := NewCmdWebhooks(factory.New())
+
+	if cmd.Use != "webhooks [flags] <organization>" {
+		t.Errorf("Expected Use to be 'webhooks [flags] <organization>', got %s", cmd.Use)
+	}
+	for flag, defValue := range map[string]string{"addr": ":8080", "path": "/webhook", "policy-file": ""} {
+		f := cmd.Flag(flag)
+		if f == nil {
+			t.Errorf("Expected flag '%s' to exist", flag)
+			continue
+		}
+		if f.DefValue != defValue {
+			t.Errorf("Expected flag '%s' default to be '%s', got '%s'", flag, defValue, f.DefValue)
+		}
+	}
+}
+
+func writePolicy(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte("max_permission: write\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWebhooksRequiresSecretAndPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		args   []string
+		err    string
+	}{
+		{name: "no secret", args: []string{"test-org", "--policy-file", writePolicy(t)}, err: "set the webhook secret in " + SecretEnv},
+		{name: "no policy", secret: "secret", args: []string{"test-org"}, err: "no policy to check grants against"},
+		{name: "missing policy", secret: "secret", args: []string{"test-org", "--policy-file", "missing.yaml"}, err: "failed to read policy missing.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SecretEnv, tt.secret)
+			cmd := NewCmdWebhooks(factory.New())
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// syncBuffer is written by the server while the test reads it
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestWebhooksServesUntilCancelled(t *testing.T) {
+	t.Setenv(SecretEnv, "secret")
+	server := replay.NewServer(t)
+	f := factorytest.New(server)
+	f.Profile = &config.Profile{PolicyFile: writePolicy(t)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out syncBuffer
+	cmd := NewCmdWebhooks(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"test-org", "--addr", "127.0.0.1:0"})
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.ExecuteContext(ctx)
+	}()
+
+	var url string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if line, ok := strings.CutPrefix(out.String(), "Listening for webhooks on "); ok {
+			url = strings.TrimSpace(line)
+			break
+		}
+	}
+	if url == "" {
+		t.Fatalf("Expected the server to start, got %q", out.String())
+	}
+
+	payload := []byte(`{"zen":"Design for failure."}`)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(webhook.EventHeader, "ping")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign([]byte("secret"), payload))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected the ping to be delivered, got %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for a signed ping, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to stop once cancelled")
+	}
+}
@@ -0,0 +1,43 @@
+package summary
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/report"
+	"github.com/spf13/cobra"
+)
+
+type cmdFlags struct {
+	format string
+	top    int
+	save   bool
+}
+
+func NewCmdSummary(f *factory.Factory) *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	summaryCmd := &cobra.Command{
+		Use:   "summary [flags] <report-file>",
+		Short: "Summarize a report generated by list.",
+		Long:  "Summarize a report generated by list: outside collaborators, repositories per collaborator, grants by permission level and the repositories with the most outside collaborators.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(summaryCmd *cobra.Command, args []string) error {
+			saved, err := report.SummarizeReport(args[0], cmdFlags.format, cmdFlags.top, cmdFlags.save, summaryCmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if saved != "" {
+				_, _ = fmt.Fprintf(summaryCmd.OutOrStdout(), "Summary saved to: %s\n", saved)
+			}
+			return nil
+		},
+	}
+
+	summaryCmd.Flags().StringVarP(&cmdFlags.format, "format", "f", report.FormatTable, fmt.Sprintf("Summary format: %s", strings.Join(report.SummaryFormats, ", ")))
+	summaryCmd.Flags().IntVarP(&cmdFlags.top, "top", "", report.DefaultTop, "Number of repositories with the most outside collaborators to show")
+	summaryCmd.Flags().BoolVarP(&cmdFlags.save, "save", "", false, "Also write the summary next to the report")
+
+	return summaryCmd
+}
@@ -0,0 +1,81 @@
+package summary
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/factory"
+)
+
+func TestNewCmdSummary(t *testing.T) {
+	cmd := NewCmdSummary(factory.New())
+
+	if cmd.Use != "summary [flags] <report-file>" {
+		t.Errorf("Expected Use to be 'summary [flags] <report-file>', got '%s'", cmd.Use)
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected error with no report file, got nil")
+	}
+	for flag, expected := range map[string]string{"format": "table", "top": "10", "save": "false"} {
+		if f := cmd.Flag(flag); f == nil || f.DefValue != expected {
+			t.Errorf("Expected flag '%s' with default %s, got %v", flag, expected, f)
+		}
+	}
+}
+
+func TestSummaryCommand(t *testing.T) {
+	var out bytes.Buffer
+	cmd := NewCmdSummary(factory.New())
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"testdata/report.csv", "--top", "1"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, expected := range []string{"Outside collaborators:     3", "repo1       3"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "repo2       1") {
+		t.Errorf("Expected only the top repository, got:\n%s", out.String())
+	}
+}
+
+func TestSummaryCommandSave(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.csv")
+	content, err := os.ReadFile("testdata/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := NewCmdSummary(factory.New())
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{reportPath, "--format", "json", "--save"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	summaryPath := strings.TrimSuffix(reportPath, ".csv") + "-summary.json"
+	if !strings.Contains(out.String(), "Summary saved to: "+summaryPath) {
+		t.Errorf("Expected saved path in output, got:\n%s", out.String())
+	}
+	if _, err := os.Stat(summaryPath); err != nil {
+		t.Errorf("Expected summary file, got %v", err)
+	}
+}
+
+func TestSummaryCommandInvalidFormat(t *testing.T) {
+	cmd := NewCmdSummary(factory.New())
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"testdata/report.csv", "--format", "yaml"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown summary format") {
+		t.Errorf("Expected unknown format error, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package verifyaudit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/katiem0/gh-collaborators/internal/audit"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdVerifyAudit(f *factory.Factory) *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify-audit [flags] [audit-log]",
+		Short: "Check the audit log has not been tampered with.",
+		Long: "Check the hash chain of the audit log: every entry must match its hash and link to the entry before it, " +
+			"so modified, removed or reordered entries are reported. The log given by --audit-log is checked unless a file is given.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(verifyCmd *cobra.Command, args []string) error {
+			path := f.AuditLog
+			if len(args) > 0 {
+				path = args[0]
+			}
+			if path == "" {
+				return fmt.Errorf("no audit log to verify, set --audit-log or give a file")
+			}
+
+			count, err := audit.Verify(path)
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("no audit log found at %s", path)
+			}
+			if err != nil {
+				return fmt.Errorf("audit log %s failed verification after %d intact entries: %w", path, count, err)
+			}
+			_, _ = fmt.Fprintf(verifyCmd.OutOrStdout(), "Verified %d entries in %s, the hash chain is intact.\n", count, path)
+			return nil
+		},
+	}
+
+	return verifyCmd
+}
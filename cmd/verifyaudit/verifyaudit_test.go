@@ -0,0 +1,74 @@
+package verifyaudit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/audit"
+	"github.com/katiem0/gh-collaborators/internal/factory"
+)
+
+func writeAuditLog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, repo := range []string{"repo1", "repo2"} {
+		if err := l.Append(audit.Entry{Actor: "admin", Org: "test-org", Repository: repo, Username: "alice", OldPermission: "write", NewPermission: "none"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestVerifyAudit(t *testing.T) {
+	f := factory.New()
+	f.AuditLog = writeAuditLog(t)
+
+	var out bytes.Buffer
+	cmd := NewCmdVerifyAudit(f)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Verified 2 entries") {
+		t.Errorf("Expected verified entries, got %q", out.String())
+	}
+}
+
+func TestVerifyAuditTampered(t *testing.T) {
+	path := writeAuditLog(t)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(content), `"repo":"repo2"`, `"repo":"repo3"`, 1)
+	if err := os.WriteFile(path, []byte(tampered), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewCmdVerifyAudit(factory.New())
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{path})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "after 1 intact entries: entry 2 was modified") {
+		t.Errorf("Expected the modified entry reported, got %v", err)
+	}
+}
+
+func TestVerifyAuditMissing(t *testing.T) {
+	cmd := NewCmdVerifyAudit(factory.New())
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "audit.jsonl")})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "no audit log found") {
+		t.Errorf("Expected a missing log error, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const appName = "gh-collaborators"
+
+// Entry is one change of collaborator access. Hash covers every other field,
+// including the previous entry's hash, so editing, reordering or removing an
+// entry breaks the chain from that point on.
+type Entry struct {
+	Seq        int       `json:"seq"`
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	Host       string    `json:"host"`
+	Org        string    `json:"org"`
+	Repository string    `json:"repo"`
+	// Team is set for team membership changes, which have no repository
+	Team          string `json:"team,omitempty"`
+	Username      string `json:"user"`
+	OldPermission string `json:"old_permission"`
+	NewPermission string `json:"new_permission"`
+	RequestID     string `json:"request_id"`
+	Command       string `json:"command"`
+	RunID         string `json:"run_id"`
+	PrevHash      string `json:"prev_hash"`
+	Hash          string `json:"hash"`
+}
+
+// ComputeHash returns the hash of the entry's fields other than Hash.
+func (e Entry) ComputeHash() string {
+	e.Hash = ""
+	content, _ := json.Marshal(e)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Log appends entries to a single JSONL file shared by every run, each
+// chained to the one before it. Runs in other processes may append to the
+// same file at the same time, so the chain is continued from the last line
+// of the file rather than from what this process last wrote.
+type Log struct {
+	Path string
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// DefaultPath returns ~/.local/state/gh-collaborators/audit.jsonl, honoring
+// XDG_STATE_HOME when it is set.
+func DefaultPath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, appName, "audit.jsonl")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", appName, "audit.jsonl")
+}
+
+// Open prepares the log at path for appending, checking its last entry can
+// be read. The file is created on the first append.
+func Open(path string) (*Log, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Log{Path: path, now: time.Now}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := lastEntry(file); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	return &Log{Path: path, now: time.Now}, nil
+}
+
+// Append chains the entry to the log and writes it, synced so it survives
+// the process being killed. The file is locked while its last entry is read
+// and the new one written, so concurrent runs cannot fork the chain.
+func (l *Log) Append(entry Entry) (err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.Path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", l.Path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	if err := lockFile(file); err != nil {
+		return fmt.Errorf("failed to lock audit log %s: %w", l.Path, err)
+	}
+	defer func() { _ = unlockFile(file) }()
+
+	last, err := lastEntry(file)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log %s: %w", l.Path, err)
+	}
+	entry.Seq = 1
+	entry.PrevHash = ""
+	if last != nil {
+		entry.Seq = last.Seq + 1
+		entry.PrevHash = last.Hash
+	}
+	entry.Time = l.now().UTC()
+	entry.Hash = entry.ComputeHash()
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log %s: %w", l.Path, err)
+	}
+	return file.Sync()
+}
+
+// lastEntry returns the last entry of the log open in file, or nil when it
+// has none. The file is read backwards from its end, a chunk at a time,
+// until the start of the last line is found.
+func lastEntry(file *os.File) (*Entry, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	const chunkSize = 4096
+	var tail []byte
+	for offset := info.Size(); offset > 0; {
+		n := int64(chunkSize)
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		chunk := make([]byte, n)
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+		tail = append(chunk, tail...)
+
+		line := bytes.TrimRight(tail, "\n")
+		if start := bytes.LastIndexByte(line, '\n'); start >= 0 || offset == 0 {
+			line = line[start+1:]
+			if len(line) == 0 {
+				return nil, nil
+			}
+			var entry Entry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return nil, fmt.Errorf("failed to parse last entry: %w", err)
+			}
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// Read parses every entry of the log at path without checking the chain.
+func Read(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Verify checks that every entry of the log at path hashes to its recorded
+// hash and links to the entry before it, returning the number of entries.
+// Removing entries from the end of the log cannot be detected this way.
+func Verify(path string) (int, error) {
+	entries, err := Read(path)
+	if err != nil {
+		return 0, err
+	}
+	prevHash := ""
+	for i, entry := range entries {
+		switch {
+		case entry.Seq != i+1:
+			return i, fmt.Errorf("entry %d has sequence number %d, entries were removed or reordered", i+1, entry.Seq)
+		case entry.PrevHash != prevHash:
+			return i, fmt.Errorf("entry %d does not link to the entry before it", entry.Seq)
+		case entry.ComputeHash() != entry.Hash:
+			return i, fmt.Errorf("entry %d was modified, its hash does not match its content", entry.Seq)
+		}
+		prevHash = entry.Hash
+	}
+	return len(entries), nil
+}
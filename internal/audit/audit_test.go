@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+
+	expected := filepath.Join("/tmp/state", "gh-collaborators", "audit.jsonl")
+	if got := DefaultPath(); got != expected {
+		t.Errorf("Expected default path %s, got %s", expected, got)
+	}
+}
+
+func appendEntries(t *testing.T, path string, repos ...string) {
+	t.Helper()
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error opening audit log, got %v", err)
+	}
+	for _, repo := range repos {
+		entry := Entry{Actor: "admin", Host: "github.com", Org: "test-org", Repository: repo, Username: "alice", OldPermission: "none", NewPermission: "push"}
+		if err := l.Append(entry); err != nil {
+			t.Fatalf("Expected no error appending, got %v", err)
+		}
+	}
+}
+
+func TestAppendChainsAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "audit.jsonl")
+	appendEntries(t, path, "repo1", "repo2")
+	appendEntries(t, path, "repo3")
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].PrevHash != "" || entries[2].PrevHash != entries[1].Hash || entries[2].Seq != 3 {
+		t.Errorf("Expected the second run to continue the chain, got %+v", entries)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the audit log readable by its owner only, got %v", info.Mode().Perm())
+	}
+
+	count, err := Verify(path)
+	if err != nil || count != 3 {
+		t.Errorf("Expected 3 verified entries, got %d, %v", count, err)
+	}
+}
+
+func TestAppendConcurrentLogs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// Each log stands in for a separate run appending to the shared file
+	var wg sync.WaitGroup
+	for run := 0; run < 8; run++ {
+		l, err := Open(path)
+		if err != nil {
+			t.Fatalf("Expected no error opening audit log, got %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				if err := l.Append(Entry{Org: "test-org", Repository: "repo1", Username: "alice"}); err != nil {
+					t.Errorf("Expected no error appending, got %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, err := Verify(path)
+	if err != nil || count != 200 {
+		t.Errorf("Expected 200 verified entries, got %d, %v", count, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		err    string
+	}{
+		{
+			name: "modified entry",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"new_permission":"push"`, `"new_permission":"admin"`, 1)
+				return lines
+			},
+			err: "entry 2 was modified",
+		},
+		{
+			name: "removed entry",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			err: "entry 2 has sequence number 3",
+		},
+		{
+			name: "reordered entries",
+			tamper: func(lines []string) []string {
+				lines[0], lines[1] = lines[1], lines[0]
+				return lines
+			},
+			err: "entry 1 has sequence number 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			appendEntries(t, path, "repo1", "repo2", "repo3")
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(content)), "\n"))
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Verify(path); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyMissingLog(t *testing.T) {
+	if _, err := Verify(filepath.Join(t.TempDir(), "audit.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,350 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultTTL = time.Hour
+
+	// HeaderFromCache is set on responses answered from the cache.
+	HeaderFromCache = "X-From-Cache"
+
+	anyKey = "_"
+)
+
+type Key struct {
+	Host string
+	Org  string
+	User string
+	ID   string
+}
+
+type Entry struct {
+	URL        string      `json:"url"`
+	ETag       string      `json:"etag,omitempty"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+type Store struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// DefaultDir returns the user cache directory for the extension.
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gh-collaborators")
+}
+
+func NewStore(dir string, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{dir: dir, ttl: ttl, now: time.Now}
+}
+
+func (s *Store) path(key Key) string {
+	return filepath.Join(s.dir, sanitize(key.Host), sanitize(key.Org), sanitize(key.User), sanitize(key.ID)+".json")
+}
+
+func (s *Store) Get(key Key) (*Entry, bool) {
+	content, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	entry := new(Entry)
+	if err := json.Unmarshal(content, entry); err != nil {
+		zap.S().Debugf("Ignoring unreadable cache entry %s: %v", s.path(key), err)
+		return nil, false
+	}
+	return entry, true
+}
+
+// Fresh reports whether an entry is within the store's TTL.
+func (s *Store) Fresh(entry *Entry) bool {
+	return s.now().Sub(entry.StoredAt) < s.ttl
+}
+
+func (s *Store) Put(key Key, entry *Entry) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	entry.StoredAt = s.now()
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so readers never see a partial entry
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Invalidate drops the cached entries for a user in an organization, along
+// with organization wide entries that may include that user.
+func (s *Store) Invalidate(host string, org string, user string) error {
+	paths := []string{filepath.Join(s.dir, sanitize(host), sanitize(org), sanitize(anyKey))}
+	if user != "" && user != anyKey {
+		paths = append(paths, filepath.Join(s.dir, sanitize(host), sanitize(org), sanitize(user)))
+	}
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func sanitize(part string) string {
+	if part == "" {
+		part = anyKey
+	}
+	part = strings.ToLower(part)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Trim(part, "."))
+}
+
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+type liveKey struct{}
+
+// Live marks requests made with ctx as needing GitHub's current answer,
+// for reads that decide a change. They are never answered from a cached
+// GraphQL response or a stale entry.
+func Live(ctx context.Context) context.Context {
+	return context.WithValue(ctx, liveKey{}, true)
+}
+
+func isLive(ctx context.Context) bool {
+	live, _ := ctx.Value(liveKey{}).(bool)
+	return live
+}
+
+// Transport caches REST GET responses and revalidates them with their ETag,
+// a 304 response does not count against the rate limit. GraphQL queries are
+// cached for the store's TTL. Successful mutations invalidate the entries
+// for the organization and user they touched.
+type Transport struct {
+	Store *Store
+	Base  http.RoundTripper
+	// Credential identifies the token or GitHub App the responses are
+	// fetched with, entries are only served to the same credential
+	Credential string
+	// Offline answers REST reads from the cache, however old, when GitHub
+	// cannot be reached. Live and canceled requests are never answered this
+	// way.
+	Offline bool
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case req.Method == http.MethodGet:
+		return t.roundTripREST(req)
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/graphql"):
+		return t.roundTripGraphQL(req)
+	default:
+		resp, err := t.base().RoundTrip(req)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			org, user := restScope(req.URL.Path)
+			if org != anyKey {
+				if invalidateErr := t.Store.Invalidate(req.URL.Host, org, user); invalidateErr != nil {
+					zap.S().Warnf("Error invalidating cache for %s/%s: %v", org, user, invalidateErr)
+				}
+			}
+		}
+		return resp, err
+	}
+}
+
+func (t *Transport) roundTripREST(req *http.Request) (*http.Response, error) {
+	org, user := restScope(req.URL.Path)
+	key := Key{Host: req.URL.Host, Org: org, User: user, ID: hashKey(t.Credential, req.URL.String(), req.Header.Get("Accept"))}
+	cached, ok := t.Store.Get(key)
+
+	if ok && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		if ok && t.Offline && req.Context().Err() == nil && !isLive(req.Context()) {
+			zap.S().Debugf("Request to %s failed, serving cached response: %v", req.URL, err)
+			return cached.response(req), nil
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		zap.S().Debugf("Cached response for %s is still valid", req.URL)
+		drain(resp)
+		return cached.response(req), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	return t.store(key, etag, req, resp)
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func (t *Transport) roundTripGraphQL(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return t.base().RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	var payload graphQLRequest
+	if err := json.Unmarshal(body, &payload); err != nil || strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation") {
+		return t.base().RoundTrip(req)
+	}
+
+	key := Key{
+		Host: req.URL.Host,
+		Org:  stringVariable(payload.Variables, "owner"),
+		User: stringVariable(payload.Variables, "user"),
+		ID:   hashKey(t.Credential, req.URL.String(), string(body)),
+	}
+	if cached, ok := t.Store.Get(key); ok && t.Store.Fresh(cached) && !isLive(req.Context()) {
+		zap.S().Debugf("Serving cached GraphQL response for %s/%s", key.Org, key.User)
+		return cached.response(req), nil
+	}
+
+	resp, err := t.base().RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	return t.store(key, "", req, resp)
+}
+
+func (t *Transport) store(key Key, etag string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Never cache GraphQL responses carrying errors, they are usually
+	// transient and would otherwise stick around for the whole TTL
+	if etag == "" && bytes.Contains(body, []byte(`"errors"`)) {
+		return resp, nil
+	}
+
+	entry := &Entry{
+		URL:        req.URL.String(),
+		ETag:       etag,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+	}
+	if err := t.Store.Put(key, entry); err != nil {
+		zap.S().Warnf("Error writing cache entry for %s: %v", req.URL, err)
+	}
+	return resp, nil
+}
+
+func (e *Entry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(HeaderFromCache, "1")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// restScope derives the organization and user a REST path belongs to, so
+// entries can be stored and invalidated per host/org/user.
+func restScope(path string) (string, string) {
+	path = strings.TrimPrefix(path, "/api/v3")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "orgs":
+		return segments[1], anyKey
+	case len(segments) >= 5 && segments[0] == "repos" && segments[3] == "collaborators":
+		return segments[1], segments[4]
+	case len(segments) >= 2 && segments[0] == "repos":
+		return segments[1], anyKey
+	default:
+		return anyKey, anyKey
+	}
+}
+
+func stringVariable(variables map[string]interface{}, name string) string {
+	if value, ok := variables[name].(string); ok {
+		return value
+	}
+	return anyKey
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCachedClient(t *testing.T, ttl time.Duration) (*http.Client, *Store) {
+	t.Helper()
+	store := NewStore(t.TempDir(), ttl)
+	return &http.Client{Transport: &Transport{Store: store}}, store
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return string(body)
+}
+
+func TestRestScope(t *testing.T) {
+	tests := []struct {
+		path string
+		org  string
+		user string
+	}{
+		{path: "/orgs/test-org/outside_collaborators", org: "test-org", user: "_"},
+		{path: "/api/v3/orgs/test-org/outside_collaborators", org: "test-org", user: "_"},
+		{path: "/repos/test-org/repo1/collaborators/user1", org: "test-org", user: "user1"},
+		{path: "/repos/test-org/repo1/collaborators/user1/permission", org: "test-org", user: "user1"},
+		{path: "/repos/test-org/repo1", org: "test-org", user: "_"},
+		{path: "/user", org: "_", user: "_"},
+	}
+
+	for _, tt := range tests {
+		org, user := restScope(tt.path)
+		if org != tt.org || user != tt.user {
+			t.Errorf("Expected scope %s/%s for %s, got %s/%s", tt.org, tt.user, tt.path, org, user)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"":                "_",
+		"Test-Org":        "test-org",
+		"127.0.0.1:8080":  "127.0.0.1_8080",
+		"../escape":       "_escape",
+		"user/with/slash": "user_with_slash",
+	}
+
+	for input, expected := range tests {
+		if got := sanitize(input); got != expected {
+			t.Errorf("Expected sanitize(%q) to be %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestTransportRevalidatesWithETag(t *testing.T) {
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = fmt.Fprint(w, `[{"login":"user1"}]`)
+	}))
+	defer server.Close()
+
+	client, _ := newCachedClient(t, time.Hour)
+	url := server.URL + "/orgs/test-org/outside_collaborators"
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if body := readBody(t, resp); body != `[{"login":"user1"}]` {
+			t.Errorf("Expected cached body, got %s", body)
+		}
+		if i == 1 && resp.Header.Get(HeaderFromCache) != "1" {
+			t.Error("Expected second response to be served from cache")
+		}
+	}
+
+	if requests != 2 || notModified != 1 {
+		t.Errorf("Expected 2 requests with 1 revalidation, got %d requests and %d revalidations", requests, notModified)
+	}
+}
+
+func TestTransportServesStaleOnlyOffline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = fmt.Fprint(w, `[]`)
+	}))
+	store := NewStore(t.TempDir(), time.Hour)
+	transport := &Transport{Store: store}
+	client := &http.Client{Transport: transport}
+	url := server.URL + "/orgs/test-org/outside_collaborators"
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	readBody(t, resp)
+	server.Close()
+
+	// Without --offline a failed request fails
+	if _, err := client.Get(url); err == nil {
+		t.Error("Expected an error while GitHub is unreachable, got a cached response")
+	}
+
+	transport.Offline = true
+	resp, err = client.Get(url)
+	if err != nil {
+		t.Fatalf("Expected cached response while offline, got %v", err)
+	}
+	if body := readBody(t, resp); body != `[]` {
+		t.Errorf("Expected cached body, got %s", body)
+	}
+
+	// Canceled requests and reads deciding a change are never answered
+	// from the cache
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for name, ctx := range map[string]context.Context{"canceled": canceled, "live": Live(context.Background())} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if resp, err := client.Do(req); err == nil {
+			readBody(t, resp)
+			t.Errorf("Expected an error for a %s request, got a cached response", name)
+		}
+	}
+}
+
+func TestTransportSeparatesCredentials(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		_, _ = fmt.Fprintf(w, `{"data":{"n":%d}}`, n)
+	}))
+	defer server.Close()
+
+	store := NewStore(t.TempDir(), time.Hour)
+	query := `{"query":"query{viewer{login}}","variables":{"owner":"test-org"}}`
+	post := func(credential string, ctx context.Context) string {
+		client := &http.Client{Transport: &Transport{Store: store, Credential: credential}}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/graphql", strings.NewReader(query))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return readBody(t, resp)
+	}
+
+	admin := post("token:admin", context.Background())
+	if reader := post("token:reader", context.Background()); reader == admin {
+		t.Errorf("Expected another credential's response not to be served, got %s", reader)
+	}
+	if again := post("token:admin", context.Background()); again != admin {
+		t.Errorf("Expected the same credential to be served from cache, got %s", again)
+	}
+	if live := post("token:admin", Live(context.Background())); live == admin || requests != 3 {
+		t.Errorf("Expected a live query to reach the server, got %s after %d requests", live, requests)
+	}
+}
+
+func TestTransportCachesGraphQLWithinTTL(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		_, _ = fmt.Fprintf(w, `{"data":{"n":%d}}`, n)
+	}))
+	defer server.Close()
+
+	client, store := newCachedClient(t, time.Hour)
+	query := `{"query":"query{viewer{login}}","variables":{"owner":"test-org","user":"user1"}}`
+
+	post := func() string {
+		resp, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(query))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return readBody(t, resp)
+	}
+
+	first := post()
+	second := post()
+	if first != second || requests != 1 {
+		t.Errorf("Expected second query to be served from cache, got %s then %s after %d requests", first, second, requests)
+	}
+
+	// Once the TTL passes the query is sent again
+	store.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if third := post(); third == first || requests != 2 {
+		t.Errorf("Expected expired entry to be refreshed, got %s after %d requests", third, requests)
+	}
+}
+
+func TestTransportSkipsGraphQLErrorsAndMutations(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = fmt.Fprint(w, `{"errors":[{"message":"timeout"}]}`)
+	}))
+	defer server.Close()
+
+	client, _ := newCachedClient(t, time.Hour)
+	for _, query := range []string{
+		`{"query":"query{viewer{login}}"}`,
+		`{"query":"query{viewer{login}}"}`,
+		`{"query":"mutation{addStar}"}`,
+		`{"query":"mutation{addStar}"}`,
+	} {
+		resp, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(query))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		readBody(t, resp)
+	}
+
+	if requests != 4 {
+		t.Errorf("Expected every request to reach the server, got %d", requests)
+	}
+}
+
+func TestTransportInvalidatesAfterMutation(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		_, _ = fmt.Fprint(w, `{"data":{}}`)
+	}))
+	defer server.Close()
+
+	client, _ := newCachedClient(t, time.Hour)
+	query := `{"query":"query{viewer{login}}","variables":{"owner":"test-org","user":"user1"}}`
+	post := func() {
+		resp, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(query))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		readBody(t, resp)
+	}
+
+	post()
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/repos/test-org/repo1/collaborators/user1", strings.NewReader(`{"permission":"push"}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	readBody(t, resp)
+	post()
+
+	if requests != 2 {
+		t.Errorf("Expected cache to be invalidated by the mutation, got %d queries", requests)
+	}
+}
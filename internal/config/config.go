@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	appName        = "gh-collaborators"
+	configFileName = "config.yaml"
+)
+
+type Profile struct {
+	Hostname       string `yaml:"hostname"`
+	Org            string `yaml:"org"`
+	TokenSource    string `yaml:"token_source"`
+	AppID          int64  `yaml:"app_id"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+	InstallationID int64  `yaml:"installation_id"`
+	OutputFormat   string `yaml:"output_format"`
+	Concurrency    int    `yaml:"concurrency"`
+	PolicyFile     string `yaml:"policy_file"`
+	Notify         Notify `yaml:"notify"`
+}
+
+// Notify configures where add and remove send a digest of each run's
+// changes, a section left out sends nothing there.
+type Notify struct {
+	Webhook *WebhookNotify `yaml:"webhook"`
+	Slack   *SlackNotify   `yaml:"slack"`
+	Email   *EmailNotify   `yaml:"email"`
+	GitHub  *GitHubNotify  `yaml:"github"`
+}
+
+// WebhookNotify posts the digest as JSON to a URL, the URL and header values
+// may be env:NAME or file:PATH to keep secrets out of the file.
+type WebhookNotify struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// SlackNotify posts the digest to a Slack compatible incoming webhook, the
+// URL may be env:NAME or file:PATH.
+type SlackNotify struct {
+	WebhookURL string `yaml:"webhook_url"`
+}
+
+// EmailNotify mails the digest through an SMTP server, which is expected to
+// offer STARTTLS when a password is sent.
+type EmailNotify struct {
+	Host           string   `yaml:"host"`
+	Port           int      `yaml:"port"`
+	Username       string   `yaml:"username"`
+	PasswordSource string   `yaml:"password_source"`
+	From           string   `yaml:"from"`
+	To             []string `yaml:"to"`
+}
+
+// GitHubNotify opens an issue on every changed repository, comments the
+// digest on one tracking issue, or both.
+type GitHubNotify struct {
+	Issues               bool   `yaml:"issues"`
+	MentionCollaborators bool   `yaml:"mention_collaborators"`
+	Repository           string `yaml:"repository"`
+	IssueNumber          int    `yaml:"issue_number"`
+}
+
+// Enabled reports whether any notification is configured.
+func (n Notify) Enabled() bool {
+	return n.Webhook != nil || n.Slack != nil || n.Email != nil || n.GitHub != nil
+}
+
+type Config struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// DefaultPath returns ~/.config/gh-collaborators/config.yaml, honoring
+// XDG_CONFIG_HOME when it is set.
+func DefaultPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, appName, configFileName)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", appName, configFileName)
+}
+
+// Load reads the configuration file, a missing file is an empty configuration.
+func Load(path string) (*Config, error) {
+	cfg := &Config{Profiles: map[string]Profile{}}
+	if path == "" {
+		return cfg, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	for name, profile := range cfg.Profiles {
+		profile.PrivateKeyPath = expandHome(profile.PrivateKeyPath)
+		profile.PolicyFile = expandHome(profile.PolicyFile)
+		cfg.Profiles[name] = profile
+	}
+	return cfg, nil
+}
+
+// Profile returns the named profile, or the default profile when name is
+// empty. A nil profile is returned when no profile applies.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found, available profiles: %s", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	return &profile, nil
+}
+
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveToken reads the token described by the profile's token source:
+// "env:NAME" reads an environment variable, "file:PATH" reads a file and
+// "gh" or an empty source defers to the gh auth token.
+func (p *Profile) ResolveToken() (string, error) {
+	source := strings.TrimSpace(p.TokenSource)
+	switch {
+	case source == "" || source == "gh":
+		return "", nil
+	case strings.HasPrefix(source, "env:") || strings.HasPrefix(source, "file:"):
+		return ResolveSecret("token_source", source)
+	default:
+		return "", fmt.Errorf("unsupported token_source %q, expected gh, env:NAME or file:PATH", p.TokenSource)
+	}
+}
+
+// ResolveSecret reads a secret setting named field: "env:NAME" reads an
+// environment variable, "file:PATH" reads a file and any other value is the
+// secret itself.
+func ResolveSecret(field string, source string) (string, error) {
+	source = strings.TrimSpace(source)
+	switch {
+	case strings.HasPrefix(source, "env:"):
+		name := strings.TrimPrefix(source, "env:")
+		secret := os.Getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s from %s is not set", name, field)
+		}
+		return secret, nil
+	case strings.HasPrefix(source, "file:"):
+		path := strings.TrimPrefix(source, "file:")
+		content, err := os.ReadFile(expandHome(path))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s file %s: %w", field, path, err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	default:
+		return source, nil
+	}
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
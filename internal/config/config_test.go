@@ -0,0 +1,187 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfig = `default_profile: cloud
+profiles:
+  cloud:
+    hostname: github.com
+    org: cloud-org
+    token_source: env:TEST_COLLAB_TOKEN
+    output_format: csv
+    concurrency: 4
+  ghes:
+    hostname: github.example.com
+    org: ghes-org
+    app_id: 123
+    private_key_path: ~/keys/app.pem
+    installation_id: 456
+    policy_file: ~/policy.yaml
+    notify:
+      slack:
+        webhook_url: env:SLACK_WEBHOOK_URL
+      github:
+        issues: true
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+
+	expected := filepath.Join("/tmp/xdg", "gh-collaborators", "config.yaml")
+	if got := DefaultPath(); got != expected {
+		t.Errorf("Expected default path %s, got %s", expected, got)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	home, _ := os.UserHomeDir()
+	expected = filepath.Join(home, ".config", "gh-collaborators", "config.yaml")
+	if got := DefaultPath(); got != expected {
+		t.Errorf("Expected default path %s, got %s", expected, got)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Expected no error for missing file, got %v", err)
+	}
+	if len(cfg.Profiles) != 0 {
+		t.Errorf("Expected no profiles, got %d", len(cfg.Profiles))
+	}
+
+	profile, err := cfg.Profile("")
+	if err != nil || profile != nil {
+		t.Errorf("Expected no profile and no error, got %v, %v", profile, err)
+	}
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	path := writeConfig(t, "profiles: [not, a, map")
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for invalid YAML, got nil")
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	cfg, err := Load(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	names := cfg.ProfileNames()
+	if len(names) != 2 || names[0] != "cloud" || names[1] != "ghes" {
+		t.Errorf("Expected sorted profile names [cloud ghes], got %v", names)
+	}
+
+	profile, err := cfg.Profile("")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if profile.Org != "cloud-org" || profile.Concurrency != 4 || profile.OutputFormat != "csv" {
+		t.Errorf("Expected default profile 'cloud', got %+v", profile)
+	}
+
+	profile, err = cfg.Profile("ghes")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	home, _ := os.UserHomeDir()
+	if profile.PrivateKeyPath != filepath.Join(home, "keys", "app.pem") {
+		t.Errorf("Expected private key path to expand home, got %s", profile.PrivateKeyPath)
+	}
+	if profile.PolicyFile != filepath.Join(home, "policy.yaml") {
+		t.Errorf("Expected policy file to expand home, got %s", profile.PolicyFile)
+	}
+	if profile.AppID != 123 || profile.InstallationID != 456 {
+		t.Errorf("Expected app settings, got %+v", profile)
+	}
+	if !profile.Notify.Enabled() || profile.Notify.Slack.WebhookURL != "env:SLACK_WEBHOOK_URL" || !profile.Notify.GitHub.Issues || profile.Notify.Email != nil {
+		t.Errorf("Expected slack and github notifications, got %+v", profile.Notify)
+	}
+	if cloud, _ := cfg.Profile("cloud"); cloud.Notify.Enabled() {
+		t.Errorf("Expected no notifications for cloud, got %+v", cloud.Notify)
+	}
+
+	if _, err := cfg.Profile("missing"); err == nil {
+		t.Error("Expected error for unknown profile, got nil")
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	t.Setenv("TEST_COLLAB_TOKEN", "env-token")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		source    string
+		expected  string
+		expectErr bool
+	}{
+		{name: "empty defers to gh", source: "", expected: ""},
+		{name: "gh", source: "gh", expected: ""},
+		{name: "env", source: "env:TEST_COLLAB_TOKEN", expected: "env-token"},
+		{name: "unset env", source: "env:TEST_COLLAB_MISSING", expectErr: true},
+		{name: "file", source: "file:" + tokenFile, expected: "file-token"},
+		{name: "missing file", source: "file:/nonexistent/token", expectErr: true},
+		{name: "unsupported", source: "vault:secret", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Profile{TokenSource: tt.source}
+			token, err := p.ResolveToken()
+			if tt.expectErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if token != tt.expected {
+				t.Errorf("Expected token %q, got %q", tt.expected, token)
+			}
+		})
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("TEST_COLLAB_SECRET", "env-secret")
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+
+	for source, expected := range map[string]string{
+		"env:TEST_COLLAB_SECRET":    "env-secret",
+		"file:" + secretFile:        "file-secret",
+		"https://hooks.example.com": "https://hooks.example.com",
+	} {
+		secret, err := ResolveSecret("webhook_url", source)
+		if err != nil || secret != expected {
+			t.Errorf("Expected %q from %s, got %q, %v", expected, source, secret, err)
+		}
+	}
+
+	_, err := ResolveSecret("password_source", "env:TEST_COLLAB_MISSING")
+	if err == nil || err.Error() != "environment variable TEST_COLLAB_MISSING from password_source is not set" {
+		t.Errorf("Expected unset variable error, got %v", err)
+	}
+}
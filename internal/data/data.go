@@ -1,5 +1,10 @@
 package data
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type Edge struct {
 	Permission string
 	Node       struct {
@@ -7,29 +12,60 @@ type Edge struct {
 	}
 }
 
-type RepoInfo struct {
-	DatabaseId    int    `json:"databaseId"`
-	Name          string `json:"name"`
-	Visibility    string `json:"visibility"`
-	Collaborators struct {
-		Edges []Edge
-	} `graphql:"collaborators(first:1, query: $user)"`
-}
-
-type OrganizationUserQuery struct {
+// BatchRepoPermissionsQuery is the response to a query checking many users
+// against each page of repositories at once.
+type BatchRepoPermissionsQuery struct {
+	RateLimit struct {
+		Cost      int
+		Remaining int
+	}
 	Organization struct {
 		Repositories struct {
-			Nodes    []RepoInfo
+			Nodes    []BatchRepoInfo
 			PageInfo struct {
 				EndCursor   string
 				HasNextPage bool
 			}
-		} `graphql:"repositories(first: 100, after: $endCursor)"`
-	} `graphql:"organization(login: $owner)"`
+		}
+	}
 }
 
-type RepoSingleQuery struct {
-	Repository RepoInfo `graphql:"repository(owner: $owner, name: $name)"`
+// BatchRepoInfo is a repository in a batched query, Collaborators holds the
+// collaborator edges found for each user keyed by the user's field alias.
+type BatchRepoInfo struct {
+	DatabaseId    int
+	Name          string
+	Visibility    string
+	Collaborators map[string][]Edge
+}
+
+func (r *BatchRepoInfo) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	r.Collaborators = map[string][]Edge{}
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "databaseId":
+			err = json.Unmarshal(value, &r.DatabaseId)
+		case "name":
+			err = json.Unmarshal(value, &r.Name)
+		case "visibility":
+			err = json.Unmarshal(value, &r.Visibility)
+		default:
+			var collaborators struct {
+				Edges []Edge
+			}
+			err = json.Unmarshal(value, &collaborators)
+			r.Collaborators[key] = collaborators.Edges
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse repository field %s: %w", key, err)
+		}
+	}
+	return nil
 }
 
 type RepoCollaborators struct {
@@ -47,3 +83,99 @@ type ImportedRepoCollab struct {
 type Permission struct {
 	Permission string `json:"permission"`
 }
+
+type CollaboratorPermission struct {
+	Permission string `json:"permission"`
+	RoleName   string `json:"role_name"`
+}
+
+type User struct {
+	Login string `json:"login"`
+	ID    int    `json:"id"`
+}
+
+type Organization struct {
+	Login                       string `json:"login"`
+	DefaultRepositoryPermission string `json:"default_repository_permission"`
+}
+
+type OrgMembership struct {
+	State string `json:"state"`
+	Role  string `json:"role"`
+}
+
+type RepoCollaborator struct {
+	Login       string          `json:"login"`
+	ID          int             `json:"id"`
+	RoleName    string          `json:"role_name"`
+	Permissions map[string]bool `json:"permissions"`
+}
+
+type Team struct {
+	ID   int    `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type TeamRepository struct {
+	Name     string `json:"name"`
+	RoleName string `json:"role_name"`
+}
+
+type OrgInvitation struct {
+	InviteeID int    `json:"invitee_id"`
+	Role      string `json:"role"`
+	TeamIDs   []int  `json:"team_ids,omitempty"`
+}
+
+// RepoInvitation is a pending invitation to collaborate on a repository,
+// made instead of a grant when the user is not in the organization.
+type RepoInvitation struct {
+	ID          int64  `json:"id"`
+	Invitee     User   `json:"invitee"`
+	Permissions string `json:"permissions"`
+}
+
+type Issue struct {
+	Number  int    `json:"number,omitempty"`
+	Title   string `json:"title"`
+	Body    string `json:"body,omitempty"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+type IssueComment struct {
+	ID      int64  `json:"id,omitempty"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// AuditLogEvent is an organization audit log entry, the repository is the
+// full owner/name and the timestamp is in milliseconds.
+type AuditLogEvent struct {
+	Action        string `json:"action"`
+	Actor         string `json:"actor"`
+	User          string `json:"user"`
+	Repo          string `json:"repo"`
+	Permission    string `json:"permission"`
+	OldPermission string `json:"old_permission"`
+	Timestamp     int64  `json:"@timestamp"`
+}
+
+type MutationResult struct {
+	RepositoryName     string `json:"repository"`
+	Username           string `json:"username"`
+	Permission         string `json:"permission"`
+	PreviousPermission string `json:"previous_permission"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	// RequestID is GitHub's ID for the request that made the change
+	RequestID string `json:"request_id,omitempty"`
+	// InvitationID is set when the grant invited the user rather than
+	// adding them, so it can be withdrawn while still pending
+	InvitationID int64 `json:"invitation_id,omitempty"`
+	// Team is set when the change was to the user's membership of a team,
+	// membership changes carry no repository
+	Team string `json:"team,omitempty"`
+	// Err is the error behind a failed result, kept for retry decisions
+	Err error `json:"-"`
+}
@@ -1,54 +1,10 @@
 package data
 
 import (
+	"encoding/json"
 	"testing"
 )
 
-func TestRepoInfo(t *testing.T) {
-	repo := RepoInfo{
-		DatabaseId: 123,
-		Name:       "test-repo",
-		Visibility: "private",
-		Collaborators: struct {
-			Edges []Edge
-		}{
-			Edges: []Edge{
-				{
-					Permission: "admin",
-					Node: struct {
-						Login string
-					}{Login: "testuser"},
-				},
-			},
-		},
-	}
-
-	if repo.DatabaseId != 123 {
-		t.Errorf("Expected DatabaseId 123, got %d", repo.DatabaseId)
-	}
-
-	if repo.Name != "test-repo" {
-		t.Errorf("Expected Name 'test-repo', got %s", repo.Name)
-	}
-
-	if repo.Visibility != "private" {
-		t.Errorf("Expected Visibility 'private', got %s", repo.Visibility)
-	}
-
-	if len(repo.Collaborators.Edges) != 1 {
-		t.Errorf("Expected 1 collaborator edge, got %d", len(repo.Collaborators.Edges))
-	}
-
-	edge := repo.Collaborators.Edges[0]
-	if edge.Permission != "admin" {
-		t.Errorf("Expected permission 'admin', got %s", edge.Permission)
-	}
-
-	if edge.Node.Login != "testuser" {
-		t.Errorf("Expected login 'testuser', got %s", edge.Node.Login)
-	}
-}
-
 func TestRepoCollaborators(t *testing.T) {
 	collab := RepoCollaborators{
 		Login: "testuser",
@@ -99,60 +55,50 @@ func TestPermission(t *testing.T) {
 	}
 }
 
-func TestOrganizationUserQuery(t *testing.T) {
-	query := OrganizationUserQuery{
-		Organization: struct {
-			Repositories struct {
-				Nodes    []RepoInfo
-				PageInfo struct {
-					EndCursor   string
-					HasNextPage bool
-				}
-			} `graphql:"repositories(first: 100, after: $endCursor)"`
-		}{
-			Repositories: struct {
-				Nodes    []RepoInfo
-				PageInfo struct {
-					EndCursor   string
-					HasNextPage bool
-				}
-			}{
-				Nodes: []RepoInfo{
-					{
-						DatabaseId: 456,
-						Name:       "another-repo",
-						Visibility: "public",
-					},
-				},
-				PageInfo: struct {
-					EndCursor   string
-					HasNextPage bool
-				}{
-					EndCursor:   "cursor456",
-					HasNextPage: true,
-				},
-			},
-		},
-	}
-
-	if len(query.Organization.Repositories.Nodes) != 1 {
-		t.Errorf("Expected 1 repository node, got %d", len(query.Organization.Repositories.Nodes))
-	}
-
-	repo := query.Organization.Repositories.Nodes[0]
-	if repo.DatabaseId != 456 {
-		t.Errorf("Expected DatabaseId 456, got %d", repo.DatabaseId)
-	}
-
-	if repo.Name != "another-repo" {
-		t.Errorf("Expected Name 'another-repo', got %s", repo.Name)
-	}
-
-	if query.Organization.Repositories.PageInfo.EndCursor != "cursor456" {
-		t.Errorf("Expected EndCursor 'cursor456', got %s", query.Organization.Repositories.PageInfo.EndCursor)
-	}
-
-	if !query.Organization.Repositories.PageInfo.HasNextPage {
-		t.Error("Expected HasNextPage to be true")
+func TestMutationResult(t *testing.T) {
+	result := MutationResult{
+		RepositoryName:     "repo1",
+		Username:           "user1",
+		Permission:         "push",
+		PreviousPermission: "admin",
+		Status:             "blocked",
+		Reason:             "would downgrade admin to write",
+	}
+
+	content, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `{"repository":"repo1","username":"user1","permission":"push","previous_permission":"admin","status":"blocked","reason":"would downgrade admin to write"}`
+	if string(content) != expected {
+		t.Errorf("Expected %s, got %s", expected, content)
+	}
+}
+
+func TestBatchRepoInfoUnmarshal(t *testing.T) {
+	body := `{
+		"databaseId": 101,
+		"name": "repo1",
+		"visibility": "PRIVATE",
+		"u0": {"edges": [{"permission": "WRITE", "node": {"login": "alice"}}]},
+		"u1": {"edges": []}
+	}`
+
+	var repo BatchRepoInfo
+	if err := json.Unmarshal([]byte(body), &repo); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if repo.DatabaseId != 101 || repo.Name != "repo1" || repo.Visibility != "PRIVATE" {
+		t.Errorf("Unexpected repository fields %+v", repo)
+	}
+	if edges := repo.Collaborators["u0"]; len(edges) != 1 || edges[0].Permission != "WRITE" || edges[0].Node.Login != "alice" {
+		t.Errorf("Expected alice's edge under u0, got %v", edges)
+	}
+	if edges, ok := repo.Collaborators["u1"]; !ok || len(edges) != 0 {
+		t.Errorf("Expected no edges under u1, got %v", edges)
+	}
+
+	if err := json.Unmarshal([]byte(`{"name": 5}`), &repo); err == nil {
+		t.Error("Expected error for invalid name")
 	}
 }
@@ -0,0 +1,493 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/cli/go-gh/v2/pkg/prompter"
+	"github.com/katiem0/gh-collaborators/internal/audit"
+	"github.com/katiem0/gh-collaborators/internal/cache"
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/ghapp"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/log"
+	"github.com/katiem0/gh-collaborators/internal/notify"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"golang.org/x/term"
+)
+
+const defaultHostname = "github.com"
+
+type Options struct {
+	Token          string
+	Hostname       string
+	AppID          int64
+	PrivateKeyPath string
+	InstallationID int64
+	Owner          string
+	CacheDir       string
+	CacheTTL       time.Duration
+	Offline        bool
+	Timeout        time.Duration
+	Transport      http.RoundTripper
+}
+
+func (o Options) UseApp() bool {
+	return o.AppID != 0 || o.PrivateKeyPath != "" || o.InstallationID != 0
+}
+
+// credential identifies who responses are cached for: the GitHub App and
+// its installation, whose tokens rotate, or the token itself.
+func (o Options) credential(token string) string {
+	if o.UseApp() {
+		return fmt.Sprintf("app:%d:%d:%s", o.AppID, o.InstallationID, strings.ToLower(o.Owner))
+	}
+	return "token:" + token
+}
+
+// NewClientOptions resolves authentication for the REST and GraphQL clients,
+// either from a token or by authenticating as a GitHub App installation.
+func NewClientOptions(opts Options) (api.ClientOptions, error) {
+	clientOpts := api.ClientOptions{
+		Host:    opts.Hostname,
+		Timeout: opts.Timeout,
+	}
+	var transport http.RoundTripper = http.DefaultTransport
+	if opts.Transport != nil {
+		transport = opts.Transport
+		clientOpts.Transport = transport
+	}
+
+	if !opts.UseApp() {
+		if opts.Token != "" {
+			clientOpts.AuthToken = opts.Token
+		} else {
+			t, _ := auth.TokenForHost(opts.Hostname)
+			clientOpts.AuthToken = t
+		}
+	} else {
+		if opts.Token != "" {
+			return clientOpts, fmt.Errorf("--token cannot be combined with GitHub App authentication")
+		}
+
+		source, err := ghapp.NewTokenSource(ghapp.Config{
+			AppID:          opts.AppID,
+			PrivateKeyPath: opts.PrivateKeyPath,
+			InstallationID: opts.InstallationID,
+			Hostname:       opts.Hostname,
+			Org:            opts.Owner,
+			Transport:      transport,
+		})
+		if err != nil {
+			return clientOpts, err
+		}
+
+		// Fetch the first token up front so authentication problems surface
+		// before any work starts
+		token, err := source.Token()
+		if err != nil {
+			return clientOpts, err
+		}
+		clientOpts.AuthToken = token
+		transport = &ghapp.Transport{Source: source, Base: transport}
+		clientOpts.Transport = transport
+	}
+
+	if opts.CacheDir != "" {
+		zap.S().Debugf("Caching responses in %s", opts.CacheDir)
+		clientOpts.Transport = &cache.Transport{
+			Store:      cache.NewStore(opts.CacheDir, opts.CacheTTL),
+			Base:       transport,
+			Credential: opts.credential(clientOpts.AuthToken),
+			Offline:    opts.Offline,
+		}
+	}
+	return clientOpts, nil
+}
+
+func NewAPIGetter(opts Options) (*utils.APIGetter, error) {
+	clientOpts, err := NewClientOptions(opts)
+	if err != nil {
+		zap.S().Errorf("Error arose resolving authentication")
+		return nil, err
+	}
+
+	restOpts := clientOpts
+	restOpts.Headers = map[string]string{
+		"Accept": "application/vnd.github+json",
+	}
+	restClient, err := api.NewRESTClient(restOpts)
+	if err != nil {
+		zap.S().Errorf("Error arose retrieving rest client")
+		return nil, err
+	}
+
+	gqlOpts := clientOpts
+	gqlOpts.Headers = map[string]string{
+		"Accept": "application/vnd.github.hawkgirl-preview+json",
+	}
+	gqlClient, err := api.NewGraphQLClient(gqlOpts)
+	if err != nil {
+		zap.S().Errorf("Error arose retrieving graphql client")
+		return nil, err
+	}
+
+	return utils.NewAPIGetter(gqlClient, restClient), nil
+}
+
+type Factory struct {
+	Token          string
+	Hostname       string
+	Debug          bool
+	LogFormat      string
+	LogFile        string
+	LogLevel       string
+	AppID          int64
+	PrivateKeyPath string
+	InstallationID int64
+	ProfileName    string
+	ConfigPath     string
+	NoCache        bool
+	CacheDir       string
+	CacheTTL       time.Duration
+	Offline        bool
+	JournalDir     string
+	AuditLog       string
+	Concurrency    int
+	RateLimit      int
+	Timeout        time.Duration
+
+	// Transport replaces the HTTP transport of every client, tests use it
+	// to send requests to a local server
+	Transport http.RoundTripper
+
+	// Profile is the selected configuration profile, nil when none applies
+	Profile *config.Profile
+
+	// NewGetter builds the API client used by every subcommand, tests
+	// replace it to inject a fake Getter.
+	NewGetter func(owner string) (utils.Getter, error)
+
+	// IsInteractive reports whether the user can answer prompts, and
+	// Confirm asks them a yes or no question. Tests replace both.
+	IsInteractive func() bool
+	Confirm       func(question string) (bool, error)
+}
+
+// ErrAborted is returned when the user declines to make a change
+var ErrAborted = errors.New("aborted, no changes made")
+
+func New() *Factory {
+	f := &Factory{ConfigPath: config.DefaultPath()}
+	f.NewGetter = func(owner string) (utils.Getter, error) {
+		g, err := NewAPIGetter(f.Options(owner))
+		if err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
+	f.IsInteractive = func() bool {
+		return term.IsTerminal(int(os.Stdin.Fd()))
+	}
+	f.Confirm = func(question string) (bool, error) {
+		return prompter.New(os.Stdin, os.Stdout, os.Stderr).Confirm(question, false)
+	}
+	return f
+}
+
+func (f *Factory) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&f.Token, "token", "t", "", `GitHub Personal Access Token (default "gh auth token")`)
+	cmd.PersistentFlags().StringVarP(&f.Hostname, "hostname", "", defaultHostname, "GitHub Enterprise Server hostname")
+	cmd.PersistentFlags().Int64VarP(&f.AppID, "app-id", "", 0, "GitHub App ID to authenticate as (requires --private-key-path)")
+	cmd.PersistentFlags().StringVarP(&f.PrivateKeyPath, "private-key-path", "", "", "Path to the GitHub App private key PEM file")
+	cmd.PersistentFlags().Int64VarP(&f.InstallationID, "installation-id", "", 0, "GitHub App installation ID (default discovered for the organization)")
+	cmd.PersistentFlags().BoolVarP(&f.Debug, "debug", "d", false, "To debug logging")
+	cmd.PersistentFlags().StringVarP(&f.LogFormat, "log-format", "", log.FormatConsole, "Log output format: console, json")
+	cmd.PersistentFlags().StringVarP(&f.LogFile, "log-file", "", "", "Write logs to this file instead of stderr")
+	cmd.PersistentFlags().StringVarP(&f.LogLevel, "log-level", "", "", "Minimum level logged: debug, info, warn, error (default info, or debug with --debug)")
+	cmd.PersistentFlags().BoolVarP(&f.NoCache, "no-cache", "", false, "Do not read or write the local response cache")
+	cmd.PersistentFlags().StringVarP(&f.CacheDir, "cache-dir", "", cache.DefaultDir(), "Directory to cache API responses in")
+	cmd.PersistentFlags().DurationVarP(&f.CacheTTL, "cache-ttl", "", cache.DefaultTTL, "How long cached GraphQL responses are reused")
+	cmd.PersistentFlags().BoolVarP(&f.Offline, "offline", "", false, "Answer reads from the response cache when GitHub cannot be reached")
+	cmd.PersistentFlags().StringVarP(&f.JournalDir, "journal-dir", "", journal.DefaultDir(), "Directory to write undo journals for commands that change access to")
+	cmd.PersistentFlags().StringVarP(&f.AuditLog, "audit-log", "", audit.DefaultPath(), "File to append a hash chained audit trail of access changes to")
+	cmd.PersistentFlags().IntVarP(&f.Concurrency, "concurrency", "", utils.DefaultConcurrency, "Number of rows or users processed at once")
+	cmd.PersistentFlags().IntVarP(&f.RateLimit, "rate-limit", "", utils.DefaultMutationsPerMinute, "Maximum add or remove requests per minute, 0 disables the limit")
+	cmd.PersistentFlags().DurationVarP(&f.Timeout, "timeout", "", 0, "Maximum time for each API request, 0 for no limit")
+	cmd.PersistentFlags().StringVarP(&f.ProfileName, "profile", "", "", "Configuration profile to use (default the config file's default_profile)")
+}
+
+// Init applies the selected profile and environment defaults for values not
+// set on the command line, then reinitializes logging when debugging or any
+// log option was set. Flags take precedence over the profile, which takes precedence
+// over the environment.
+func (f *Factory) Init(cmd *cobra.Command) error {
+	if err := f.LoadProfile(); err != nil {
+		return err
+	}
+	if err := f.applyProfile(cmd); err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("hostname") && (f.Profile == nil || f.Profile.Hostname == "") {
+		if host := os.Getenv("GH_HOST"); host != "" {
+			f.Hostname = host
+		}
+	}
+	if f.Token == "" && !f.Options("").UseApp() && !isGitHubHost(f.Hostname) {
+		f.Token = os.Getenv("GH_ENTERPRISE_TOKEN")
+	}
+
+	flags := cmd.Flags()
+	if f.Debug || flags.Changed("log-format") || flags.Changed("log-file") || flags.Changed("log-level") {
+		logger, err := log.NewLoggerWithOptions(log.Options{
+			Debug:  f.Debug,
+			Format: f.LogFormat,
+			Level:  f.LogLevel,
+			File:   f.LogFile,
+		})
+		if err != nil {
+			return err
+		}
+		zap.ReplaceGlobals(logger)
+	}
+	return nil
+}
+
+// LoadProfile reads the configuration file and selects the profile, it is
+// safe to call more than once.
+func (f *Factory) LoadProfile() error {
+	if f.Profile != nil {
+		return nil
+	}
+	cfg, err := config.Load(f.ConfigPath)
+	if err != nil {
+		return err
+	}
+	profile, err := cfg.Profile(f.ProfileName)
+	if err != nil {
+		return err
+	}
+	f.Profile = profile
+	return nil
+}
+
+func (f *Factory) applyProfile(cmd *cobra.Command) error {
+	if f.Profile == nil {
+		return nil
+	}
+	p := f.Profile
+	flags := cmd.Flags()
+
+	if !flags.Changed("hostname") && p.Hostname != "" {
+		f.Hostname = p.Hostname
+	}
+	if !flags.Changed("concurrency") && p.Concurrency > 0 {
+		f.Concurrency = p.Concurrency
+	}
+
+	// App credentials given on the command line replace the profile's
+	// authentication entirely
+	if flags.Changed("token") || flags.Changed("app-id") || flags.Changed("private-key-path") || flags.Changed("installation-id") {
+		return nil
+	}
+	if p.AppID != 0 {
+		f.AppID = p.AppID
+		f.PrivateKeyPath = p.PrivateKeyPath
+		f.InstallationID = p.InstallationID
+		return nil
+	}
+	token, err := p.ResolveToken()
+	if err != nil {
+		return err
+	}
+	f.Token = token
+	return nil
+}
+
+// OrgArgs validates that an organization was given as the first argument or
+// is set by the selected profile.
+func (f *Factory) OrgArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return nil
+	}
+	if err := f.LoadProfile(); err != nil {
+		return err
+	}
+	if f.Profile != nil && f.Profile.Org != "" {
+		return nil
+	}
+	return fmt.Errorf("requires an organization argument or a profile with an org")
+}
+
+// Owner returns the organization from the arguments, falling back to the
+// selected profile.
+func (f *Factory) Owner(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	if f.Profile != nil {
+		return f.Profile.Org
+	}
+	return ""
+}
+
+func (f *Factory) Options(owner string) Options {
+	opts := Options{
+		Token:          f.Token,
+		Hostname:       f.Hostname,
+		AppID:          f.AppID,
+		PrivateKeyPath: f.PrivateKeyPath,
+		InstallationID: f.InstallationID,
+		Owner:          owner,
+		CacheTTL:       f.CacheTTL,
+		Offline:        f.Offline,
+		Timeout:        f.Timeout,
+		Transport:      f.Transport,
+	}
+	if !f.NoCache {
+		opts.CacheDir = f.CacheDir
+	}
+	return opts
+}
+
+// Executor returns the executor add, remove and rollback run their rows on.
+// Requests per hour are capped alongside the per minute limit.
+func (f *Factory) Executor() *utils.Executor {
+	return utils.NewExecutor(utils.ExecutorOptions{
+		Concurrency: f.Concurrency,
+		PerMinute:   f.RateLimit,
+		PerHour:     utils.DefaultMutationsPerHour,
+		MaxAttempts: utils.DefaultMaxAttempts,
+	})
+}
+
+// ConfirmChanges shows the pre-flight summary of a destructive or bulk
+// change and asks before going ahead. yes skips the prompt, without it the
+// change is refused when nobody is there to answer.
+func (f *Factory) ConfirmChanges(out io.Writer, summary string, yes bool) error {
+	_, _ = fmt.Fprintf(out, "%s.\n", summary)
+	if yes {
+		return nil
+	}
+	if !f.IsInteractive() {
+		return fmt.Errorf("refusing to make changes without confirmation when not running interactively, rerun with --yes")
+	}
+	confirmed, err := f.Confirm("Continue?")
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if !confirmed {
+		return ErrAborted
+	}
+	return nil
+}
+
+// OpenJournal starts the undo journal for a run. Every change the journal
+// records is also appended to the audit log, when one is set. Without a
+// journal directory the run is not journaled but is still audited, and with
+// neither a nil journal that records nothing is returned.
+func (f *Factory) OpenJournal(ctx context.Context, command string, owner string) (*journal.Journal, error) {
+	var j *journal.Journal
+	switch {
+	case f.JournalDir != "":
+		var err error
+		if j, err = journal.Open(f.JournalDir, command, owner); err != nil {
+			return nil, err
+		}
+	case f.AuditLog != "":
+		j = journal.Discard(command, owner)
+	default:
+		return nil, nil
+	}
+	if f.AuditLog == "" {
+		return j, nil
+	}
+	if err := f.auditJournal(ctx, j, owner); err != nil {
+		_ = j.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// auditJournal appends the changes recorded by the journal to the audit
+// log, attributed to the user the token belongs to.
+func (f *Factory) auditJournal(ctx context.Context, j *journal.Journal, owner string) error {
+	auditLog, err := audit.Open(f.AuditLog)
+	if err != nil {
+		return err
+	}
+	actor, err := f.auditActor(ctx, owner)
+	if err != nil {
+		return err
+	}
+	j.OnRecord(func(entry journal.Entry) error {
+		switch entry.Status {
+		case utils.StatusAdded, utils.StatusUpdated, utils.StatusRemoved:
+		default:
+			return nil
+		}
+		return auditLog.Append(audit.Entry{
+			Actor:         actor,
+			Host:          f.Hostname,
+			Org:           entry.Owner,
+			Repository:    entry.RepositoryName,
+			Team:          entry.Team,
+			Username:      entry.Username,
+			OldPermission: utils.NormalizePermission(entry.PreviousPermission),
+			NewPermission: utils.NormalizePermission(entry.Permission),
+			RequestID:     entry.RequestID,
+			Command:       entry.Command,
+			RunID:         entry.RunID,
+		})
+	})
+	return nil
+}
+
+// auditActor names who changes are made as: the token's user, or the
+// GitHub App, whose installation tokens cannot read the authenticated user.
+func (f *Factory) auditActor(ctx context.Context, owner string) (string, error) {
+	if f.Options(owner).UseApp() {
+		return fmt.Sprintf("app/%d", f.AppID), nil
+	}
+	g, err := f.NewGetter(owner)
+	if err != nil {
+		return "", err
+	}
+	user, err := g.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the authenticated user for the audit log: %w", err)
+	}
+	return user.Login, nil
+}
+
+// Notifier builds the notifications configured for the profile, sending
+// issues and comments through g. It returns a nil Sender, which sends
+// nothing, when the profile configures none.
+func (f *Factory) Notifier(g utils.Getter) (*notify.Sender, error) {
+	if err := f.LoadProfile(); err != nil {
+		return nil, err
+	}
+	if f.Profile == nil {
+		return nil, nil
+	}
+	sender, err := notify.New(f.Profile.Notify, f.Hostname, g, f.Transport)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notify settings in profile: %w", err)
+	}
+	return sender, nil
+}
+
+func isGitHubHost(hostname string) bool {
+	return hostname == "" || hostname == defaultHostname
+}
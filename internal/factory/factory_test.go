@@ -0,0 +1,448 @@
+package factory
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/cache"
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func TestOptionsUseApp(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected bool
+	}{
+		{name: "token only", opts: Options{Token: "abc"}, expected: false},
+		{name: "empty", opts: Options{}, expected: false},
+		{name: "app id", opts: Options{AppID: 1}, expected: true},
+		{name: "private key", opts: Options{PrivateKeyPath: "key.pem"}, expected: true},
+		{name: "installation id", opts: Options{InstallationID: 2}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.UseApp(); got != tt.expected {
+				t.Errorf("Expected UseApp() to be %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewClientOptionsWithToken(t *testing.T) {
+	opts, err := NewClientOptions(Options{Token: "test-token", Hostname: "github.example.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if opts.AuthToken != "test-token" {
+		t.Errorf("Expected auth token 'test-token', got %s", opts.AuthToken)
+	}
+	if opts.Host != "github.example.com" {
+		t.Errorf("Expected host 'github.example.com', got %s", opts.Host)
+	}
+	if opts.Transport != nil {
+		t.Error("Expected no custom transport for token authentication")
+	}
+}
+
+func TestNewClientOptionsRejectsTokenWithApp(t *testing.T) {
+	_, err := NewClientOptions(Options{Token: "test-token", AppID: 1, PrivateKeyPath: "key.pem"})
+	if err == nil {
+		t.Error("Expected error combining token and app authentication, got nil")
+	}
+}
+
+func TestNewClientOptionsAppMissingKey(t *testing.T) {
+	_, err := NewClientOptions(Options{AppID: 1, Owner: "test-org"})
+	if err == nil {
+		t.Error("Expected error for app authentication without a private key, got nil")
+	}
+}
+
+func TestNewClientOptionsAppUsesTransport(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The installation token is requested through the configured transport,
+	// such as a proxy or the test replay server
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "POST", Path: "/app/installations/42/access_tokens"},
+		Response: replay.Response{Status: 201, Body: []byte(`{"token": "ghs_replayed", "expires_at": "2099-01-01T00:00:00Z"}`)},
+	})
+	opts, err := NewClientOptions(Options{AppID: 1, PrivateKeyPath: keyPath, InstallationID: 42, Hostname: "github.com", Transport: server.Transport()})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+	if opts.AuthToken != "ghs_replayed" {
+		t.Errorf("Expected the installation token, got %q", opts.AuthToken)
+	}
+}
+
+func TestNewAPIGetterWithToken(t *testing.T) {
+	getter, err := NewAPIGetter(Options{Token: "test-token", Hostname: "github.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if getter == nil {
+		t.Error("Expected getter, got nil")
+	}
+}
+
+func newTestCommand(f *Factory) *cobra.Command {
+	cmd := &cobra.Command{Use: "test", RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+	f.AddFlags(cmd)
+	return cmd
+}
+
+func TestFactoryInitUsesGHHost(t *testing.T) {
+	t.Setenv("GH_HOST", "github.example.com")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+
+	f := New()
+	f.ConfigPath = ""
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if f.Hostname != "github.example.com" {
+		t.Errorf("Expected hostname from GH_HOST, got %s", f.Hostname)
+	}
+	if f.Token != "enterprise-token" {
+		t.Errorf("Expected token from GH_ENTERPRISE_TOKEN, got %s", f.Token)
+	}
+}
+
+func TestFactoryInitFlagsOverrideEnv(t *testing.T) {
+	t.Setenv("GH_HOST", "github.example.com")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+
+	f := New()
+	f.ConfigPath = ""
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{"--hostname", "github.com", "--token", "flag-token", "--concurrency", "2"}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if f.Hostname != "github.com" {
+		t.Errorf("Expected hostname from flag, got %s", f.Hostname)
+	}
+	if f.Token != "flag-token" {
+		t.Errorf("Expected token from flag, got %s", f.Token)
+	}
+	if f.Concurrency != 2 {
+		t.Errorf("Expected concurrency from flag, got %d", f.Concurrency)
+	}
+}
+
+func TestFactoryInitLogFile(t *testing.T) {
+	defer zap.ReplaceGlobals(zap.NewNop())
+	logFile := filepath.Join(t.TempDir(), "gh-collaborators.log")
+
+	f := New()
+	f.ConfigPath = ""
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{"--log-format", "json", "--log-file", logFile}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	zap.L().Info("logged to file", zap.String("org", "test-org"))
+	_ = zap.L().Sync()
+
+	written, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(written), `"msg":"logged to file"`) || !strings.Contains(string(written), `"org":"test-org"`) {
+		t.Errorf("Expected a JSON log line in the log file, got %q", written)
+	}
+}
+
+func TestFactoryInitInvalidLogFormat(t *testing.T) {
+	f := New()
+	f.ConfigPath = ""
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{"--log-format", "xml"}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err == nil || !strings.Contains(err.Error(), "--log-format") {
+		t.Errorf("Expected an invalid log format error, got %v", err)
+	}
+}
+
+func TestFactoryInitIgnoresEnterpriseTokenForGitHub(t *testing.T) {
+	t.Setenv("GH_HOST", "")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+
+	f := New()
+	f.ConfigPath = ""
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if f.Token != "" {
+		t.Errorf("Expected no token for github.com, got %s", f.Token)
+	}
+}
+
+func TestFactoryOptions(t *testing.T) {
+	f := &Factory{Token: "abc", Hostname: "github.com", AppID: 1, PrivateKeyPath: "key.pem", InstallationID: 2}
+	opts := f.Options("test-org")
+
+	if opts.Owner != "test-org" || opts.Token != "abc" || opts.AppID != 1 || opts.PrivateKeyPath != "key.pem" || opts.InstallationID != 2 {
+		t.Errorf("Expected options to mirror factory values, got %+v", opts)
+	}
+}
+
+func writeProfileConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `default_profile: ghes
+profiles:
+  ghes:
+    hostname: github.example.com
+    org: ghes-org
+    token_source: env:TEST_PROFILE_TOKEN
+    concurrency: 8
+  app:
+    org: app-org
+    app_id: 99
+    private_key_path: /keys/app.pem
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestFactoryInitAppliesDefaultProfile(t *testing.T) {
+	t.Setenv("GH_HOST", "env.example.com")
+	t.Setenv("TEST_PROFILE_TOKEN", "profile-token")
+
+	f := New()
+	f.ConfigPath = writeProfileConfig(t)
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if f.Hostname != "github.example.com" {
+		t.Errorf("Expected hostname from profile, got %s", f.Hostname)
+	}
+	if f.Token != "profile-token" {
+		t.Errorf("Expected token from profile, got %s", f.Token)
+	}
+	if f.Concurrency != 8 {
+		t.Errorf("Expected concurrency from profile, got %d", f.Concurrency)
+	}
+	if f.Owner(nil) != "ghes-org" {
+		t.Errorf("Expected owner from profile, got %s", f.Owner(nil))
+	}
+	if f.Owner([]string{"arg-org"}) != "arg-org" {
+		t.Errorf("Expected owner from arguments, got %s", f.Owner([]string{"arg-org"}))
+	}
+}
+
+func TestFactoryInitFlagsOverrideProfile(t *testing.T) {
+	t.Setenv("TEST_PROFILE_TOKEN", "profile-token")
+
+	f := New()
+	f.ConfigPath = writeProfileConfig(t)
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{"--hostname", "github.com", "--token", "flag-token", "--concurrency", "2"}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if f.Hostname != "github.com" {
+		t.Errorf("Expected hostname from flag, got %s", f.Hostname)
+	}
+	if f.Token != "flag-token" {
+		t.Errorf("Expected token from flag, got %s", f.Token)
+	}
+	if f.Concurrency != 2 {
+		t.Errorf("Expected concurrency from flag, got %d", f.Concurrency)
+	}
+}
+
+func TestFactoryInitSelectsNamedProfile(t *testing.T) {
+	f := New()
+	f.ConfigPath = writeProfileConfig(t)
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{"--profile", "app"}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if f.AppID != 99 || f.PrivateKeyPath != "/keys/app.pem" {
+		t.Errorf("Expected app settings from profile, got app id %d and key %s", f.AppID, f.PrivateKeyPath)
+	}
+	if f.Owner(nil) != "app-org" {
+		t.Errorf("Expected owner 'app-org', got %s", f.Owner(nil))
+	}
+}
+
+func TestFactoryInitUnknownProfile(t *testing.T) {
+	f := New()
+	f.ConfigPath = writeProfileConfig(t)
+	cmd := newTestCommand(f)
+	if err := cmd.ParseFlags([]string{"--profile", "missing"}); err != nil {
+		t.Fatalf("Expected no error parsing flags, got %v", err)
+	}
+	if err := f.Init(cmd); err == nil {
+		t.Error("Expected error for unknown profile, got nil")
+	}
+}
+
+func TestFactoryOrgArgs(t *testing.T) {
+	f := New()
+	f.ConfigPath = filepath.Join(t.TempDir(), "missing.yaml")
+	cmd := newTestCommand(f)
+
+	if err := f.OrgArgs(cmd, []string{}); err == nil {
+		t.Error("Expected error with no arguments and no profile, got nil")
+	}
+	if err := f.OrgArgs(cmd, []string{"test-org"}); err != nil {
+		t.Errorf("Expected no error with an organization argument, got %v", err)
+	}
+
+	f = New()
+	f.ConfigPath = writeProfileConfig(t)
+	if err := f.OrgArgs(cmd, []string{}); err != nil {
+		t.Errorf("Expected profile org to satisfy arguments, got %v", err)
+	}
+}
+
+func TestFactoryOptionsCache(t *testing.T) {
+	f := &Factory{CacheDir: "/tmp/cache", CacheTTL: time.Minute}
+	if opts := f.Options("test-org"); opts.CacheDir != "/tmp/cache" || opts.CacheTTL != time.Minute {
+		t.Errorf("Expected cache options to be passed through, got %+v", opts)
+	}
+
+	f.NoCache = true
+	if opts := f.Options("test-org"); opts.CacheDir != "" {
+		t.Errorf("Expected --no-cache to disable the cache, got %s", opts.CacheDir)
+	}
+}
+
+func TestNewClientOptionsWithTimeout(t *testing.T) {
+	f := &Factory{Token: "test-token", Hostname: "github.com", Timeout: 30 * time.Second}
+	opts, err := NewClientOptions(f.Options("test-org"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if opts.Timeout != 30*time.Second {
+		t.Errorf("Expected request timeout of 30s, got %v", opts.Timeout)
+	}
+}
+
+func TestNewClientOptionsWithCache(t *testing.T) {
+	opts, err := NewClientOptions(Options{Token: "test-token", Hostname: "github.com", CacheDir: t.TempDir(), Offline: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	transport, ok := opts.Transport.(*cache.Transport)
+	if !ok {
+		t.Fatalf("Expected cache transport, got %T", opts.Transport)
+	}
+	if transport.Credential != "token:test-token" || !transport.Offline {
+		t.Errorf("Expected entries kept per token and served offline, got %+v", transport)
+	}
+
+	app := Options{AppID: 1, InstallationID: 2, Owner: "Test-Org"}
+	if app.credential("rotating-token") != "app:1:2:test-org" {
+		t.Errorf("Expected the app identity, got %s", app.credential("rotating-token"))
+	}
+}
+
+func TestConfirmChanges(t *testing.T) {
+	f := New()
+	asked := 0
+	f.Confirm = func(question string) (bool, error) {
+		asked++
+		return false, nil
+	}
+
+	var out bytes.Buffer
+	f.IsInteractive = func() bool { return false }
+	if err := f.ConfirmChanges(&out, "About to remove 1 grant for 1 user across 1 repo", true); err != nil {
+		t.Errorf("Expected --yes to skip the prompt, got %v", err)
+	}
+	if out.String() != "About to remove 1 grant for 1 user across 1 repo.\n" {
+		t.Errorf("Expected the summary to be shown, got %q", out.String())
+	}
+	if err := f.ConfirmChanges(&out, "About to remove", false); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("Expected a refusal without a terminal, got %v", err)
+	}
+
+	f.IsInteractive = func() bool { return true }
+	if err := f.ConfirmChanges(&out, "About to remove", false); !errors.Is(err, ErrAborted) {
+		t.Errorf("Expected ErrAborted when declined, got %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("Expected one prompt, got %d", asked)
+	}
+}
+
+func TestFactoryNotifier(t *testing.T) {
+	f := New()
+	f.ConfigPath = ""
+	sender, err := f.Notifier(nil)
+	if err != nil || sender != nil {
+		t.Errorf("Expected no notifications without a profile, got %+v, %v", sender, err)
+	}
+
+	f.Hostname = "github.example.com"
+	f.Profile = &config.Profile{Notify: config.Notify{GitHub: &config.GitHubNotify{Issues: true}}}
+	sender, err = f.Notifier(nil)
+	if err != nil || sender == nil || sender.Host != "github.example.com" || len(sender.Notifiers) != 1 {
+		t.Errorf("Expected a github notifier for the host, got %+v, %v", sender, err)
+	}
+
+	f.Profile = &config.Profile{Notify: config.Notify{Email: &config.EmailNotify{Host: "smtp.example.com"}}}
+	if _, err := f.Notifier(nil); err == nil || !strings.HasPrefix(err.Error(), "invalid notify settings in profile: notify.email.from is required") {
+		t.Errorf("Expected an invalid settings error, got %v", err)
+	}
+}
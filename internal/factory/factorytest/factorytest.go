@@ -0,0 +1,20 @@
+// Package factorytest builds factories for command tests.
+package factorytest
+
+import (
+	"github.com/katiem0/gh-collaborators/internal/factory"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+)
+
+// New returns a factory whose clients send every request to the replay
+// server, with the user's configuration, cache, journal and audit log left
+// out.
+func New(server *replay.Server) *factory.Factory {
+	f := factory.New()
+	f.Hostname = "github.com"
+	f.Token = "test-token"
+	f.ConfigPath = ""
+	f.NoCache = true
+	f.Transport = server.Transport()
+	return f
+}
@@ -0,0 +1,254 @@
+package ghapp
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Installation tokens are valid for one hour, refresh them a little early so
+// that requests in flight never carry an expired token.
+const refreshWindow = 5 * time.Minute
+
+type Config struct {
+	AppID          int64
+	PrivateKeyPath string
+	InstallationID int64
+	Hostname       string
+	Org            string
+	BaseURL        string
+	Transport      http.RoundTripper
+}
+
+type TokenSource struct {
+	appID          int64
+	installationID int64
+	org            string
+	baseURL        string
+	key            *rsa.PrivateKey
+	client         *http.Client
+	now            func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+type installationResponse struct {
+	Id int64 `json:"id"`
+}
+
+type accessTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewTokenSource(cfg Config) (*TokenSource, error) {
+	if cfg.AppID == 0 {
+		return nil, fmt.Errorf("a GitHub App ID is required for app authentication")
+	}
+	if cfg.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("a private key path is required for app authentication")
+	}
+	if cfg.InstallationID == 0 && cfg.Org == "" {
+		return nil, fmt.Errorf("an installation ID or organization is required for app authentication")
+	}
+
+	pemData, err := os.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	key, err := ParsePrivateKey(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = APIBaseURL(cfg.Hostname)
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &TokenSource{
+		appID:          cfg.AppID,
+		installationID: cfg.InstallationID,
+		org:            cfg.Org,
+		baseURL:        strings.TrimSuffix(baseURL, "/") + "/",
+		key:            key,
+		client:         &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		now:            time.Now,
+	}, nil
+}
+
+// APIBaseURL mirrors how go-gh resolves the REST endpoint for a host.
+func APIBaseURL(hostname string) string {
+	if hostname == "" || hostname == "github.com" {
+		return "https://api.github.com/"
+	}
+	if strings.HasSuffix(hostname, ".ghe.com") {
+		return fmt.Sprintf("https://api.%s/", hostname)
+	}
+	return fmt.Sprintf("https://%s/api/v3/", hostname)
+}
+
+func ParsePrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Token returns a valid installation token, minting a new one when the
+// current token is missing or about to expire.
+func (s *TokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(refreshWindow).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	if s.installationID == 0 {
+		id, err := s.discoverInstallation()
+		if err != nil {
+			return "", err
+		}
+		s.installationID = id
+	}
+
+	zap.S().Debugf("Requesting installation token for installation %d", s.installationID)
+	var tokenResp accessTokenResponse
+	url := fmt.Sprintf("app/installations/%d/access_tokens", s.installationID)
+	if err := s.appRequest("POST", url, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+	if tokenResp.Token == "" {
+		return "", fmt.Errorf("installation token response did not contain a token")
+	}
+
+	s.token = tokenResp.Token
+	s.expiresAt = tokenResp.ExpiresAt
+	return s.token, nil
+}
+
+func (s *TokenSource) discoverInstallation() (int64, error) {
+	zap.S().Debugf("Discovering GitHub App installation for %s", s.org)
+	var install installationResponse
+	if err := s.appRequest("GET", fmt.Sprintf("orgs/%s/installation", s.org), &install); err != nil {
+		return 0, fmt.Errorf("failed to find GitHub App installation for organization %s: %w", s.org, err)
+	}
+	return install.Id, nil
+}
+
+func (s *TokenSource) appRequest(method string, path string, out interface{}) error {
+	jwt, err := s.JWT()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, s.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := resp.Body.Close()
+		if closeErr != nil {
+			zap.S().Warnf("Error closing response body: %v", closeErr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// JWT mints the short lived RS256 token used to authenticate as the app.
+func (s *TokenSource) JWT() (string, error) {
+	now := s.now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	claims := map[string]interface{}{
+		// Backdate issue time to allow for clock drift with the server
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Transport injects a fresh installation token into every request so that
+// long running commands keep working after the first token expires.
+type Transport struct {
+	Source *TokenSource
+	Base   http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token()
+	if err != nil {
+		return nil, err
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	authReq := req.Clone(req.Context())
+	authReq.Header.Set("Authorization", "token "+token)
+	return base.RoundTrip(authReq)
+}
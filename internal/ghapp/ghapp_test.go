@@ -0,0 +1,252 @@
+package ghapp
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeTestKey(t *testing.T, pkcs8 bool) (string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if pkcs8 {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal key: %v", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	path := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return path, key
+}
+
+func newTestServer(t *testing.T, tokensIssued *int32, expiresIn time.Duration) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/orgs/test-org/installation":
+			_, _ = fmt.Fprint(w, `{"id": 42}`)
+		case r.Method == "POST" && r.URL.Path == "/app/installations/42/access_tokens":
+			n := atomic.AddInt32(tokensIssued, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"token":      fmt.Sprintf("ghs_token%d", n),
+				"expires_at": time.Now().Add(expiresIn).UTC().Format(time.RFC3339),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestAPIBaseURL(t *testing.T) {
+	tests := map[string]string{
+		"":                   "https://api.github.com/",
+		"github.com":         "https://api.github.com/",
+		"github.example.com": "https://github.example.com/api/v3/",
+		"tenant.ghe.com":     "https://api.tenant.ghe.com/",
+	}
+
+	for host, expected := range tests {
+		if got := APIBaseURL(host); got != expected {
+			t.Errorf("Expected base URL %s for host %q, got %s", expected, host, got)
+		}
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	for _, pkcs8 := range []bool{false, true} {
+		path, key := writeTestKey(t, pkcs8)
+		pemData, _ := os.ReadFile(path)
+
+		parsed, err := ParsePrivateKey(pemData)
+		if err != nil {
+			t.Fatalf("Expected no error parsing key (pkcs8=%v), got %v", pkcs8, err)
+		}
+		if !parsed.Equal(key) {
+			t.Errorf("Parsed key does not match generated key (pkcs8=%v)", pkcs8)
+		}
+	}
+
+	if _, err := ParsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("Expected error for non-PEM data, got nil")
+	}
+}
+
+func TestNewTokenSourceValidation(t *testing.T) {
+	path, _ := writeTestKey(t, false)
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "missing app id", cfg: Config{PrivateKeyPath: path, Org: "test-org"}},
+		{name: "missing key", cfg: Config{AppID: 1, Org: "test-org"}},
+		{name: "missing installation and org", cfg: Config{AppID: 1, PrivateKeyPath: path}},
+		{name: "unreadable key", cfg: Config{AppID: 1, PrivateKeyPath: "/nonexistent/key.pem", Org: "test-org"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTokenSource(tt.cfg); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestJWTSignature(t *testing.T) {
+	path, key := writeTestKey(t, false)
+	source, err := NewTokenSource(Config{AppID: 1234, PrivateKeyPath: path, InstallationID: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	jwt, err := source.JWT()
+	if err != nil {
+		t.Fatalf("Expected no error minting JWT, got %v", err)
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 JWT segments, got %d", len(parts))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("Failed to decode signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("JWT signature did not verify: %v", err)
+	}
+
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		t.Fatalf("Failed to decode claims: %v", err)
+	}
+	if claims["iss"] != "1234" {
+		t.Errorf("Expected iss '1234', got %v", claims["iss"])
+	}
+	if claims["exp"].(float64)-claims["iat"].(float64) > 600 {
+		t.Error("Expected JWT lifetime to be at most 10 minutes")
+	}
+}
+
+func TestTokenDiscoversInstallationAndCaches(t *testing.T) {
+	var issued int32
+	server := newTestServer(t, &issued, time.Hour)
+	defer server.Close()
+
+	path, _ := writeTestKey(t, false)
+	source, err := NewTokenSource(Config{AppID: 1, PrivateKeyPath: path, Org: "test-org", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := source.Token()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if token != "ghs_token1" {
+			t.Errorf("Expected cached token 'ghs_token1', got %s", token)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("Expected 1 token to be issued, got %d", issued)
+	}
+}
+
+func TestTokenRefreshesBeforeExpiry(t *testing.T) {
+	var issued int32
+	// Tokens that expire inside the refresh window are refreshed on every call
+	server := newTestServer(t, &issued, time.Minute)
+	defer server.Close()
+
+	path, _ := writeTestKey(t, false)
+	source, err := NewTokenSource(Config{AppID: 1, PrivateKeyPath: path, InstallationID: 42, BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	first, _ := source.Token()
+	second, _ := source.Token()
+	if first == second {
+		t.Errorf("Expected token to be refreshed, got %s twice", first)
+	}
+}
+
+func TestTokenDiscoveryFailure(t *testing.T) {
+	var issued int32
+	server := newTestServer(t, &issued, time.Hour)
+	defer server.Close()
+
+	path, _ := writeTestKey(t, false)
+	source, err := NewTokenSource(Config{AppID: 1, PrivateKeyPath: path, Org: "other-org", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := source.Token(); err == nil {
+		t.Error("Expected error for organization without installation, got nil")
+	}
+}
+
+func TestTransportSetsInstallationToken(t *testing.T) {
+	var issued int32
+	appServer := newTestServer(t, &issued, time.Hour)
+	defer appServer.Close()
+
+	var gotAuth string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer apiServer.Close()
+
+	path, _ := writeTestKey(t, false)
+	source, err := NewTokenSource(Config{AppID: 1, PrivateKeyPath: path, InstallationID: 42, BaseURL: appServer.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	client := &http.Client{Transport: &Transport{Source: source}}
+	req, _ := http.NewRequest("GET", apiServer.URL, nil)
+	req.Header.Set("Authorization", "token stale")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if gotAuth != "token ghs_token1" {
+		t.Errorf("Expected installation token header, got %s", gotAuth)
+	}
+}
@@ -0,0 +1,179 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+const appName = "gh-collaborators"
+
+// Entry is one journaled mutation, the embedded result carries the
+// permission read before the change so it can be restored.
+type Entry struct {
+	RunID   string    `json:"run_id"`
+	Command string    `json:"command"`
+	Owner   string    `json:"owner"`
+	Time    time.Time `json:"time"`
+	data.MutationResult
+}
+
+// Journal appends entries for one run to <dir>/<run-id>.jsonl, one JSON
+// object per line so a run that is interrupted still leaves a usable file.
+// A journal from Discard keeps no file and only calls its hooks.
+type Journal struct {
+	RunID   string
+	Path    string
+	command string
+	owner   string
+
+	mu    sync.Mutex
+	file  *os.File
+	now   func() time.Time
+	hooks []func(Entry) error
+}
+
+var runIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// DefaultDir returns ~/.local/state/gh-collaborators/journal, honoring
+// XDG_STATE_HOME when it is set.
+func DefaultDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, appName, "journal")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", appName, "journal")
+}
+
+// NewRunID returns a sortable, unique identifier for a run.
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// Open creates the journal file for a new run of command against owner.
+func Open(dir string, command string, owner string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory %s: %w", dir, err)
+	}
+	runID := NewRunID()
+	path := filepath.Join(dir, runID+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal %s: %w", path, err)
+	}
+	return &Journal{RunID: runID, Path: path, command: command, owner: owner, file: file, now: time.Now}, nil
+}
+
+// Discard returns a journal for a new run that writes no file, so the run
+// cannot be rolled back, but still has a run ID and calls its hooks.
+func Discard(command string, owner string) *Journal {
+	return &Journal{RunID: NewRunID(), command: command, owner: owner, now: time.Now}
+}
+
+// Undoable reports whether the journal keeps a file the run can be rolled
+// back with.
+func (j *Journal) Undoable() bool {
+	return j != nil && j.file != nil
+}
+
+// Record appends a mutation result to the journal, the file is synced after
+// every entry so it survives the process being killed. Recording to a nil
+// journal does nothing.
+func (j *Journal) Record(result data.MutationResult) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := Entry{
+		RunID:          j.RunID,
+		Command:        j.command,
+		Owner:          j.owner,
+		Time:           j.now().UTC(),
+		MutationResult: result,
+	}
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if j.file != nil {
+		if _, err := j.file.Write(append(content, '\n')); err != nil {
+			return fmt.Errorf("failed to write journal %s: %w", j.Path, err)
+		}
+		if err := j.file.Sync(); err != nil {
+			return err
+		}
+	}
+	for _, hook := range j.hooks {
+		if err := hook(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnRecord registers a function called with every entry once it is written,
+// in the order entries are recorded. An error from it fails the record.
+func (j *Journal) OnRecord(hook func(Entry) error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.hooks = append(j.hooks, hook)
+}
+
+func (j *Journal) Close() error {
+	if j == nil || j.file == nil {
+		return nil
+	}
+	return j.file.Close()
+}
+
+// Load reads the entries journaled for a run.
+func Load(dir string, runID string) ([]Entry, error) {
+	if !runIDPattern.MatchString(runID) {
+		return nil, fmt.Errorf("invalid run ID %q", runID)
+	}
+	path := filepath.Join(dir, runID+".jsonl")
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no journal found for run %s in %s", runID, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
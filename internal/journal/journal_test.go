@@ -0,0 +1,156 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+
+	expected := filepath.Join("/tmp/state", "gh-collaborators", "journal")
+	if got := DefaultDir(); got != expected {
+		t.Errorf("Expected default dir %s, got %s", expected, got)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	home, _ := os.UserHomeDir()
+	expected = filepath.Join(home, ".local", "state", "gh-collaborators", "journal")
+	if got := DefaultDir(); got != expected {
+		t.Errorf("Expected default dir %s, got %s", expected, got)
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	first, second := NewRunID(), NewRunID()
+	if first == second {
+		t.Errorf("Expected unique run IDs, got %s twice", first)
+	}
+	if !runIDPattern.MatchString(first) {
+		t.Errorf("Expected run ID %s to be a valid file name", first)
+	}
+}
+
+func TestRecordAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "journal")
+	j, err := Open(dir, "add", "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	results := []data.MutationResult{
+		{RepositoryName: "repo1", Username: "user1", Permission: "push", PreviousPermission: "none", Status: "added"},
+		{RepositoryName: "repo2", Username: "user1", Permission: "pull", PreviousPermission: "admin", Status: "blocked", Reason: "would downgrade admin to read"},
+	}
+	for _, result := range results {
+		if err := j.Record(result); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Expected no error closing journal, got %v", err)
+	}
+
+	info, err := os.Stat(j.Path)
+	if err != nil {
+		t.Fatalf("Expected journal file, got %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected journal to be private, got %v", info.Mode().Perm())
+	}
+
+	entries, err := Load(dir, j.RunID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.RunID != j.RunID || entry.Command != "add" || entry.Owner != "test-org" || entry.Time.IsZero() {
+			t.Errorf("Expected run metadata on entry %d, got %+v", i, entry)
+		}
+		if entry.MutationResult != results[i] {
+			t.Errorf("Expected result %+v, got %+v", results[i], entry.MutationResult)
+		}
+	}
+}
+
+func TestRecordHooks(t *testing.T) {
+	j, err := Open(t.TempDir(), "remove", "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = j.Close() }()
+
+	var recorded []Entry
+	j.OnRecord(func(entry Entry) error {
+		recorded = append(recorded, entry)
+		return nil
+	})
+	if err := j.Record(data.MutationResult{RepositoryName: "repo1", Username: "user1", Status: "removed"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(recorded) != 1 || recorded[0].RunID != j.RunID || recorded[0].Command != "remove" || recorded[0].RepositoryName != "repo1" {
+		t.Errorf("Expected the hook to receive the journaled entry, got %+v", recorded)
+	}
+
+	j.OnRecord(func(entry Entry) error { return errors.New("audit log unavailable") })
+	if err := j.Record(data.MutationResult{RepositoryName: "repo2", Username: "user1"}); err == nil || err.Error() != "audit log unavailable" {
+		t.Errorf("Expected the hook's error, got %v", err)
+	}
+}
+
+func TestDiscardJournal(t *testing.T) {
+	j := Discard("remove", "test-org")
+	var recorded []Entry
+	j.OnRecord(func(entry Entry) error {
+		recorded = append(recorded, entry)
+		return nil
+	})
+	if err := j.Record(data.MutationResult{RepositoryName: "repo1", Username: "user1", Status: "removed"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(recorded) != 1 || recorded[0].RunID != j.RunID || j.RunID == "" {
+		t.Errorf("Expected the hook to receive the entry, got %+v", recorded)
+	}
+	if j.Undoable() || j.Path != "" {
+		t.Errorf("Expected a discarding journal to keep no file, got %q", j.Path)
+	}
+	if err := j.Close(); err != nil {
+		t.Errorf("Expected a discarding journal to close cleanly, got %v", err)
+	}
+}
+
+func TestNilJournal(t *testing.T) {
+	var j *Journal
+	if err := j.Record(data.MutationResult{Status: "added"}); err != nil {
+		t.Errorf("Expected nil journal to ignore records, got %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Errorf("Expected nil journal to close cleanly, got %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Load(dir, "../../etc/passwd"); err == nil || !strings.Contains(err.Error(), "invalid run ID") {
+		t.Errorf("Expected invalid run ID error, got %v", err)
+	}
+	if _, err := Load(dir, "missing"); err == nil || !strings.Contains(err.Error(), "no journal found") {
+		t.Errorf("Expected missing journal error, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.jsonl"), []byte("{not json}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+	if _, err := Load(dir, "broken"); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected parse error with line number, got %v", err)
+	}
+}
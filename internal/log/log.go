@@ -1,28 +1,76 @@
 package log
 
 import (
+	"fmt"
+
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
 )
 
+// Options configure the logger, empty values keep the defaults: console
+// output to stderr at info level, or debug level when Debug is set.
+type Options struct {
+	Debug  bool
+	Format string
+	Level  string
+	File   string
+}
+
 func NewLogger(debug bool) (*zap.Logger, error) {
+	return NewLoggerWithOptions(Options{Debug: debug})
+}
+
+func NewLoggerWithOptions(opts Options) (*zap.Logger, error) {
 	level := zap.InfoLevel
 
-	if debug {
+	if opts.Debug {
 		level = zap.DebugLevel
 	}
+	if opts.Level != "" {
+		parsed, err := zapcore.ParseLevel(opts.Level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --log-level %q, must be one of: debug, info, warn, error", opts.Level)
+		}
+		level = parsed
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = FormatConsole
+	}
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	switch format {
+	case FormatConsole:
+	case FormatJSON:
+		// JSON lines are read by log pipelines, keep the standard field names
+		encoderConfig = zap.NewProductionEncoderConfig()
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	default:
+		return nil, fmt.Errorf("invalid --log-format %q, must be one of: %s, %s", opts.Format, FormatConsole, FormatJSON)
+	}
+
+	output := "stderr"
+	if opts.File != "" {
+		output = opts.File
+	}
 
 	loggerConfig := zap.Config{
 		Level:            zap.NewAtomicLevelAt(level),
-		Encoding:         "console",
-		EncoderConfig:    zap.NewDevelopmentEncoderConfig(),
-		OutputPaths:      []string{"stderr"},
+		Encoding:         format,
+		EncoderConfig:    encoderConfig,
+		OutputPaths:      []string{output},
 		ErrorOutputPaths: []string{"stderr"},
 		// Disable stack traces for cleaner output
-		DisableStacktrace: !debug, // Only show stack traces in debug mode
+		DisableStacktrace: !opts.Debug, // Only show stack traces in debug mode
 	}
 
 	// Customize encoder config for better readability
-	if debug {
+	if opts.Debug {
 		loggerConfig.EncoderConfig.StacktraceKey = "stacktrace"
 	} else {
 		loggerConfig.EncoderConfig.StacktraceKey = ""
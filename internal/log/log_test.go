@@ -1,6 +1,10 @@
 package log
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"go.uber.org/zap"
@@ -82,3 +86,47 @@ func TestLoggerSingleton(t *testing.T) {
 		t.Error("NewLogger() should not return nil")
 	}
 }
+
+func TestNewLoggerWithOptions(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "gh-collaborators.log")
+	logger, err := NewLoggerWithOptions(Options{Format: FormatJSON, Level: "warn", File: logFile})
+	if err != nil {
+		t.Fatalf("NewLoggerWithOptions() error = %v, want nil", err)
+	}
+	logger.Info("skipped below the level")
+	logger.Warn("permission read failed", zap.String("org", "test-org"), zap.Int("http_status", 404))
+	_ = logger.Sync()
+
+	written, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(written)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected one log line at warn level, got:\n%s", written)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Expected a JSON log line, got %q: %v", lines[0], err)
+	}
+	if entry["msg"] != "permission read failed" || entry["org"] != "test-org" || entry["http_status"] != float64(404) {
+		t.Errorf("Expected structured fields in the log line, got %v", entry)
+	}
+}
+
+func TestNewLoggerWithOptionsInvalid(t *testing.T) {
+	if _, err := NewLoggerWithOptions(Options{Format: "xml"}); err == nil || !strings.Contains(err.Error(), "--log-format") {
+		t.Errorf("Expected an invalid format error, got %v", err)
+	}
+	if _, err := NewLoggerWithOptions(Options{Level: "loud"}); err == nil || !strings.Contains(err.Error(), "--log-level") {
+		t.Errorf("Expected an invalid level error, got %v", err)
+	}
+
+	logger, err := NewLoggerWithOptions(Options{Debug: true, Level: "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.Core().Enabled(zap.WarnLevel) {
+		t.Error("Expected --log-level to take precedence over --debug")
+	}
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+)
+
+const defaultSMTPPort = 587
+
+// Email mails the digest through an SMTP server. The connection is upgraded
+// with STARTTLS when the server offers it, and net/smtp refuses to send a
+// password over a plain connection to anything but localhost.
+type Email struct {
+	Addr string
+	From string
+	To   []string
+	auth smtp.Auth
+	send func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func newEmail(cfg config.EmailNotify) (*Email, error) {
+	switch {
+	case cfg.Host == "":
+		return nil, fmt.Errorf("notify.email.host is required")
+	case cfg.From == "":
+		return nil, fmt.Errorf("notify.email.from is required")
+	case len(cfg.To) == 0:
+		return nil, fmt.Errorf("notify.email.to needs at least one address")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	e := &Email{
+		Addr: net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		From: cfg.From,
+		To:   cfg.To,
+		send: sendMail,
+	}
+	if cfg.Username != "" {
+		password, err := config.ResolveSecret("notify.email.password_source", cfg.PasswordSource)
+		if err != nil {
+			return nil, err
+		}
+		e.auth = smtp.PlainAuth("", cfg.Username, password, cfg.Host)
+	}
+	return e, nil
+}
+
+func (e *Email) Name() string {
+	return "email"
+}
+
+// Notify sends the digest as a plain text message.
+func (e *Email) Notify(ctx context.Context, digest Digest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return e.send(ctx, e.Addr, e.auth, e.From, e.To, e.message(digest))
+}
+
+// sendMail sends msg as smtp.SendMail does, which takes no context and
+// would wait on a server that stops responding for as long as the server
+// keeps the connection open. Here the dial is bounded by sendTimeout and
+// the whole exchange by ctx, the connection is cut off when ctx ends.
+func sendMail(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	dialer := net.Dialer{Timeout: sendTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server %s does not support AUTH", addr)
+		}
+		if err := c.Auth(a); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := c.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *Email) message(digest Digest) []byte {
+	var b bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", e.From},
+		{"To", strings.Join(e.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", digest.Subject())},
+		{"Date", digest.Time.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+	} {
+		_, _ = fmt.Fprintf(&b, "%s: %s\r\n", header[0], header[1])
+	}
+	_, _ = b.WriteString("\r\n")
+	_, _ = b.WriteString(strings.ReplaceAll(digest.Text(), "\n", "\r\n"))
+	return b.Bytes()
+}
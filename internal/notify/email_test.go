@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+)
+
+func TestEmailNotify(t *testing.T) {
+	t.Setenv("TEST_SMTP_PASSWORD", "hunter2")
+	n, err := newEmail(config.EmailNotify{
+		Host:           "smtp.example.com",
+		Username:       "bot",
+		PasswordSource: "env:TEST_SMTP_PASSWORD",
+		From:           "bot@example.com",
+		To:             []string{"admins@example.com", "security@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var sentAddr, sentFrom string
+	var sentTo []string
+	var sentAuth smtp.Auth
+	var sent []byte
+	n.send = func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sentAddr, sentAuth, sentFrom, sentTo, sent = addr, a, from, to, msg
+		return nil
+	}
+
+	if err := n.Notify(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sentAddr != "smtp.example.com:587" || sentFrom != "bot@example.com" || len(sentTo) != 2 || sentAuth == nil {
+		t.Errorf("Unexpected envelope %s %s %v %v", sentAddr, sentFrom, sentTo, sentAuth)
+	}
+	message := string(sent)
+	for _, expected := range []string{
+		"From: bot@example.com\r\n",
+		"To: admins@example.com, security@example.com\r\n",
+		"Subject: gh collaborators add in test-org: 1 added, 1 updated, 1 removed, 1 skipped\r\n",
+		"Date: Thu, 01 Jan 2026 12:00:00 +0000\r\n",
+		"\r\n\r\ngh collaborators add changed collaborator access",
+		"\r\nrepo1\r\n  alice added with write\r\n",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected message to contain %q, got:\n%s", expected, message)
+		}
+	}
+}
+
+func TestEmailWithoutLogin(t *testing.T) {
+	n, err := newEmail(config.EmailNotify{Host: "localhost", Port: 25, From: "bot@example.com", To: []string{"admins@example.com"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n.Addr != "localhost:25" || n.auth != nil {
+		t.Errorf("Expected an unauthenticated server on port 25, got %s %v", n.Addr, n.auth)
+	}
+
+	if _, err := newEmail(config.EmailNotify{Host: "smtp.example.com", Username: "bot", PasswordSource: "env:TEST_SMTP_MISSING", From: "bot@example.com", To: []string{"a@example.com"}}); err == nil {
+		t.Error("Expected an error for an unset password, got nil")
+	}
+}
+
+// serveSMTP answers one SMTP session on a local listener, recording the
+// commands and message it receives, and returns the listener's address.
+func serveSMTP(t *testing.T, received chan<- string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 localhost ready")
+		var session strings.Builder
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			session.WriteString(line + "\n")
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				_ = text.PrintfLine("250 localhost")
+			case line == "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				body, _ := text.ReadDotLines()
+				session.WriteString(strings.Join(body, "\n") + "\n")
+				_ = text.PrintfLine("250 queued")
+			case line == "QUIT":
+				_ = text.PrintfLine("221 bye")
+				received <- session.String()
+				return
+			default:
+				_ = text.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestEmailSendMail(t *testing.T) {
+	received := make(chan string, 1)
+	addr := serveSMTP(t, received)
+	n := &Email{Addr: addr, From: "bot@example.com", To: []string{"admins@example.com"}, send: sendMail}
+
+	if err := n.Notify(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	session := <-received
+	for _, expected := range []string{
+		"MAIL FROM:<bot@example.com>",
+		"RCPT TO:<admins@example.com>",
+		"Subject: gh collaborators add in test-org",
+	} {
+		if !strings.Contains(session, expected) {
+			t.Errorf("Expected session to contain %q, got:\n%s", expected, session)
+		}
+	}
+}
+
+func TestEmailStalledServer(t *testing.T) {
+	// The server accepts the connection and never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	n := &Email{Addr: listener.Addr().String(), From: "bot@example.com", To: []string{"admins@example.com"}, send: sendMail}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := n.Notify(ctx, testDigest()); err == nil {
+		t.Fatal("Expected an error from a stalled server, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the send to end with the context, took %v", elapsed)
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+// GitHub opens an issue on every repository a run changed, so its admins
+// see what changed, and comments the whole digest on a tracking issue.
+type GitHub struct {
+	Issues               bool
+	MentionCollaborators bool
+	// Repository and IssueNumber locate the tracking issue, Repository is
+	// a name in the organization or owner/name
+	Repository  string
+	IssueNumber int
+	getter      utils.Getter
+}
+
+func newGitHub(cfg config.GitHubNotify, g utils.Getter) (*GitHub, error) {
+	if (cfg.Repository == "") != (cfg.IssueNumber == 0) {
+		return nil, fmt.Errorf("notify.github.repository and notify.github.issue_number must be set together")
+	}
+	if !cfg.Issues && cfg.Repository == "" {
+		return nil, fmt.Errorf("notify.github needs issues: true, or a repository and issue_number to comment on")
+	}
+	return &GitHub{
+		Issues:               cfg.Issues,
+		MentionCollaborators: cfg.MentionCollaborators,
+		Repository:           cfg.Repository,
+		IssueNumber:          cfg.IssueNumber,
+		getter:               g,
+	}, nil
+}
+
+func (n *GitHub) Name() string {
+	return "github"
+}
+
+func (n *GitHub) Notify(ctx context.Context, digest Digest) error {
+	var errs []error
+	if n.Issues {
+		title := fmt.Sprintf("Collaborator access changed by gh collaborators %s", digest.Command)
+		for _, repo := range digest.Repositories() {
+			if _, err := n.getter.CreateIssue(ctx, digest.Org, repo.Repository, title, n.issueBody(digest, repo)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to open an issue on %s: %w", repo.Repository, err))
+			}
+		}
+	}
+	if n.Repository != "" {
+		owner, repo := digest.Org, n.Repository
+		if i := strings.Index(n.Repository, "/"); i >= 0 {
+			owner, repo = n.Repository[:i], n.Repository[i+1:]
+		}
+		if _, err := n.getter.CreateIssueComment(ctx, owner, repo, n.IssueNumber, n.commentBody(digest)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to comment on %s#%d: %w", n.Repository, n.IssueNumber, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// issueBody lists the changes on one repository.
+func (n *GitHub) issueBody(digest Digest, repo RepositoryChanges) string {
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "`gh collaborators %s` changed collaborator access to this repository%s:\n\n", digest.Command, runID(digest))
+	n.writeChanges(&b, repo.Changes)
+	return b.String()
+}
+
+// commentBody lists the changes on every repository, followed by the rows
+// that were not applied.
+func (n *GitHub) commentBody(digest Digest) string {
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "`gh collaborators %s` changed collaborator access in %s%s.\n", digest.Command, digest.Org, runID(digest))
+	for _, repo := range digest.Repositories() {
+		_, _ = fmt.Fprintf(&b, "\n**%s**\n\n", repo.Repository)
+		n.writeChanges(&b, repo.Changes)
+	}
+	_, _ = fmt.Fprintln(&b, "\n```")
+	utils.WriteResultsSummary(&b, digest.Results)
+	_, _ = fmt.Fprintln(&b, "```")
+	return b.String()
+}
+
+// writeChanges writes a list item per change, naming the collaborator with
+// an @mention when MentionCollaborators is set so they are notified too.
+func (n *GitHub) writeChanges(b *bytes.Buffer, changes []data.MutationResult) {
+	for _, change := range changes {
+		username := "`" + change.Username + "`"
+		if n.MentionCollaborators {
+			username = "@" + change.Username
+		}
+		_, _ = fmt.Fprintf(b, "- %s %s\n", username, DescribeChange(change))
+	}
+}
+
+func runID(digest Digest) string {
+	if digest.RunID == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (run ID `%s`)", digest.RunID)
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+// issueGetter records the issues and comments created through it, failing
+// for repositories in fail.
+type issueGetter struct {
+	utils.Getter
+	fail     map[string]bool
+	issues   []string
+	comments []string
+}
+
+func (g *issueGetter) CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (*data.Issue, error) {
+	if g.fail[repo] {
+		return nil, errors.New("issues are disabled")
+	}
+	g.issues = append(g.issues, fmt.Sprintf("%s/%s: %s\n%s", owner, repo, title, body))
+	return &data.Issue{Number: len(g.issues), Title: title, Body: body}, nil
+}
+
+func (g *issueGetter) CreateIssueComment(ctx context.Context, owner string, repo string, number int, body string) (*data.IssueComment, error) {
+	g.comments = append(g.comments, fmt.Sprintf("%s/%s#%d\n%s", owner, repo, number, body))
+	return &data.IssueComment{Body: body}, nil
+}
+
+func TestGitHubNotifyIssues(t *testing.T) {
+	g := &issueGetter{}
+	n, err := newGitHub(config.GitHubNotify{Issues: true, MentionCollaborators: true}, g)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := n.Notify(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(g.issues) != 2 || len(g.comments) != 0 {
+		t.Fatalf("Expected an issue on each changed repository, got %v", g.issues)
+	}
+	expected := "test-org/repo2: Collaborator access changed by gh collaborators add\n" +
+		"`gh collaborators add` changed collaborator access to this repository (run ID `20260101-120000-abcd`):\n\n" +
+		"- @bob changed from read to admin\n" +
+		"- @carol removed, had read\n"
+	if g.issues[1] != expected {
+		t.Errorf("Expected issue:\n%s\ngot:\n%s", expected, g.issues[1])
+	}
+}
+
+func TestGitHubNotifyComment(t *testing.T) {
+	g := &issueGetter{}
+	n, err := newGitHub(config.GitHubNotify{Repository: "security/access-reviews", IssueNumber: 12}, g)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := n.Notify(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(g.issues) != 0 || len(g.comments) != 1 {
+		t.Fatalf("Expected a single comment, got %v and %v", g.issues, g.comments)
+	}
+	comment := g.comments[0]
+	for _, expected := range []string{
+		"security/access-reviews#12\n",
+		"\n**repo1**\n\n- `alice` added with write\n",
+		"\n**repo2**\n\n- `bob` changed from read to admin\n",
+		"skipped: dave on repo3 (already has write)",
+	} {
+		if !strings.Contains(comment, expected) {
+			t.Errorf("Expected comment to contain %q, got:\n%s", expected, comment)
+		}
+	}
+
+	// A repository name alone is in the organization
+	n.Repository = "access-reviews"
+	if err := n.Notify(context.Background(), testDigest()); err != nil || !strings.HasPrefix(g.comments[1], "test-org/access-reviews#12\n") {
+		t.Errorf("Expected a comment in test-org, got %v, %v", g.comments, err)
+	}
+}
+
+func TestGitHubNotifyContinuesAfterFailure(t *testing.T) {
+	g := &issueGetter{fail: map[string]bool{"repo1": true}}
+	n, err := newGitHub(config.GitHubNotify{Issues: true, Repository: "access-reviews", IssueNumber: 12}, g)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = n.Notify(context.Background(), testDigest())
+	if err == nil || !strings.Contains(err.Error(), "failed to open an issue on repo1: issues are disabled") {
+		t.Errorf("Expected the repo1 failure, got %v", err)
+	}
+	if len(g.issues) != 1 || len(g.comments) != 1 {
+		t.Errorf("Expected the other issue and the comment to be made, got %v and %v", g.issues, g.comments)
+	}
+}
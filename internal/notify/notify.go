@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"go.uber.org/zap"
+)
+
+// sendTimeout bounds each notifier, a slow endpoint should not hold up the
+// end of a run
+const sendTimeout = 30 * time.Second
+
+// Digest is what one add or remove run did, sent once the run finishes.
+type Digest struct {
+	Command string                `json:"command"`
+	Host    string                `json:"host"`
+	Org     string                `json:"org"`
+	RunID   string                `json:"run_id,omitempty"`
+	Time    time.Time             `json:"time"`
+	Results []data.MutationResult `json:"results"`
+}
+
+// RepositoryChanges are the changes a run made on one repository.
+type RepositoryChanges struct {
+	Repository string
+	Changes    []data.MutationResult
+}
+
+// Notifier sends a digest to one destination.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, digest Digest) error
+}
+
+// Sender sends each run's digest to every notifier configured for the
+// profile. A nil Sender sends nothing.
+type Sender struct {
+	Host      string
+	Notifiers []Notifier
+}
+
+// New builds the notifiers configured in cfg, g opens issues and comments
+// and transport, when set, carries the webhook requests. It returns a nil
+// Sender when no notification is configured.
+func New(cfg config.Notify, host string, g utils.Getter, transport http.RoundTripper) (*Sender, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	client := &http.Client{Timeout: sendTimeout, Transport: transport}
+	s := &Sender{Host: host}
+	if cfg.Webhook != nil {
+		n, err := newWebhook(*cfg.Webhook, client)
+		if err != nil {
+			return nil, err
+		}
+		s.Notifiers = append(s.Notifiers, n)
+	}
+	if cfg.Slack != nil {
+		n, err := newSlack(*cfg.Slack, client)
+		if err != nil {
+			return nil, err
+		}
+		s.Notifiers = append(s.Notifiers, n)
+	}
+	if cfg.Email != nil {
+		n, err := newEmail(*cfg.Email)
+		if err != nil {
+			return nil, err
+		}
+		s.Notifiers = append(s.Notifiers, n)
+	}
+	if cfg.GitHub != nil {
+		n, err := newGitHub(*cfg.GitHub, g)
+		if err != nil {
+			return nil, err
+		}
+		s.Notifiers = append(s.Notifiers, n)
+	}
+	return s, nil
+}
+
+// Send fills in the digest's host and time and sends it to every notifier,
+// a run that changed nothing sends nothing. Every notifier is tried, the
+// errors of those that failed are joined.
+func (s *Sender) Send(ctx context.Context, digest Digest) error {
+	if s == nil || len(digest.Changes()) == 0 {
+		return nil
+	}
+	digest.Host = s.Host
+	if digest.Time.IsZero() {
+		digest.Time = time.Now().UTC()
+	}
+
+	var errs []error
+	for _, n := range s.Notifiers {
+		notifyCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := n.Notify(notifyCtx, digest)
+		cancel()
+		if err != nil {
+			zap.L().Warn("Failed to send notification", zap.String("notifier", n.Name()), zap.String("run_id", digest.RunID), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+			continue
+		}
+		zap.L().Debug("Sent notification", zap.String("notifier", n.Name()), zap.String("run_id", digest.RunID))
+	}
+	return errors.Join(errs...)
+}
+
+// Changes returns the results that changed access, in run order.
+func (d Digest) Changes() []data.MutationResult {
+	var changes []data.MutationResult
+	for _, result := range d.Results {
+		if isChange(result) {
+			changes = append(changes, result)
+		}
+	}
+	return changes
+}
+
+// Repositories groups the changes by repository, sorted by name.
+func (d Digest) Repositories() []RepositoryChanges {
+	byRepo := map[string]*RepositoryChanges{}
+	var repos []*RepositoryChanges
+	for _, result := range d.Changes() {
+		key := strings.ToLower(result.RepositoryName)
+		repo, ok := byRepo[key]
+		if !ok {
+			repo = &RepositoryChanges{Repository: result.RepositoryName}
+			byRepo[key] = repo
+			repos = append(repos, repo)
+		}
+		repo.Changes = append(repo.Changes, result)
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return strings.ToLower(repos[i].Repository) < strings.ToLower(repos[j].Repository)
+	})
+	grouped := make([]RepositoryChanges, len(repos))
+	for i, repo := range repos {
+		grouped[i] = *repo
+	}
+	return grouped
+}
+
+// Subject is a one line summary of the run, such as
+// "gh collaborators add in my-org: 1 added, 1 failed".
+func (d Digest) Subject() string {
+	return fmt.Sprintf("gh collaborators %s in %s: %s", d.Command, d.Org, utils.ResultTotals(d.Results))
+}
+
+// Text returns the digest as plain text, the changes grouped by repository
+// followed by the rows that were not applied.
+func (d Digest) Text() string {
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "gh collaborators %s changed collaborator access in %s on %s", d.Command, d.Org, d.Host)
+	if d.RunID != "" {
+		_, _ = fmt.Fprintf(&b, ", run ID %s", d.RunID)
+	}
+	_, _ = fmt.Fprintln(&b, ".")
+	for _, repo := range d.Repositories() {
+		_, _ = fmt.Fprintf(&b, "\n%s\n", repo.Repository)
+		for _, change := range repo.Changes {
+			_, _ = fmt.Fprintf(&b, "  %s %s\n", change.Username, DescribeChange(change))
+		}
+	}
+	_, _ = fmt.Fprintln(&b)
+	utils.WriteResultsSummary(&b, d.Results)
+	return b.String()
+}
+
+// DescribeChange says what a result did to the collaborator's access, such
+// as "added with write" or "changed from read to write".
+func DescribeChange(result data.MutationResult) string {
+	permission := utils.NormalizePermission(result.Permission)
+	previous := utils.NormalizePermission(result.PreviousPermission)
+	switch result.Status {
+	case utils.StatusAdded:
+		return "added with " + permission
+	case utils.StatusUpdated:
+		return fmt.Sprintf("changed from %s to %s", previous, permission)
+	case utils.StatusRemoved:
+		if previous == utils.PermissionNone {
+			return "removed"
+		}
+		return fmt.Sprintf("removed, had %s", previous)
+	default:
+		return result.Status
+	}
+}
+
+func isChange(result data.MutationResult) bool {
+	switch result.Status {
+	case utils.StatusAdded, utils.StatusUpdated, utils.StatusRemoved:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+func testDigest() Digest {
+	return Digest{
+		Command: "add",
+		Org:     "test-org",
+		RunID:   "20260101-120000-abcd",
+		Time:    time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		Results: []data.MutationResult{
+			{RepositoryName: "repo2", Username: "bob", Permission: "admin", PreviousPermission: "read", Status: utils.StatusUpdated},
+			{RepositoryName: "repo1", Username: "alice", Permission: "push", Status: utils.StatusAdded},
+			{RepositoryName: "repo3", Username: "dave", Permission: "write", PreviousPermission: "write", Status: utils.StatusSkipped, Reason: "already has write"},
+			{RepositoryName: "Repo2", Username: "carol", PreviousPermission: "pull", Status: utils.StatusRemoved},
+		},
+	}
+}
+
+type fakeNotifier struct {
+	name    string
+	err     error
+	digests []Digest
+}
+
+func (n *fakeNotifier) Name() string {
+	return n.name
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, digest Digest) error {
+	n.digests = append(n.digests, digest)
+	return n.err
+}
+
+func TestDigestRepositories(t *testing.T) {
+	repos := testDigest().Repositories()
+	if len(repos) != 2 || repos[0].Repository != "repo1" || repos[1].Repository != "repo2" {
+		t.Fatalf("Expected changes on repo1 and repo2, got %+v", repos)
+	}
+	// Repository names group case insensitively, skipped rows are left out
+	if len(repos[1].Changes) != 2 || repos[1].Changes[0].Username != "bob" || repos[1].Changes[1].Username != "carol" {
+		t.Errorf("Expected bob and carol on repo2 in run order, got %+v", repos[1].Changes)
+	}
+}
+
+func TestDigestText(t *testing.T) {
+	digest := testDigest()
+	digest.Host = "github.com"
+
+	if digest.Subject() != "gh collaborators add in test-org: 1 added, 1 updated, 1 removed, 1 skipped" {
+		t.Errorf("Unexpected subject %q", digest.Subject())
+	}
+	text := digest.Text()
+	for _, expected := range []string{
+		"gh collaborators add changed collaborator access in test-org on github.com, run ID 20260101-120000-abcd.\n",
+		"\nrepo1\n  alice added with write\n",
+		"\nrepo2\n  bob changed from read to admin\n  carol removed, had read\n",
+		"skipped: dave on repo3 (already has write)",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected digest to contain %q, got:\n%s", expected, text)
+		}
+	}
+}
+
+func TestDescribeChange(t *testing.T) {
+	tests := []struct {
+		result   data.MutationResult
+		expected string
+	}{
+		{data.MutationResult{Status: utils.StatusAdded, Permission: "pull"}, "added with read"},
+		{data.MutationResult{Status: utils.StatusUpdated, Permission: "maintain", PreviousPermission: "push"}, "changed from write to maintain"},
+		{data.MutationResult{Status: utils.StatusRemoved, PreviousPermission: "triage"}, "removed, had triage"},
+		{data.MutationResult{Status: utils.StatusRemoved}, "removed"},
+		{data.MutationResult{Status: utils.StatusFailed}, "failed"},
+	}
+	for _, tt := range tests {
+		if got := DescribeChange(tt.result); got != tt.expected {
+			t.Errorf("Expected %q for %+v, got %q", tt.expected, tt.result, got)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	failing := &fakeNotifier{name: "failing", err: errors.New("unreachable")}
+	working := &fakeNotifier{name: "working"}
+	s := &Sender{Host: "github.example.com", Notifiers: []Notifier{failing, working}}
+
+	err := s.Send(context.Background(), testDigest())
+	if err == nil || err.Error() != "failing: unreachable" {
+		t.Errorf("Expected the failing notifier's error, got %v", err)
+	}
+	// A failing notifier does not stop the others
+	if len(working.digests) != 1 || working.digests[0].Host != "github.example.com" {
+		t.Errorf("Expected the digest with its host, got %+v", working.digests)
+	}
+
+	// Runs that changed nothing send nothing
+	unchanged := testDigest()
+	unchanged.Results = unchanged.Results[2:3]
+	if err := s.Send(context.Background(), unchanged); err != nil || len(working.digests) != 1 {
+		t.Errorf("Expected no notification without changes, got %v and %d digests", err, len(working.digests))
+	}
+
+	var nilSender *Sender
+	if err := nilSender.Send(context.Background(), testDigest()); err != nil {
+		t.Errorf("Expected a nil sender to send nothing, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(config.Notify{}, "github.com", nil, nil)
+	if err != nil || s != nil {
+		t.Errorf("Expected no sender without notifications, got %+v, %v", s, err)
+	}
+
+	t.Setenv("TEST_SLACK_WEBHOOK", "https://hooks.slack.example.com/T000/B000/secret")
+	s, err = New(config.Notify{
+		Webhook: &config.WebhookNotify{URL: "https://hooks.example.com/collaborators"},
+		Slack:   &config.SlackNotify{WebhookURL: "env:TEST_SLACK_WEBHOOK"},
+		Email:   &config.EmailNotify{Host: "smtp.example.com", From: "bot@example.com", To: []string{"admins@example.com"}},
+		GitHub:  &config.GitHubNotify{Issues: true},
+	}, "github.com", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var names []string
+	for _, n := range s.Notifiers {
+		names = append(names, n.Name())
+	}
+	if strings.Join(names, ",") != "webhook,slack,email,github" {
+		t.Errorf("Expected every notifier, got %v", names)
+	}
+	if url := s.Notifiers[1].(*Slack).WebhookURL; url != "https://hooks.slack.example.com/T000/B000/secret" {
+		t.Errorf("Expected the slack URL from the environment, got %s", url)
+	}
+
+	for name, cfg := range map[string]config.Notify{
+		"webhook without url":   {Webhook: &config.WebhookNotify{}},
+		"slack with unset env":  {Slack: &config.SlackNotify{WebhookURL: "env:TEST_SLACK_MISSING"}},
+		"email without to":      {Email: &config.EmailNotify{Host: "smtp.example.com", From: "bot@example.com"}},
+		"github without target": {GitHub: &config.GitHubNotify{MentionCollaborators: true}},
+		"github without number": {GitHub: &config.GitHubNotify{Repository: "access-reviews"}},
+	} {
+		if _, err := New(cfg, "github.com", nil, nil); err == nil {
+			t.Errorf("Expected an error for %s, got nil", name)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+// webhookPayload is the digest posted by the JSON webhook, with the totals
+// per status alongside every row.
+type webhookPayload struct {
+	Digest
+	Summary map[string]int `json:"summary"`
+}
+
+// Webhook posts the digest as JSON to a URL.
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+	client  *http.Client
+}
+
+func newWebhook(cfg config.WebhookNotify, client *http.Client) (*Webhook, error) {
+	endpoint, err := config.ResolveSecret("notify.webhook.url", cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("notify.webhook.url is required")
+	}
+	headers := map[string]string{}
+	for name, value := range cfg.Headers {
+		if headers[name], err = config.ResolveSecret("notify.webhook.headers."+name, value); err != nil {
+			return nil, err
+		}
+	}
+	return &Webhook{URL: endpoint, Headers: headers, client: client}, nil
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+func (w *Webhook) Notify(ctx context.Context, digest Digest) error {
+	return postJSON(ctx, w.client, w.URL, w.Headers, webhookPayload{Digest: digest, Summary: utils.CountResults(digest.Results)})
+}
+
+// slackPayload is the message format of Slack incoming webhooks, which
+// Mattermost, Rocket.Chat and others accept too.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// Slack posts the digest as a message to a Slack compatible incoming webhook.
+type Slack struct {
+	WebhookURL string
+	client     *http.Client
+}
+
+func newSlack(cfg config.SlackNotify, client *http.Client) (*Slack, error) {
+	endpoint, err := config.ResolveSecret("notify.slack.webhook_url", cfg.WebhookURL)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("notify.slack.webhook_url is required")
+	}
+	return &Slack{WebhookURL: endpoint, client: client}, nil
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+func (s *Slack) Notify(ctx context.Context, digest Digest) error {
+	return postJSON(ctx, s.client, s.WebhookURL, nil, slackPayload{Text: fmt.Sprintf("*%s*\n```\n%s```", digest.Subject(), digest.Text())})
+}
+
+// postJSON posts payload to endpoint, any status other than 2xx is an
+// error. Errors leave the endpoint out, incoming webhook URLs are secrets.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, payload any) error {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("webhook request failed: %w", urlErr.Err)
+		}
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
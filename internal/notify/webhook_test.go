@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/config"
+)
+
+// capture records the requests made to a test server, answering with status.
+func capture(t *testing.T, status int) (*httptest.Server, *[]*http.Request, *[][]byte) {
+	t.Helper()
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("no_service\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &bodies
+}
+
+func TestWebhookNotify(t *testing.T) {
+	server, requests, bodies := capture(t, http.StatusNoContent)
+	t.Setenv("TEST_WEBHOOK_TOKEN", "Bearer secret")
+	n, err := newWebhook(config.WebhookNotify{
+		URL:     server.URL + "/collaborators",
+		Headers: map[string]string{"Authorization": "env:TEST_WEBHOOK_TOKEN"},
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := n.Notify(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("Expected one request, got %d", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/collaborators" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected request %s %s %v", req.Method, req.URL.Path, req.Header)
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Expected the header from the environment, got %q", req.Header.Get("Authorization"))
+	}
+
+	var payload struct {
+		Command string         `json:"command"`
+		Org     string         `json:"org"`
+		RunID   string         `json:"run_id"`
+		Summary map[string]int `json:"summary"`
+		Results []struct {
+			Repository string `json:"repository"`
+			Status     string `json:"status"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal((*bodies)[0], &payload); err != nil {
+		t.Fatalf("Expected a JSON payload, got %v", err)
+	}
+	if payload.Command != "add" || payload.Org != "test-org" || payload.RunID != "20260101-120000-abcd" {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+	if payload.Summary["added"] != 1 || payload.Summary["skipped"] != 1 || len(payload.Results) != 4 {
+		t.Errorf("Expected every result with totals, got %+v", payload)
+	}
+}
+
+func TestSlackNotify(t *testing.T) {
+	server, _, bodies := capture(t, http.StatusOK)
+	n, err := newSlack(config.SlackNotify{WebhookURL: server.URL}, server.Client())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := n.Notify(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var payload slackPayload
+	if err := json.Unmarshal((*bodies)[0], &payload); err != nil {
+		t.Fatalf("Expected a JSON payload, got %v", err)
+	}
+	if !strings.HasPrefix(payload.Text, "*gh collaborators add in test-org: ") || !strings.Contains(payload.Text, "alice added with write") {
+		t.Errorf("Unexpected message %q", payload.Text)
+	}
+}
+
+func TestWebhookNotifyErrorHidesURL(t *testing.T) {
+	server, _, _ := capture(t, http.StatusNotFound)
+	n, err := newSlack(config.SlackNotify{WebhookURL: server.URL + "/services/secret"}, server.Client())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = n.Notify(context.Background(), testDigest())
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found: no_service") {
+		t.Errorf("Expected the response status and body, got %v", err)
+	}
+
+	server.Close()
+	err = n.Notify(context.Background(), testDigest())
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected an error without the webhook URL, got %v", err)
+	}
+}
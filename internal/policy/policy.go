@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ActionLog reports a grant breaking the policy and leaves it
+	ActionLog = "log"
+	// ActionIssue opens an issue on the repository about the grant
+	ActionIssue = "issue"
+	// ActionRevert removes the collaborator from the repository
+	ActionRevert = "revert"
+)
+
+// Actions are the ways a grant breaking the policy can be handled
+var Actions = []string{ActionLog, ActionIssue, ActionRevert}
+
+// Policy limits the repository access outside collaborators may hold. The
+// first repository rule whose pattern matches overrides the defaults.
+type Policy struct {
+	MaxPermission string           `yaml:"max_permission"`
+	Action        string           `yaml:"action"`
+	AllowedUsers  []string         `yaml:"allowed_users"`
+	Repositories  []RepositoryRule `yaml:"repositories"`
+}
+
+// RepositoryRule sets the highest permission, and optionally the action,
+// for repositories matching a shell pattern such as "public-*".
+type RepositoryRule struct {
+	Pattern       string `yaml:"pattern"`
+	MaxPermission string `yaml:"max_permission"`
+	Action        string `yaml:"action"`
+}
+
+// Decision is the outcome of checking a grant against the policy.
+type Decision struct {
+	Allowed       bool
+	MaxPermission string
+	Action        string
+}
+
+// Load reads and validates a policy file.
+func Load(file string) (*Policy, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", file, err)
+	}
+	p := new(Policy)
+	if err := yaml.Unmarshal(content, p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", file, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", file, err)
+	}
+	return p, nil
+}
+
+func (p *Policy) validate() error {
+	if p.MaxPermission == "" {
+		return fmt.Errorf("max_permission is required")
+	}
+	if err := validatePermission(p.MaxPermission); err != nil {
+		return err
+	}
+	if p.Action == "" {
+		p.Action = ActionLog
+	}
+	if err := validateAction(p.Action); err != nil {
+		return err
+	}
+	for i, rule := range p.Repositories {
+		if _, err := path.Match(rule.Pattern, ""); err != nil || rule.Pattern == "" {
+			return fmt.Errorf("repositories[%d] has an invalid pattern %q", i, rule.Pattern)
+		}
+		if rule.MaxPermission != "" {
+			if err := validatePermission(rule.MaxPermission); err != nil {
+				return fmt.Errorf("repositories[%d]: %w", i, err)
+			}
+		}
+		if rule.Action != "" {
+			if err := validateAction(rule.Action); err != nil {
+				return fmt.Errorf("repositories[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePermission(permission string) error {
+	if !utils.IsBuiltInPermission(permission) {
+		return fmt.Errorf("unknown permission %q", permission)
+	}
+	return nil
+}
+
+func validateAction(action string) error {
+	for _, known := range Actions {
+		if action == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q, must be one of: %s", action, strings.Join(Actions, ", "))
+}
+
+// Check decides whether an outside collaborator may hold permission on the
+// repository, and how to handle the grant when they may not.
+func (p *Policy) Check(repo string, username string, permission string) Decision {
+	decision := Decision{Allowed: true, MaxPermission: utils.NormalizePermission(p.MaxPermission), Action: p.Action}
+	for _, rule := range p.Repositories {
+		if matched, _ := path.Match(strings.ToLower(rule.Pattern), strings.ToLower(repo)); !matched {
+			continue
+		}
+		if rule.MaxPermission != "" {
+			decision.MaxPermission = utils.NormalizePermission(rule.MaxPermission)
+		}
+		if rule.Action != "" {
+			decision.Action = rule.Action
+		}
+		break
+	}
+	for _, allowed := range p.AllowedUsers {
+		if strings.EqualFold(allowed, username) {
+			return decision
+		}
+	}
+	decision.Allowed = utils.CoversPermission(decision.MaxPermission, permission)
+	return decision
+}
@@ -0,0 +1,72 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAndCheck(t *testing.T) {
+	p, err := Load("testdata/policy.yaml")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		repo       string
+		username   string
+		permission string
+		expected   Decision
+	}{
+		{name: "within the default", repo: "repo1", username: "alice", permission: "push", expected: Decision{Allowed: true, MaxPermission: "write", Action: ActionIssue}},
+		{name: "above the default", repo: "repo1", username: "alice", permission: "admin", expected: Decision{Allowed: false, MaxPermission: "write", Action: ActionIssue}},
+		{name: "custom role", repo: "repo1", username: "alice", permission: "security", expected: Decision{Allowed: false, MaxPermission: "write", Action: ActionIssue}},
+		{name: "forbidden repository", repo: "Secret-keys", username: "alice", permission: "read", expected: Decision{Allowed: false, MaxPermission: "none", Action: ActionRevert}},
+		{name: "rule keeps default action", repo: "docs", username: "alice", permission: "write", expected: Decision{Allowed: false, MaxPermission: "read", Action: ActionIssue}},
+		{name: "allowed user", repo: "secret-keys", username: "Trusted-Vendor", permission: "admin", expected: Decision{Allowed: true, MaxPermission: "none", Action: ActionRevert}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Check(tt.repo, tt.username, tt.permission); got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadDefaultsToLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte("max_permission: read\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if p.Action != ActionLog {
+		t.Errorf("Expected the log action by default, got %s", p.Action)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"action: log\n":                                             "max_permission is required",
+		"max_permission: owner\n":                                   `unknown permission "owner"`,
+		"max_permission: read\naction: x\n":                         `unknown action "x"`,
+		"max_permission: read\nrepositories:\n  - pattern: \"[\"\n": `repositories[0] has an invalid pattern "["`,
+		"max_permission: read\nrepositories:\n  - pattern: a\n    max_permission: owner\n": `repositories[0]: unknown permission "owner"`,
+	}
+
+	for content, expected := range tests {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q for %q, got %v", expected, content, err)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type Request struct {
+	Method    string                 `json:"method"`
+	Path      string                 `json:"path"`
+	Query     string                 `json:"query,omitempty"`
+	Body      json.RawMessage        `json:"body,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+func LoadFixture(path string) (*Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+	fixture := new(Fixture)
+	if err := json.Unmarshal(content, fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+func (f *Fixture) Save(path string) error {
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// RecordEnv switches NewServer to recording real traffic into its fixture
+// instead of replaying it, the token comes from the test's client options.
+const RecordEnv = "GH_COLLABORATORS_RECORD"
+
+// Server stands in for the REST and GraphQL endpoints, answering each
+// request with the first unused interaction that matches it.
+type Server struct {
+	t        testing.TB
+	server   *httptest.Server
+	recorder *Recorder
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	requests     []Request
+}
+
+func NewServer(t testing.TB, fixturePaths ...string) *Server {
+	t.Helper()
+	s := &Server{t: t}
+	if os.Getenv(RecordEnv) != "" && len(fixturePaths) == 1 {
+		s.recorder = &Recorder{Path: fixturePaths[0]}
+		s.server = httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(s.server.Close)
+		return s
+	}
+	for _, path := range fixturePaths {
+		fixture, err := LoadFixture(path)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		s.Add(fixture.Interactions...)
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *Server) Add(interactions ...Interaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interactions = append(s.interactions, interactions...)
+	s.used = append(s.used, make([]bool, len(interactions))...)
+}
+
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Transport sends every request to the replay server regardless of the
+// host go-gh resolved for it.
+func (s *Server) Transport() http.RoundTripper {
+	if s.recorder != nil {
+		return s.recorder
+	}
+	target, _ := url.Parse(s.server.URL)
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rewritten := req.Clone(req.Context())
+		rewritten.URL.Scheme = target.Scheme
+		rewritten.URL.Host = target.Host
+		rewritten.Host = target.Host
+		return http.DefaultTransport.RoundTrip(rewritten)
+	})
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Unused returns the interactions that were never requested.
+func (s *Server) Unused() []Interaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var unused []Interaction
+	for i, used := range s.used {
+		if !used {
+			unused = append(unused, s.interactions[i])
+		}
+	}
+	return unused
+}
+
+func (s *Server) AssertAllUsed() {
+	s.t.Helper()
+	if s.recorder != nil {
+		return
+	}
+	for _, interaction := range s.Unused() {
+		s.t.Errorf("Expected request %s %s %v was never made", interaction.Request.Method, interaction.Request.Path, interaction.Request.Variables)
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	received, err := newRequest(r)
+	if err != nil {
+		s.t.Errorf("Failed to read request %s %s: %v", r.Method, r.URL.Path, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, received)
+	match := -1
+	for i, interaction := range s.interactions {
+		if !s.used[i] && matches(interaction.Request, received) {
+			match = i
+			break
+		}
+	}
+	if match >= 0 {
+		s.used[match] = true
+	}
+	s.mu.Unlock()
+
+	if match < 0 {
+		s.t.Errorf("Unexpected request %s %s?%s %s", received.Method, received.Path, received.Query, string(received.Body))
+		w.WriteHeader(http.StatusNotImplemented)
+		_, _ = fmt.Fprint(w, `{"message":"no fixture matches this request"}`)
+		return
+	}
+
+	resp := s.interactions[match].Response
+	for key, value := range resp.Headers {
+		w.Header().Set(key, value)
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(resp.Body)
+}
+
+func newRequest(r *http.Request) (Request, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Request{}, err
+	}
+	received := Request{
+		Method: r.Method,
+		Path:   normalizePath(r.URL.Path),
+		Query:  r.URL.RawQuery,
+	}
+	if len(body) > 0 {
+		received.Body = body
+	}
+
+	var graphQL struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if strings.HasSuffix(received.Path, "/graphql") && json.Unmarshal(body, &graphQL) == nil {
+		received.Variables = graphQL.Variables
+	}
+	return received, nil
+}
+
+// normalizePath drops the GitHub Enterprise Server API prefixes so one
+// fixture serves both github.com and GHES hosts.
+func normalizePath(path string) string {
+	path = strings.TrimPrefix(path, "/api/v3")
+	if path == "/api/graphql" {
+		return "/graphql"
+	}
+	return path
+}
+
+func matches(expected Request, received Request) bool {
+	if !strings.EqualFold(expected.Method, received.Method) || expected.Path != received.Path {
+		return false
+	}
+	if expected.Query != "" && expected.Query != received.Query {
+		return false
+	}
+	if len(expected.Body) > 0 && !jsonEqual(expected.Body, received.Body) {
+		return false
+	}
+	for key, value := range expected.Variables {
+		if !reflect.DeepEqual(value, received.Variables[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+func jsonEqual(a, b []byte) bool {
+	var left, right interface{}
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return bytes.Equal(bytes.TrimSpace(a), bytes.TrimSpace(b))
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// Recorder captures real API traffic into a fixture file, request headers
+// are never recorded so tokens do not end up in fixtures.
+type Recorder struct {
+	Path string
+	Base http.RoundTripper
+
+	mu      sync.Mutex
+	fixture Fixture
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = body
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	base := r.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded := Request{Method: req.Method, Path: normalizePath(req.URL.Path), Query: req.URL.RawQuery}
+	if strings.HasSuffix(recorded.Path, "/graphql") {
+		var graphQL struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if json.Unmarshal(reqBody, &graphQL) == nil {
+			recorded.Variables = graphQL.Variables
+		}
+	} else if json.Valid(reqBody) {
+		recorded.Body = reqBody
+	}
+
+	headers := map[string]string{}
+	for _, name := range []string{"Content-Type", "ETag", "Link", "Retry-After", "X-GitHub-Request-Id"} {
+		if value := resp.Header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	interaction := Interaction{Request: recorded, Response: Response{Status: resp.StatusCode, Headers: headers}}
+	if json.Valid(respBody) {
+		interaction.Response.Body = respBody
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+	if err := r.fixture.Save(r.Path); err != nil {
+		return nil, fmt.Errorf("failed to save fixture %s: %w", r.Path, err)
+	}
+	return resp, nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,206 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testFixture = `{
+  "interactions": [
+    {
+      "request": {"method": "GET", "path": "/orgs/test-org/outside_collaborators"},
+      "response": {"status": 200, "headers": {"ETag": "\"abc\""}, "body": [{"login": "alice"}]}
+    },
+    {
+      "request": {"method": "POST", "path": "/graphql", "variables": {"user": "alice", "endCursor": null}},
+      "response": {"status": 200, "body": {"data": {"page": 1}}}
+    },
+    {
+      "request": {"method": "POST", "path": "/graphql", "variables": {"user": "alice", "endCursor": "next"}},
+      "response": {"status": 200, "body": {"data": {"page": 2}}}
+    },
+    {
+      "request": {"method": "PUT", "path": "/repos/test-org/repo1/collaborators/alice", "body": {"permission": "push"}},
+      "response": {"status": 204}
+    }
+  ]
+}`
+
+func writeFixture(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func compact(body string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(body)); err != nil {
+		return body
+	}
+	return buf.String()
+}
+
+func do(t *testing.T, client *http.Client, method string, url string, body string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	content, _ := io.ReadAll(resp.Body)
+	return resp, string(content)
+}
+
+func TestServerReplaysMatchingInteractions(t *testing.T) {
+	server := NewServer(t, writeFixture(t, testFixture))
+	client := &http.Client{Transport: server.Transport()}
+
+	resp, body := do(t, client, "GET", "https://api.github.com/orgs/test-org/outside_collaborators", "")
+	if resp.StatusCode != 200 || resp.Header.Get("ETag") != `"abc"` || !strings.Contains(body, "alice") {
+		t.Errorf("Unexpected REST response %d %s", resp.StatusCode, body)
+	}
+
+	// GraphQL requests are matched on their variables, not their order
+	_, body = do(t, client, "POST", "https://api.github.com/graphql", `{"query":"q","variables":{"user":"alice","endCursor":"next"}}`)
+	if !strings.Contains(compact(body), `"page":2`) {
+		t.Errorf("Expected second page, got %s", body)
+	}
+	_, body = do(t, client, "POST", "https://github.example.com/api/graphql", `{"query":"q","variables":{"user":"alice","endCursor":null}}`)
+	if !strings.Contains(compact(body), `"page":1`) {
+		t.Errorf("Expected first page, got %s", body)
+	}
+
+	resp, _ = do(t, client, "PUT", "https://github.example.com/api/v3/repos/test-org/repo1/collaborators/alice", `{ "permission" : "push" }`)
+	if resp.StatusCode != 204 {
+		t.Errorf("Expected status 204, got %d", resp.StatusCode)
+	}
+
+	server.AssertAllUsed()
+	if len(server.Requests()) != 4 {
+		t.Errorf("Expected 4 recorded requests, got %d", len(server.Requests()))
+	}
+}
+
+func TestServerInteractionsAreUsedOnce(t *testing.T) {
+	server := NewServer(t)
+	server.Add(Interaction{
+		Request:  Request{Method: "DELETE", Path: "/repos/test-org/repo1/collaborators/alice"},
+		Response: Response{Status: 204},
+	})
+	client := &http.Client{Transport: server.Transport()}
+
+	resp, _ := do(t, client, "DELETE", server.URL()+"/repos/test-org/repo1/collaborators/alice", "")
+	if resp.StatusCode != 204 {
+		t.Errorf("Expected status 204, got %d", resp.StatusCode)
+	}
+	if len(server.Unused()) != 0 {
+		t.Errorf("Expected no unused interactions, got %d", len(server.Unused()))
+	}
+}
+
+func TestMatches(t *testing.T) {
+	expected := Request{Method: "PUT", Path: "/repos/o/r/collaborators/u", Body: []byte(`{"permission":"push"}`)}
+
+	tests := []struct {
+		name     string
+		received Request
+		match    bool
+	}{
+		{name: "same", received: Request{Method: "put", Path: "/repos/o/r/collaborators/u", Body: []byte(`{"permission": "push"}`)}, match: true},
+		{name: "other method", received: Request{Method: "DELETE", Path: "/repos/o/r/collaborators/u"}, match: false},
+		{name: "other path", received: Request{Method: "PUT", Path: "/repos/o/r/collaborators/v", Body: []byte(`{"permission":"push"}`)}, match: false},
+		{name: "other body", received: Request{Method: "PUT", Path: "/repos/o/r/collaborators/u", Body: []byte(`{"permission":"pull"}`)}, match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(expected, tt.received); got != tt.match {
+				t.Errorf("Expected match to be %v, got %v", tt.match, got)
+			}
+		})
+	}
+
+	withQuery := Request{Method: "GET", Path: "/orgs/o/outside_collaborators", Query: "page=2"}
+	if matches(withQuery, Request{Method: "GET", Path: "/orgs/o/outside_collaborators", Query: "page=1"}) {
+		t.Error("Expected query mismatch not to match")
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := map[string]string{
+		"/api/v3/orgs/o/outside_collaborators": "/orgs/o/outside_collaborators",
+		"/api/graphql":                         "/graphql",
+		"/graphql":                             "/graphql",
+		"/orgs/o":                              "/orgs/o",
+	}
+	for input, expected := range tests {
+		if got := normalizePath(input); got != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, input, got)
+		}
+	}
+}
+
+func TestLoadFixtureErrors(t *testing.T) {
+	if _, err := LoadFixture(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing fixture, got nil")
+	}
+	if _, err := LoadFixture(writeFixture(t, "{not json")); err == nil {
+		t.Error("Expected error for invalid fixture, got nil")
+	}
+}
+
+func TestRecorderWritesReplayableFixture(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+		if r.Method == "POST" {
+			_, _ = io.WriteString(w, `{"data":{"ok":true}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `[{"login":"alice"}]`)
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "recorded.json")
+	client := &http.Client{Transport: &Recorder{Path: path}}
+
+	req, _ := http.NewRequest("GET", upstream.URL+"/orgs/test-org/outside_collaborators", nil)
+	req.Header.Set("Authorization", "token secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = resp.Body.Close()
+	do(t, client, "POST", upstream.URL+"/graphql", `{"query":"q","variables":{"user":"alice"}}`)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected fixture to be written, got %v", err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Error("Expected request headers not to be recorded")
+	}
+
+	server := NewServer(t, path)
+	replayClient := &http.Client{Transport: server.Transport()}
+	_, body := do(t, replayClient, "GET", "https://api.github.com/orgs/test-org/outside_collaborators", "")
+	if compact(body) != `[{"login":"alice"}]` {
+		t.Errorf("Expected recorded body, got %s", body)
+	}
+	_, body = do(t, replayClient, "POST", "https://api.github.com/graphql", `{"query":"q","variables":{"user":"alice"}}`)
+	if compact(body) != `{"data":{"ok":true}}` {
+		t.Errorf("Expected recorded GraphQL body, got %s", body)
+	}
+	server.AssertAllUsed()
+}
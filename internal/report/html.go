@@ -0,0 +1,102 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed templates/report.html
+var htmlTemplate string
+
+var reportHTML = template.Must(template.New("report").Parse(htmlTemplate))
+
+// Metadata describes how a report was generated.
+type Metadata struct {
+	Organization string    `json:"organization"`
+	Hostname     string    `json:"hostname"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	Version      string    `json:"version"`
+}
+
+type htmlCollaborator struct {
+	Name         string
+	Repositories int
+	Admin        int
+}
+
+type htmlRepository struct {
+	Name          string
+	Visibility    string
+	Collaborators int
+	Admin         int
+}
+
+// WriteHTML writes a self-contained HTML report, with the styles and the
+// script that sorts and filters its tables inline so it works offline.
+func WriteHTML(w io.Writer, rows []Row, metadata Metadata) error {
+	collaborators := map[string]*htmlCollaborator{}
+	repositories := map[string]*htmlRepository{}
+	for _, row := range rows {
+		admin := 0
+		if strings.EqualFold(row.Permission, "ADMIN") {
+			admin = 1
+		}
+		if collaborators[row.Username] == nil {
+			collaborators[row.Username] = &htmlCollaborator{Name: row.Username}
+		}
+		collaborators[row.Username].Repositories++
+		collaborators[row.Username].Admin += admin
+		if repositories[row.Repository] == nil {
+			repositories[row.Repository] = &htmlRepository{Name: row.Repository, Visibility: row.Visibility}
+		}
+		repositories[row.Repository].Collaborators++
+		repositories[row.Repository].Admin += admin
+	}
+
+	data := struct {
+		Metadata      Metadata
+		Summary       Summary
+		Rows          []Row
+		Collaborators []*htmlCollaborator
+		Repositories  []*htmlRepository
+	}{
+		Metadata: metadata,
+		Summary:  Summarize(rows, 0),
+		Rows:     rows,
+	}
+	for _, collaborator := range collaborators {
+		data.Collaborators = append(data.Collaborators, collaborator)
+	}
+	sort.Slice(data.Collaborators, func(i, j int) bool { return data.Collaborators[i].Name < data.Collaborators[j].Name })
+	for _, repository := range repositories {
+		data.Repositories = append(data.Repositories, repository)
+	}
+	sort.Slice(data.Repositories, func(i, j int) bool { return data.Repositories[i].Name < data.Repositories[j].Name })
+
+	return reportHTML.Execute(w, data)
+}
+
+// WriteHTMLFile writes the HTML report for the report at path next to it,
+// returning the path written.
+func WriteHTMLFile(path string, metadata Metadata) (string, error) {
+	rows, err := ReadCSV(path)
+	if err != nil {
+		return "", err
+	}
+	htmlPath := ReportPath(path, ".html")
+	file, err := os.OpenFile(htmlPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTML report: %w", err)
+	}
+	if err := WriteHTML(file, rows, metadata); err != nil {
+		_ = file.Close()
+		return "", fmt.Errorf("failed to write HTML report: %w", err)
+	}
+	return htmlPath, file.Close()
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTML(t *testing.T) {
+	rows := append(testRows(t), Row{Repository: "repo4", RepositoryID: "104", Visibility: "PUBLIC", Username: "<script>", Permission: "READ"})
+	metadata := Metadata{
+		Organization: "test-org",
+		Hostname:     "github.example.com",
+		GeneratedAt:  time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		Version:      "v1.2.3",
+	}
+
+	var b bytes.Buffer
+	if err := WriteHTML(&b, rows, metadata); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	html := b.String()
+
+	for _, expected := range []string{
+		"<title>Repository Collaborators Report: test-org</title>",
+		"<dt>Host</dt><dd>github.example.com</dd>",
+		"<dt>Generated</dt><dd>2026-10-18 12:00:00 UTC</dd>",
+		"gh-collaborators v1.2.3",
+		`<div class="value">4</div><div class="label">Outside collaborators</div>`,
+		`<td class="permission-admin">ADMIN</td>`,
+		`<tr><td>carol</td><td class="number">2</td><td class="number">1</td></tr>`,
+		`<tr><td>repo1</td><td>PRIVATE</td><td class="number">3</td><td class="number">1</td></tr>`,
+		`<table id="permissions">`,
+		`input.addEventListener("input"`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected HTML to contain %q", expected)
+		}
+	}
+
+	// Values from the report are escaped
+	if strings.Contains(html, "<td><script></td>") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Error("Expected usernames to be escaped")
+	}
+	// Nothing is loaded from the network
+	if strings.Contains(html, "http://") || strings.Contains(html, "https://") {
+		t.Error("Expected a self-contained report without external resources")
+	}
+}
+
+func TestWriteHTMLFile(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.csv")
+	content, err := os.ReadFile("testdata/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	htmlPath, err := WriteHTMLFile(reportPath, Metadata{Organization: "test-org"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if htmlPath != filepath.Join(filepath.Dir(reportPath), "report.html") {
+		t.Errorf("Expected HTML next to the report, got %s", htmlPath)
+	}
+	if _, err := os.Stat(htmlPath); err != nil {
+		t.Errorf("Expected HTML report to exist, got %v", err)
+	}
+
+	// An existing report is never overwritten
+	if _, err := WriteHTMLFile(reportPath, Metadata{Organization: "test-org"}); err == nil {
+		t.Error("Expected an error writing over an existing HTML report, got nil")
+	}
+}
@@ -0,0 +1,125 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// stepSummaryTop is how many repositories the condensed step summary lists
+const stepSummaryTop = 5
+
+// WriteMarkdown writes the report as markdown for GitHub issues and pull
+// requests: the summary followed by a collapsible section per collaborator
+// listing their repositories and permissions.
+func WriteMarkdown(w io.Writer, rows []Row, metadata Metadata) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Repository Collaborators Report: %s\n\n", markdownEscape(metadata.Organization))
+	writeMarkdownMetadata(&b, metadata)
+	if err := writeSummaryMarkdown(&b, Summarize(rows, DefaultTop)); err != nil {
+		return err
+	}
+
+	byUser := map[string][]Row{}
+	for _, row := range rows {
+		byUser[row.Username] = append(byUser[row.Username], row)
+	}
+	users := make([]string, 0, len(byUser))
+	for user := range byUser {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	b.WriteString("\n## Collaborators\n")
+	for _, user := range users {
+		userRows := byUser[user]
+		sort.Slice(userRows, func(i, j int) bool { return userRows[i].Repository < userRows[j].Repository })
+		admin := 0
+		for _, row := range userRows {
+			if strings.EqualFold(row.Permission, "ADMIN") {
+				admin++
+			}
+		}
+
+		fmt.Fprintf(&b, "\n<details>\n<summary><strong>%s</strong>: %s", html.EscapeString(user), plural(len(userRows), "repository", "repositories"))
+		if admin > 0 {
+			fmt.Fprintf(&b, " (%d admin)", admin)
+		}
+		b.WriteString("</summary>\n\n| Repository | Visibility | Permission |\n|:-----------|:-----------|:-----------|\n")
+		for _, row := range userRows {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownEscape(row.Repository), markdownEscape(row.Visibility), markdownEscape(row.Permission))
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteStepSummary writes the condensed summary added to a GitHub Actions
+// job, leaving out the per collaborator breakdown so large organizations
+// stay readable.
+func WriteStepSummary(w io.Writer, rows []Row, metadata Metadata) error {
+	var b strings.Builder
+	summary := Summarize(rows, stepSummaryTop)
+	summary.PerCollaborator = nil
+	if err := writeSummaryMarkdown(&b, summary); err != nil {
+		return err
+	}
+	b.WriteString("\n")
+	writeMarkdownMetadata(&b, metadata)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownMetadata(b *strings.Builder, metadata Metadata) {
+	fmt.Fprintf(b, "Generated for `%s` on `%s` at %s by gh-collaborators %s.\n\n",
+		metadata.Organization, metadata.Hostname, metadata.GeneratedAt.Format("2006-01-02 15:04:05 MST"), metadata.Version)
+}
+
+func plural(n int, singular string, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
+
+// WriteMarkdownFile writes the markdown report for the report at path next
+// to it, returning the path written.
+func WriteMarkdownFile(path string, metadata Metadata) (string, error) {
+	rows, err := ReadCSV(path)
+	if err != nil {
+		return "", err
+	}
+	markdownPath := ReportPath(path, ".md")
+	file, err := os.OpenFile(markdownPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create markdown report: %w", err)
+	}
+	if err := WriteMarkdown(file, rows, metadata); err != nil {
+		_ = file.Close()
+		return "", fmt.Errorf("failed to write markdown report: %w", err)
+	}
+	return markdownPath, file.Close()
+}
+
+// AppendStepSummary adds the condensed summary of the report at path to the
+// GitHub Actions step summary file, which other steps may also write to.
+func AppendStepSummary(summaryPath string, path string, metadata Metadata) error {
+	rows, err := ReadCSV(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(summaryPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open step summary: %w", err)
+	}
+	if err := WriteStepSummary(file, rows, metadata); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write step summary: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMetadata() Metadata {
+	return Metadata{
+		Organization: "test-org",
+		Hostname:     "github.com",
+		GeneratedAt:  time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		Version:      "v1.2.3",
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteMarkdown(&b, testRows(t), testMetadata()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	markdown := b.String()
+
+	for _, expected := range []string{
+		"# Repository Collaborators Report: test-org\n",
+		"Generated for `test-org` on `github.com` at 2026-10-18 12:00:00 UTC by gh-collaborators v1.2.3.",
+		"| Outside collaborators | 3 |",
+		"<details>\n<summary><strong>alice</strong>: 2 repositories (1 admin)</summary>",
+		"<summary><strong>bob</strong>: 1 repository</summary>",
+		"| repo1 | PRIVATE | WRITE |\n| repo3 | PUBLIC | ADMIN |\n",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Count(markdown, "<details>") != 3 || strings.Count(markdown, "</details>") != 3 {
+		t.Errorf("Expected a collapsible section per collaborator, got:\n%s", markdown)
+	}
+}
+
+func TestWriteStepSummary(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteStepSummary(&b, testRows(t), testMetadata()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	summary := b.String()
+
+	if !strings.Contains(summary, "| Outside collaborators | 3 |") || !strings.Contains(summary, "### Grants by Permission") {
+		t.Errorf("Expected summary tables, got:\n%s", summary)
+	}
+	if strings.Contains(summary, "Repositories per Collaborator") || strings.Contains(summary, "<details>") {
+		t.Errorf("Expected a condensed summary without per collaborator details, got:\n%s", summary)
+	}
+}
+
+func TestAppendStepSummary(t *testing.T) {
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "report.csv")
+	content, err := os.ReadFile("testdata/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	summaryPath := filepath.Join(dir, "step_summary.md")
+	if err := os.WriteFile(summaryPath, []byte("# Earlier step\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AppendStepSummary(summaryPath, reportPath, testMetadata()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	written, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(written), "# Earlier step\n") || !strings.Contains(string(written), "| Outside collaborators | 3 |") {
+		t.Errorf("Expected the summary appended after earlier steps, got:\n%s", written)
+	}
+
+	markdownPath, err := WriteMarkdownFile(reportPath, testMetadata())
+	if err != nil || markdownPath != filepath.Join(dir, "report.md") {
+		t.Errorf("Expected markdown report next to the CSV, got %s, %v", markdownPath, err)
+	}
+}
@@ -0,0 +1,172 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// MatrixOptions controls the layout of a permission matrix.
+type MatrixOptions struct {
+	// Transpose puts repositories in rows and collaborators in columns
+	Transpose bool
+	// DropEmpty leaves out repositories no outside collaborator can access
+	DropEmpty bool
+}
+
+// Matrix is a pivot of a report, by default one row per collaborator and one
+// column per repository with the permission in each cell.
+type Matrix struct {
+	Corner  string
+	Rows    []string
+	Columns []string
+	cells   map[string]map[string]string
+}
+
+// NewMatrix pivots rows into a matrix. repositories lists every repository
+// seen, including ones without outside collaborators, so they can be shown
+// as empty columns unless DropEmpty is set.
+func NewMatrix(rows []Row, repositories []string, opts MatrixOptions) *Matrix {
+	users := map[string]bool{}
+	repos := map[string]bool{}
+	if !opts.DropEmpty {
+		for _, repo := range repositories {
+			repos[repo] = true
+		}
+	}
+	cells := map[string]map[string]string{}
+	for _, row := range rows {
+		users[row.Username] = true
+		repos[row.Repository] = true
+		if cells[row.Username] == nil {
+			cells[row.Username] = map[string]string{}
+		}
+		cells[row.Username][row.Repository] = row.Permission
+	}
+
+	m := &Matrix{
+		Corner:  "Username",
+		Rows:    sortedKeys(users),
+		Columns: sortedKeys(repos),
+		cells:   cells,
+	}
+	if opts.Transpose {
+		m.Corner = "RepositoryName"
+		m.Rows, m.Columns = m.Columns, m.Rows
+	}
+	return m
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Cell returns the permission at a row and column, empty when there is none.
+func (m *Matrix) Cell(row string, column string) string {
+	if m.Corner == "RepositoryName" {
+		return m.cells[column][row]
+	}
+	return m.cells[row][column]
+}
+
+// Records returns the matrix as rows of cells, starting with the header.
+func (m *Matrix) Records() [][]string {
+	records := make([][]string, 0, len(m.Rows)+1)
+	records = append(records, append([]string{m.Corner}, m.Columns...))
+	for _, row := range m.Rows {
+		record := make([]string, 0, len(m.Columns)+1)
+		record = append(record, row)
+		for _, column := range m.Columns {
+			record = append(record, m.Cell(row, column))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func (m *Matrix) WriteCSV(w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.WriteAll(m.Records()); err != nil {
+		return err
+	}
+	return csvWriter.Error()
+}
+
+// WriteXLSX writes the matrix as a workbook with the header row and first
+// column frozen.
+func (m *Matrix) WriteXLSX(w io.Writer) error {
+	if err := m.checkXLSX(); err != nil {
+		return err
+	}
+	return writeXLSX(w, "Permissions", m.Records())
+}
+
+// checkXLSX returns an error when the matrix, with its header row and
+// column, is larger than a sheet can hold, suggesting the other layout when
+// the matrix would fit that way.
+func (m *Matrix) checkXLSX() error {
+	rows, columns := len(m.Rows)+1, len(m.Columns)+1
+	if rows <= xlsxMaxRows && columns <= xlsxMaxColumns {
+		return nil
+	}
+	err := fmt.Errorf("the matrix has %d rows and %d columns, an XLSX sheet holds at most %d rows and %d columns", rows, columns, xlsxMaxRows, xlsxMaxColumns)
+	if columns <= xlsxMaxRows && rows <= xlsxMaxColumns {
+		if m.Corner == "RepositoryName" {
+			return fmt.Errorf("%w, rerun without --transpose", err)
+		}
+		return fmt.Errorf("%w, rerun with --transpose", err)
+	}
+	return err
+}
+
+// MatrixPath returns where the matrix of a report is written, next to the
+// report with the given extension.
+func MatrixPath(reportPath string, ext string) string {
+	return ReportPath(reportPath, "-matrix"+ext)
+}
+
+// WriteMatrixFiles pivots the report at path and writes the matrix next to it
+// as CSV and XLSX, returning the paths written.
+func WriteMatrixFiles(path string, repositories []string, opts MatrixOptions) ([]string, error) {
+	rows, err := ReadCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	matrix := NewMatrix(rows, repositories, opts)
+	// Nothing is written for a matrix the workbook cannot hold
+	if err := matrix.checkXLSX(); err != nil {
+		return nil, err
+	}
+
+	writers := []struct {
+		ext   string
+		write func(io.Writer) error
+	}{
+		{".csv", matrix.WriteCSV},
+		{".xlsx", matrix.WriteXLSX},
+	}
+	var written []string
+	for _, writer := range writers {
+		matrixPath := MatrixPath(path, writer.ext)
+		file, err := os.OpenFile(matrixPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return written, fmt.Errorf("failed to create matrix file: %w", err)
+		}
+		if err := writer.write(file); err != nil {
+			_ = file.Close()
+			return written, fmt.Errorf("failed to write matrix file %s: %w", matrixPath, err)
+		}
+		if err := file.Close(); err != nil {
+			return written, err
+		}
+		written = append(written, matrixPath)
+	}
+	return written, nil
+}
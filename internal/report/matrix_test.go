@@ -0,0 +1,125 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testRows(t *testing.T) []Row {
+	t.Helper()
+	rows, err := ReadCSV("testdata/report.csv")
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	return rows
+}
+
+func TestNewMatrix(t *testing.T) {
+	matrix := NewMatrix(testRows(t), []string{"repo1", "repo4"}, MatrixOptions{})
+
+	expected := [][]string{
+		{"Username", "repo1", "repo2", "repo3", "repo4"},
+		{"alice", "WRITE", "", "ADMIN", ""},
+		{"bob", "READ", "", "", ""},
+		{"carol", "ADMIN", "READ", "", ""},
+	}
+	if records := matrix.Records(); !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected matrix %v, got %v", expected, records)
+	}
+}
+
+func TestNewMatrixTransposeDropEmpty(t *testing.T) {
+	matrix := NewMatrix(testRows(t), []string{"repo1", "repo4"}, MatrixOptions{Transpose: true, DropEmpty: true})
+
+	expected := [][]string{
+		{"RepositoryName", "alice", "bob", "carol"},
+		{"repo1", "WRITE", "READ", "ADMIN"},
+		{"repo2", "", "", "READ"},
+		{"repo3", "ADMIN", "", ""},
+	}
+	if records := matrix.Records(); !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected matrix %v, got %v", expected, records)
+	}
+	if cell := matrix.Cell("repo3", "alice"); cell != "ADMIN" {
+		t.Errorf("Expected ADMIN for alice on repo3, got %q", cell)
+	}
+}
+
+func TestMatrixWriteCSV(t *testing.T) {
+	matrix := NewMatrix(testRows(t), nil, MatrixOptions{})
+
+	var b bytes.Buffer
+	if err := matrix.WriteCSV(&b); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read matrix CSV: %v", err)
+	}
+	if !reflect.DeepEqual(records, matrix.Records()) {
+		t.Errorf("Expected CSV to match records, got %v", records)
+	}
+}
+
+func TestWriteMatrixFiles(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.csv")
+	content, err := os.ReadFile("testdata/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := WriteMatrixFiles(reportPath, nil, MatrixOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{MatrixPath(reportPath, ".csv"), MatrixPath(reportPath, ".xlsx")}
+	if !reflect.DeepEqual(written, expected) {
+		t.Errorf("Expected %v written, got %v", expected, written)
+	}
+	for _, path := range written {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to exist, got %v", path, err)
+		}
+	}
+}
+
+func TestMatrixXLSXLimits(t *testing.T) {
+	repositories := make([]string, xlsxMaxColumns)
+	for i := range repositories {
+		repositories[i] = fmt.Sprintf("repo%05d", i)
+	}
+
+	matrix := NewMatrix(testRows(t), repositories, MatrixOptions{})
+	err := matrix.WriteXLSX(io.Discard)
+	if err == nil || !strings.HasSuffix(err.Error(), "rerun with --transpose") {
+		t.Errorf("Expected too many columns to suggest --transpose, got %v", err)
+	}
+
+	// Repositories in rows fit
+	matrix = NewMatrix(testRows(t), repositories, MatrixOptions{Transpose: true})
+	if err := matrix.WriteXLSX(io.Discard); err != nil {
+		t.Errorf("Expected the transposed matrix to fit, got %v", err)
+	}
+
+	reportPath := filepath.Join(t.TempDir(), "report.csv")
+	content, err := os.ReadFile("testdata/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := WriteMatrixFiles(reportPath, repositories, MatrixOptions{}); err == nil || len(written) != 0 {
+		t.Errorf("Expected no matrix files for a matrix too wide, got %v, %v", written, err)
+	}
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ReportFormatCSV      = "csv"
+	ReportFormatMatrix   = "matrix"
+	ReportFormatHTML     = "html"
+	ReportFormatMarkdown = "markdown"
+)
+
+// ReportFormats are the outputs list can write, every format also writes
+// the CSV report since it is what an interrupted run resumes from.
+var ReportFormats = []string{ReportFormatCSV, ReportFormatMatrix, ReportFormatHTML, ReportFormatMarkdown}
+
+// ValidateReportFormat checks format is one list can write.
+func ValidateReportFormat(format string) error {
+	for _, known := range ReportFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown report format %q, expected one of %s", format, strings.Join(ReportFormats, ", "))
+}
+
+// Header is the first row of the CSV report written by list.
+var Header = []string{
+	"RepositoryName",
+	"RepositoryID",
+	"Visibility",
+	"Username",
+	"AccessLevel",
+}
+
+// Row is one collaborator's permission on one repository.
+type Row struct {
+	Repository   string `json:"repository"`
+	RepositoryID string `json:"repository_id"`
+	Visibility   string `json:"visibility"`
+	Username     string `json:"username"`
+	Permission   string `json:"permission"`
+}
+
+func (r Row) Record() []string {
+	return []string{r.Repository, r.RepositoryID, r.Visibility, r.Username, r.Permission}
+}
+
+// ReportPath returns the path of another format of the report at
+// reportPath, such as the HTML report next to the CSV.
+func ReportPath(reportPath string, ext string) string {
+	return strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + ext
+}
+
+// FormatPaths returns the files a report format writes next to the CSV
+// report at reportPath, none for the CSV format.
+func FormatPaths(reportPath string, format string) []string {
+	switch format {
+	case ReportFormatMatrix:
+		return []string{MatrixPath(reportPath, ".csv"), MatrixPath(reportPath, ".xlsx")}
+	case ReportFormatHTML:
+		return []string{ReportPath(reportPath, ".html")}
+	case ReportFormatMarkdown:
+		return []string{ReportPath(reportPath, ".md")}
+	default:
+		return nil
+	}
+}
+
+// ReadCSV reads the rows of a report written by list.
+func ReadCSV(path string) ([]Row, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open report %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+	return Read(file)
+}
+
+// Read parses a list report, checking its header so other CSV files are not
+// mistaken for one.
+func Read(r io.Reader) ([]Row, error) {
+	csvReader := csv.NewReader(r)
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("report is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	if strings.Join(header, ",") != strings.Join(Header, ",") {
+		return nil, fmt.Errorf("unexpected report header %q, expected %q", strings.Join(header, ","), strings.Join(Header, ","))
+	}
+
+	var rows []Row
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read report: %w", err)
+		}
+		rows = append(rows, Row{
+			Repository:   record[0],
+			RepositoryID: record[1],
+			Visibility:   record[2],
+			Username:     record[3],
+			Permission:   record[4],
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,61 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	rows, err := ReadCSV("testdata/report.csv")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("Expected 5 rows, got %d", len(rows))
+	}
+	expected := Row{Repository: "repo1", RepositoryID: "101", Visibility: "PRIVATE", Username: "alice", Permission: "WRITE"}
+	if rows[0] != expected {
+		t.Errorf("Expected first row %+v, got %+v", expected, rows[0])
+	}
+	if !reflect.DeepEqual(rows[0].Record(), []string{"repo1", "101", "PRIVATE", "alice", "WRITE"}) {
+		t.Errorf("Unexpected record %v", rows[0].Record())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := map[string]string{
+		"":                                   "report is empty",
+		"RepositoryName,Username,Permission": "unexpected report header",
+		strings.Join(Header, ",") + "\nrepo1,101": "failed to read report",
+	}
+	for input, expected := range tests {
+		if _, err := Read(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q for %q, got %v", expected, input, err)
+		}
+	}
+
+	if _, err := ReadCSV("testdata/missing.csv"); err == nil || !strings.Contains(err.Error(), "failed to open report") {
+		t.Errorf("Expected open error, got %v", err)
+	}
+}
+
+func TestValidateReportFormat(t *testing.T) {
+	for _, format := range ReportFormats {
+		if err := ValidateReportFormat(format); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", format, err)
+		}
+	}
+	if err := ValidateReportFormat("pdf"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestReportPath(t *testing.T) {
+	if got := ReportPath("out/report.csv", ".html"); got != "out/report.html" {
+		t.Errorf("Expected out/report.html, got %s", got)
+	}
+	if got := ReportPath("report", "-matrix.csv"); got != "report-matrix.csv" {
+		t.Errorf("Expected report-matrix.csv, got %s", got)
+	}
+}
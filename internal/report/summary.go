@@ -0,0 +1,238 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+
+	// DefaultTop is how many repositories are listed as having the most
+	// outside collaborators
+	DefaultTop = 10
+)
+
+var SummaryFormats = []string{FormatTable, FormatJSON, FormatMarkdown}
+
+// Summary aggregates a list report.
+type Summary struct {
+	Collaborators      int     `json:"collaborators"`
+	Repositories       int     `json:"repositories"`
+	Grants             int     `json:"grants"`
+	AdminGrants        int     `json:"admin_grants"`
+	AdminCollaborators int     `json:"admin_collaborators"`
+	Permissions        []Count `json:"permissions"`
+	PerCollaborator    []Count `json:"repositories_per_collaborator"`
+	TopRepositories    []Count `json:"top_repositories"`
+}
+
+// Count is a name with how many grants, repositories or collaborators it
+// has, depending on the list it is in.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Summarize counts collaborators, repositories and grants in rows. Only the
+// top repositories by number of outside collaborators are kept.
+func Summarize(rows []Row, top int) Summary {
+	permissions := map[string]int{}
+	perUser := map[string]map[string]bool{}
+	perRepo := map[string]map[string]bool{}
+	admins := map[string]bool{}
+
+	summary := Summary{Grants: len(rows)}
+	for _, row := range rows {
+		permission := strings.ToUpper(row.Permission)
+		permissions[permission]++
+		if permission == "ADMIN" {
+			summary.AdminGrants++
+			admins[row.Username] = true
+		}
+		if perUser[row.Username] == nil {
+			perUser[row.Username] = map[string]bool{}
+		}
+		perUser[row.Username][row.Repository] = true
+		if perRepo[row.Repository] == nil {
+			perRepo[row.Repository] = map[string]bool{}
+		}
+		perRepo[row.Repository][row.Username] = true
+	}
+
+	summary.Collaborators = len(perUser)
+	summary.Repositories = len(perRepo)
+	summary.AdminCollaborators = len(admins)
+	summary.Permissions = sortedCounts(permissions)
+	summary.PerCollaborator = sortedCounts(setSizes(perUser))
+	summary.TopRepositories = sortedCounts(setSizes(perRepo))
+	if top > 0 && len(summary.TopRepositories) > top {
+		summary.TopRepositories = summary.TopRepositories[:top]
+	}
+	return summary
+}
+
+func setSizes(sets map[string]map[string]bool) map[string]int {
+	sizes := make(map[string]int, len(sets))
+	for name, set := range sets {
+		sizes[name] = len(set)
+	}
+	return sizes
+}
+
+// sortedCounts orders counts largest first, ties by name so output is stable.
+func sortedCounts(counts map[string]int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, Count{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// SummaryPath returns where the summary of a report is saved, next to the
+// report itself.
+func SummaryPath(reportPath string, format string) string {
+	ext := ".txt"
+	switch format {
+	case FormatJSON:
+		ext = ".json"
+	case FormatMarkdown:
+		ext = ".md"
+	}
+	return ReportPath(reportPath, "-summary"+ext)
+}
+
+// ValidateSummaryFormat checks format is one WriteSummary supports.
+func ValidateSummaryFormat(format string) error {
+	for _, known := range SummaryFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown summary format %q, expected one of %s", format, strings.Join(SummaryFormats, ", "))
+}
+
+// SummarizeReport reads the report at path and writes its summary to out.
+// When save is set the summary is also written next to the report, and the
+// path it was saved to is returned.
+func SummarizeReport(path string, format string, top int, save bool, out io.Writer) (string, error) {
+	if err := ValidateSummaryFormat(format); err != nil {
+		return "", err
+	}
+	rows, err := ReadCSV(path)
+	if err != nil {
+		return "", err
+	}
+	summary := Summarize(rows, top)
+	if err := WriteSummary(out, summary, format); err != nil {
+		return "", err
+	}
+	if !save {
+		return "", nil
+	}
+
+	summaryPath := SummaryPath(path, format)
+	file, err := os.Create(summaryPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create summary file: %w", err)
+	}
+	if err := WriteSummary(file, summary, format); err != nil {
+		_ = file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	return summaryPath, nil
+}
+
+// WriteSummary writes the summary as a table, JSON or markdown.
+func WriteSummary(w io.Writer, summary Summary, format string) error {
+	switch format {
+	case FormatTable:
+		return writeSummaryTable(w, summary)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	case FormatMarkdown:
+		return writeSummaryMarkdown(w, summary)
+	default:
+		return ValidateSummaryFormat(format)
+	}
+}
+
+func writeSummaryTable(w io.Writer, summary Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Outside collaborators:\t%d\n", summary.Collaborators)
+	_, _ = fmt.Fprintf(tw, "Repositories:\t%d\n", summary.Repositories)
+	_, _ = fmt.Fprintf(tw, "Permission grants:\t%d\n", summary.Grants)
+	_, _ = fmt.Fprintf(tw, "Collaborators with admin:\t%d (%d grants)\n", summary.AdminCollaborators, summary.AdminGrants)
+
+	sections := []struct {
+		name   string
+		column string
+		counts []Count
+	}{
+		{"PERMISSION", "GRANTS", summary.Permissions},
+		{"COLLABORATOR", "REPOSITORIES", summary.PerCollaborator},
+		{"REPOSITORY", "COLLABORATORS", summary.TopRepositories},
+	}
+	for _, section := range sections {
+		_, _ = fmt.Fprintf(tw, "\n%s\t%s\n", section.name, section.column)
+		for _, count := range section.counts {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", count.Name, count.Count)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeSummaryMarkdown(w io.Writer, summary Summary) error {
+	var b strings.Builder
+	b.WriteString("## Repository Collaborators Summary\n\n")
+	b.WriteString("| Metric | Value |\n|:-------|------:|\n")
+	fmt.Fprintf(&b, "| Outside collaborators | %d |\n", summary.Collaborators)
+	fmt.Fprintf(&b, "| Repositories | %d |\n", summary.Repositories)
+	fmt.Fprintf(&b, "| Permission grants | %d |\n", summary.Grants)
+	fmt.Fprintf(&b, "| Collaborators with admin | %d |\n", summary.AdminCollaborators)
+	fmt.Fprintf(&b, "| Admin grants | %d |\n", summary.AdminGrants)
+
+	sections := []struct {
+		title  string
+		name   string
+		column string
+		counts []Count
+	}{
+		{"Grants by Permission", "Permission", "Grants", summary.Permissions},
+		{"Repositories per Collaborator", "Collaborator", "Repositories", summary.PerCollaborator},
+		{"Repositories with the Most Outside Collaborators", "Repository", "Collaborators", summary.TopRepositories},
+	}
+	for _, section := range sections {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n| %s | %s |\n|:---|---:|\n", section.title, section.name, section.column)
+		for _, count := range section.counts {
+			fmt.Fprintf(&b, "| %s | %d |\n", markdownEscape(count.Name), count.Count)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,143 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSummary(t *testing.T, top int) Summary {
+	t.Helper()
+	rows, err := ReadCSV("testdata/report.csv")
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	return Summarize(rows, top)
+}
+
+func TestSummarize(t *testing.T) {
+	summary := testSummary(t, DefaultTop)
+
+	if summary.Collaborators != 3 || summary.Repositories != 3 || summary.Grants != 5 {
+		t.Errorf("Unexpected totals %+v", summary)
+	}
+	if summary.AdminGrants != 2 || summary.AdminCollaborators != 2 {
+		t.Errorf("Expected 2 admin grants to 2 collaborators, got %d and %d", summary.AdminGrants, summary.AdminCollaborators)
+	}
+
+	expectedPermissions := []Count{{"ADMIN", 2}, {"READ", 2}, {"WRITE", 1}}
+	if !reflect.DeepEqual(summary.Permissions, expectedPermissions) {
+		t.Errorf("Expected permissions %v, got %v", expectedPermissions, summary.Permissions)
+	}
+	expectedPerCollaborator := []Count{{"alice", 2}, {"carol", 2}, {"bob", 1}}
+	if !reflect.DeepEqual(summary.PerCollaborator, expectedPerCollaborator) {
+		t.Errorf("Expected per collaborator %v, got %v", expectedPerCollaborator, summary.PerCollaborator)
+	}
+	expectedTop := []Count{{"repo1", 3}, {"repo2", 1}, {"repo3", 1}}
+	if !reflect.DeepEqual(summary.TopRepositories, expectedTop) {
+		t.Errorf("Expected top repositories %v, got %v", expectedTop, summary.TopRepositories)
+	}
+}
+
+func TestSummarizeTop(t *testing.T) {
+	summary := testSummary(t, 1)
+	if len(summary.TopRepositories) != 1 || summary.TopRepositories[0].Name != "repo1" {
+		t.Errorf("Expected only repo1, got %v", summary.TopRepositories)
+	}
+	// Totals still cover every repository
+	if summary.Repositories != 3 {
+		t.Errorf("Expected 3 repositories, got %d", summary.Repositories)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	summary := testSummary(t, DefaultTop)
+
+	var table bytes.Buffer
+	if err := WriteSummary(&table, summary, FormatTable); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, expected := range []string{"Outside collaborators:     3", "Collaborators with admin:  2 (2 grants)", "REPOSITORY  COLLABORATORS", "repo1       3"} {
+		if !strings.Contains(table.String(), expected) {
+			t.Errorf("Expected table to contain %q, got:\n%s", expected, table.String())
+		}
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteSummary(&markdown, summary, FormatMarkdown); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, expected := range []string{"| Outside collaborators | 3 |", "### Grants by Permission", "| alice | 2 |"} {
+		if !strings.Contains(markdown.String(), expected) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", expected, markdown.String())
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := WriteSummary(&encoded, summary, FormatJSON); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded Summary
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON summary: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, summary) {
+		t.Errorf("Expected JSON to round trip, got %+v", decoded)
+	}
+
+	if err := WriteSummary(&encoded, summary, "yaml"); err == nil || !strings.Contains(err.Error(), "unknown summary format") {
+		t.Errorf("Expected unknown format error, got %v", err)
+	}
+}
+
+func TestSummaryPath(t *testing.T) {
+	tests := map[string]string{
+		FormatTable:    "out/report-summary.txt",
+		FormatJSON:     "out/report-summary.json",
+		FormatMarkdown: "out/report-summary.md",
+	}
+	for format, expected := range tests {
+		if got := SummaryPath("out/report.csv", format); got != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, format, got)
+		}
+	}
+}
+
+func TestSummarizeReport(t *testing.T) {
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "report.csv")
+	content, err := os.ReadFile("testdata/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	saved, err := SummarizeReport(reportPath, FormatMarkdown, DefaultTop, true, &out)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if saved != filepath.Join(dir, "report-summary.md") {
+		t.Errorf("Expected summary saved next to the report, got %s", saved)
+	}
+	written, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatalf("Failed to read saved summary: %v", err)
+	}
+	if string(written) != out.String() {
+		t.Errorf("Expected saved summary to match printed summary")
+	}
+
+	if saved, err := SummarizeReport(reportPath, FormatTable, DefaultTop, false, &out); err != nil || saved != "" {
+		t.Errorf("Expected nothing saved, got %q, %v", saved, err)
+	}
+	if _, err := SummarizeReport(reportPath, "yaml", DefaultTop, false, &out); err == nil {
+		t.Error("Expected unknown format error")
+	}
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXLSXColumn(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, expected := range tests {
+		if got := xlsxColumn(index); got != expected {
+			t.Errorf("Expected column %d to be %s, got %s", index, expected, got)
+		}
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var b bytes.Buffer
+	records := [][]string{
+		{"Username", "repo1", "repo<2>"},
+		{"alice", "WRITE", ""},
+	}
+	if err := writeXLSX(&b, "Permissions", records); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("Expected a valid zip archive, got %v", err)
+	}
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		_ = reader.Close()
+		parts[file.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Expected part %s in workbook", name)
+		}
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, expected := range []string{
+		`<pane xSplit="1" ySplit="1" topLeftCell="B2" activePane="bottomRight" state="frozen"/>`,
+		`<c r="A1" t="inlineStr"><is><t>Username</t></is></c>`,
+		`<c r="C1" t="inlineStr"><is><t>repo&lt;2&gt;</t></is></c>`,
+		`<c r="B2" t="inlineStr"><is><t>WRITE</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Errorf("Expected sheet to contain %s, got:\n%s", expected, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="C2"`) {
+		t.Error("Expected empty cells to be left out")
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="Permissions"`) {
+		t.Errorf("Expected sheet name in workbook, got %s", parts["xl/workbook.xml"])
+	}
+}
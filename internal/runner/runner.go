@@ -0,0 +1,71 @@
+// Package runner applies a batch of collaborator changes for a command,
+// journaling each one and reporting the outcome the same way everywhere.
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/notify"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+// Run holds what a command needs to apply and report its changes. Journal
+// and Notifier may be nil, for runs that are not journaled or notified.
+type Run struct {
+	// Command names the command in messages, such as "add"
+	Command  string
+	Owner    string
+	Exec     *utils.Executor
+	Journal  *journal.Journal
+	Notifier *notify.Sender
+	Out      io.Writer
+}
+
+// Apply calls task for each of the n rows through the executor, journaling
+// every result as it completes. row names the repository and user of a row,
+// reported for rows that are never started. A change that cannot be journaled cannot be
+// rolled back, so the first failure to record one stops the run: rows in
+// progress finish, no new rows are started. The results are returned with
+// that failure, to be reported.
+func (r *Run) Apply(ctx context.Context, n int, row func(i int) data.MutationResult, task func(i int) data.MutationResult) ([]data.MutationResult, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var recordErr error
+	results := r.Exec.Run(runCtx, n, row, task, func(result data.MutationResult) {
+		if err := r.Journal.Record(result); err != nil && recordErr == nil {
+			recordErr = err
+			cancel()
+		}
+	})
+	return results, recordErr
+}
+
+// Report writes the summary of results and the run ID to undo them with,
+// then sends the notifications. An interrupted or stopped run still
+// reports the changes it made before returning why it ended early.
+func (r *Run) Report(ctx context.Context, results []data.MutationResult, recordErr error) error {
+	utils.WriteResultsSummary(r.Out, results)
+	digest := notify.Digest{Command: r.Command, Org: r.Owner, Results: results}
+	if r.Journal != nil {
+		digest.RunID = r.Journal.RunID
+	}
+	if r.Journal.Undoable() {
+		_, _ = fmt.Fprintf(r.Out, "Run ID: %s, undo with: gh collaborators rollback %s\n", r.Journal.RunID, r.Journal.RunID)
+	}
+
+	if err := r.Notifier.Send(context.WithoutCancel(ctx), digest); err != nil {
+		_, _ = fmt.Fprintf(r.Out, "Failed to send notifications: %v\n", err)
+	}
+	if recordErr != nil {
+		return fmt.Errorf("%s stopped after failing to journal a change: %w", r.Command, recordErr)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s interrupted: %w", r.Command, ctx.Err())
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+func added(i int) data.MutationResult {
+	return data.MutationResult{RepositoryName: "repo1", Username: []string{"alice", "bob", "carol"}[i], Permission: "push", Status: utils.StatusAdded}
+}
+
+func TestApplyStopsOnRecordFailure(t *testing.T) {
+	j, err := journal.Open(t.TempDir(), "add", "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = j.Close() }()
+	j.OnRecord(func(journal.Entry) error { return errors.New("disk full") })
+
+	var out bytes.Buffer
+	run := &Run{Command: "add", Owner: "test-org", Exec: utils.NewExecutor(utils.ExecutorOptions{Concurrency: 1}), Journal: j, Out: &out}
+	var attempted int
+	results, recordErr := run.Apply(context.Background(), 3, added, func(i int) data.MutationResult {
+		attempted++
+		return added(i)
+	})
+	if recordErr == nil || attempted != 1 {
+		t.Fatalf("Expected the run to stop after the first row, got %d rows and %v", attempted, recordErr)
+	}
+
+	// The change already made is still reported
+	err = run.Report(context.Background(), results, recordErr)
+	if err == nil || err.Error() != "add stopped after failing to journal a change: disk full" {
+		t.Errorf("Expected the record failure, got %v", err)
+	}
+	for _, expected := range []string{
+		"Summary: 1 added, 2 failed",
+		"failed: bob on repo1 (not attempted: context canceled)",
+		"Run ID: " + j.RunID,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestReportInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	run := &Run{Command: "remove", Owner: "test-org", Exec: utils.NewExecutor(utils.ExecutorOptions{}), Out: &out}
+	results, recordErr := run.Apply(ctx, 2, added, added)
+	err := run.Report(ctx, results, recordErr)
+	if err == nil || !errors.Is(err, context.Canceled) || !strings.HasPrefix(err.Error(), "remove interrupted") {
+		t.Errorf("Expected the run to be interrupted, got %v", err)
+	}
+	// Without a journal there is no run to undo
+	if !strings.Contains(out.String(), "Summary: 2 failed") || strings.Contains(out.String(), "Run ID") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,93 @@
+package tui
+
+import "unicode/utf8"
+
+type KeyType int
+
+const (
+	KeyRune KeyType = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPageUp
+	KeyPageDown
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyCtrlC
+)
+
+// Key is one key press, Rune is set for KeyRune.
+type Key struct {
+	Type KeyType
+	Rune rune
+}
+
+// escapeSequences are the ANSI sequences terminals send for special keys
+var escapeSequences = map[string]KeyType{
+	"\x1b[A":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1b[C":  KeyRight,
+	"\x1b[D":  KeyLeft,
+	"\x1bOA":  KeyUp,
+	"\x1bOB":  KeyDown,
+	"\x1bOC":  KeyRight,
+	"\x1bOD":  KeyLeft,
+	"\x1b[5~": KeyPageUp,
+	"\x1b[6~": KeyPageDown,
+}
+
+// ParseKeys decodes the bytes read from a terminal in raw mode. A lone
+// escape byte is the escape key, unknown sequences are dropped.
+func ParseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		switch b[0] {
+		case 0x03:
+			keys = append(keys, Key{Type: KeyCtrlC})
+			b = b[1:]
+			continue
+		case '\r', '\n':
+			keys = append(keys, Key{Type: KeyEnter})
+			b = b[1:]
+			continue
+		case 0x7f, 0x08:
+			keys = append(keys, Key{Type: KeyBackspace})
+			b = b[1:]
+			continue
+		case 0x1b:
+			keyType, size, ok := parseEscape(b)
+			if ok {
+				keys = append(keys, Key{Type: keyType})
+			}
+			b = b[size:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		if r >= ' ' {
+			keys = append(keys, Key{Type: KeyRune, Rune: r})
+		}
+		b = b[size:]
+	}
+	return keys
+}
+
+// parseEscape returns the key starting at an escape byte and its length,
+// ok is false for an unknown sequence, which is skipped.
+func parseEscape(b []byte) (KeyType, int, bool) {
+	if len(b) == 1 || (b[1] != '[' && b[1] != 'O') {
+		return KeyEscape, 1, true
+	}
+	for sequence, keyType := range escapeSequences {
+		if len(b) >= len(sequence) && string(b[:len(sequence)]) == sequence {
+			return keyType, len(sequence), true
+		}
+	}
+	// Skip up to the final byte of the unknown sequence
+	end := 2
+	for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+		end++
+	}
+	return KeyEscape, min(end+1, len(b)), false
+}
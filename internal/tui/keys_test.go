@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		keys  []Key
+	}{
+		{name: "runes", input: "jé", keys: []Key{{Type: KeyRune, Rune: 'j'}, {Type: KeyRune, Rune: 'é'}}},
+		{name: "arrows", input: "\x1b[A\x1b[B\x1bOC\x1b[D", keys: []Key{{Type: KeyUp}, {Type: KeyDown}, {Type: KeyRight}, {Type: KeyLeft}}},
+		{name: "pages", input: "\x1b[5~\x1b[6~", keys: []Key{{Type: KeyPageUp}, {Type: KeyPageDown}}},
+		{name: "controls", input: "\r\x7f\x03", keys: []Key{{Type: KeyEnter}, {Type: KeyBackspace}, {Type: KeyCtrlC}}},
+		{name: "lone escape", input: "\x1b", keys: []Key{{Type: KeyEscape}}},
+		{name: "escape then rune", input: "\x1bq", keys: []Key{{Type: KeyEscape}, {Type: KeyRune, Rune: 'q'}}},
+		{name: "unknown sequence skipped", input: "\x1b[1;5Aj", keys: []Key{{Type: KeyRune, Rune: 'j'}}},
+		{name: "other controls dropped", input: "\x01", keys: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if keys := ParseKeys([]byte(tt.input)); !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("Expected %v, got %v", tt.keys, keys)
+			}
+		})
+	}
+}
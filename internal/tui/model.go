@@ -0,0 +1,424 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/report"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+type view int
+
+const (
+	viewCollaborators view = iota
+	viewRepositories
+	viewPlan
+)
+
+// headerLines and footerLines frame the scrolling list in every view
+const (
+	headerLines = 3
+	footerLines = 3
+)
+
+// permissionKeys set a grant to a built in role in the repositories view
+var permissionKeys = map[rune]string{
+	'1': "read",
+	'2': "triage",
+	'3': "write",
+	'4': "maintain",
+	'5': "admin",
+}
+
+// permissionFilters are cycled through to show only grants of one role
+var permissionFilters = []string{"", "admin", "maintain", "write", "triage", "read"}
+
+// Change is a grant marked for removal, when To is none, or for a new
+// permission.
+type Change struct {
+	Repository string
+	Username   string
+	From       string
+	To         string
+}
+
+func (c Change) Removal() bool {
+	return c.To == utils.PermissionNone
+}
+
+func (c Change) String() string {
+	if c.Removal() {
+		return fmt.Sprintf("remove %s from %s (%s)", c.Username, c.Repository, c.From)
+	}
+	return fmt.Sprintf("change %s on %s from %s to %s", c.Username, c.Repository, c.From, c.To)
+}
+
+// Model is the state of the review screen, updated a key at a time so it
+// can be driven without a terminal.
+type Model struct {
+	// Height is the number of terminal rows available
+	Height int
+
+	title      string
+	grants     map[string][]report.Row
+	users      []string
+	view       view
+	userCursor int
+	repoCursor int
+	planCursor int
+	user       string
+	search     string
+	searching  bool
+	filter     int
+	changes    map[string]Change
+	apply      bool
+	message    string
+}
+
+// NewModel starts the review of the rows of a list report on the
+// collaborators view.
+func NewModel(title string, rows []report.Row) *Model {
+	m := &Model{
+		Height:  24,
+		title:   title,
+		grants:  map[string][]report.Row{},
+		changes: map[string]Change{},
+	}
+	for _, row := range rows {
+		m.grants[row.Username] = append(m.grants[row.Username], row)
+	}
+	for user, userRows := range m.grants {
+		m.users = append(m.users, user)
+		sort.Slice(userRows, func(i, j int) bool { return userRows[i].Repository < userRows[j].Repository })
+	}
+	sort.Strings(m.users)
+	return m
+}
+
+// Apply reports whether the plan was confirmed.
+func (m *Model) Apply() bool {
+	return m.apply
+}
+
+// Changes returns the marked changes by collaborator then repository.
+func (m *Model) Changes() []Change {
+	changes := make([]Change, 0, len(m.changes))
+	for _, change := range m.changes {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Username != changes[j].Username {
+			return changes[i].Username < changes[j].Username
+		}
+		return changes[i].Repository < changes[j].Repository
+	})
+	return changes
+}
+
+// Update handles a key press, returning true once the review is over,
+// either confirmed or quit.
+func (m *Model) Update(key Key) bool {
+	m.message = ""
+	if key.Type == KeyCtrlC {
+		return true
+	}
+	if m.searching {
+		m.updateSearch(key)
+		return false
+	}
+
+	switch m.view {
+	case viewCollaborators:
+		return m.updateCollaborators(key)
+	case viewRepositories:
+		return m.updateRepositories(key)
+	case viewPlan:
+		return m.updatePlan(key)
+	}
+	return false
+}
+
+func (m *Model) updateSearch(key Key) {
+	switch key.Type {
+	case KeyEnter:
+		m.searching = false
+	case KeyEscape:
+		m.searching = false
+		m.search = ""
+	case KeyBackspace:
+		if len(m.search) > 0 {
+			runes := []rune(m.search)
+			m.search = string(runes[:len(runes)-1])
+		}
+	case KeyRune:
+		m.search += string(key.Rune)
+	}
+	m.userCursor = min(m.userCursor, max(len(m.visibleUsers())-1, 0))
+	m.repoCursor = min(m.repoCursor, max(len(m.visibleGrants())-1, 0))
+}
+
+func (m *Model) updateCollaborators(key Key) bool {
+	users := m.visibleUsers()
+	if m.moveCursor(key, &m.userCursor, len(users)) {
+		return false
+	}
+	switch {
+	case key.Type == KeyEnter || key.Type == KeyRight || key.Rune == 'l':
+		if len(users) > 0 {
+			m.user = users[m.userCursor]
+			m.view = viewRepositories
+			m.repoCursor = 0
+			m.search = ""
+		}
+	case key.Rune == '/':
+		m.searching = true
+	case key.Rune == 'f':
+		m.nextFilter()
+	case key.Rune == 'p':
+		m.openPlan()
+	case key.Rune == 'q' || key.Type == KeyEscape:
+		return true
+	}
+	return false
+}
+
+func (m *Model) updateRepositories(key Key) bool {
+	grants := m.visibleGrants()
+	if m.moveCursor(key, &m.repoCursor, len(grants)) {
+		return false
+	}
+	switch {
+	case key.Type == KeyEscape || key.Type == KeyLeft || key.Rune == 'h':
+		m.view = viewCollaborators
+		m.search = ""
+	case key.Rune == '/':
+		m.searching = true
+	case key.Rune == 'f':
+		m.nextFilter()
+	case key.Rune == 'p':
+		m.openPlan()
+	case key.Rune == 'q':
+		return true
+	case len(grants) == 0:
+		return false
+	case key.Rune == 'd':
+		m.mark(grants[m.repoCursor], utils.PermissionNone)
+	case key.Rune == 'u':
+		delete(m.changes, changeKey(grants[m.repoCursor]))
+	case permissionKeys[key.Rune] != "":
+		m.mark(grants[m.repoCursor], permissionKeys[key.Rune])
+	}
+	return false
+}
+
+func (m *Model) updatePlan(key Key) bool {
+	changes := m.Changes()
+	if m.moveCursor(key, &m.planCursor, len(changes)) {
+		return false
+	}
+	switch {
+	case key.Rune == 'y':
+		m.apply = true
+		return true
+	case key.Rune == 'u' && len(changes) > 0:
+		delete(m.changes, changes[m.planCursor].key())
+		m.planCursor = min(m.planCursor, max(len(m.changes)-1, 0))
+		if len(m.changes) == 0 {
+			m.view = viewCollaborators
+		}
+	case key.Rune == 'n' || key.Type == KeyEscape:
+		m.view = viewCollaborators
+	case key.Rune == 'q':
+		return true
+	}
+	return false
+}
+
+func (m *Model) moveCursor(key Key, cursor *int, length int) bool {
+	page := max(m.listHeight(), 1)
+	switch {
+	case key.Type == KeyUp || key.Rune == 'k':
+		*cursor--
+	case key.Type == KeyDown || key.Rune == 'j':
+		*cursor++
+	case key.Type == KeyPageUp:
+		*cursor -= page
+	case key.Type == KeyPageDown:
+		*cursor += page
+	default:
+		return false
+	}
+	*cursor = max(min(*cursor, length-1), 0)
+	return true
+}
+
+func (m *Model) nextFilter() {
+	m.filter = (m.filter + 1) % len(permissionFilters)
+	m.userCursor = 0
+	m.repoCursor = 0
+}
+
+func (m *Model) openPlan() {
+	if len(m.changes) == 0 {
+		m.message = "No changes marked yet"
+		return
+	}
+	m.view = viewPlan
+	m.planCursor = 0
+	m.search = ""
+}
+
+// mark records a change of the grant to permission, marking the grant's
+// current permission clears it.
+func (m *Model) mark(row report.Row, permission string) {
+	current := utils.NormalizePermission(row.Permission)
+	key := changeKey(row)
+	if existing, ok := m.changes[key]; (ok && existing.To == permission) || current == permission {
+		delete(m.changes, key)
+		return
+	}
+	m.changes[key] = Change{Repository: row.Repository, Username: row.Username, From: current, To: permission}
+}
+
+func changeKey(row report.Row) string {
+	return row.Username + "/" + row.Repository
+}
+
+func (c Change) key() string {
+	return c.Username + "/" + c.Repository
+}
+
+func (m *Model) matchesFilter(row report.Row) bool {
+	filter := permissionFilters[m.filter]
+	return filter == "" || utils.NormalizePermission(row.Permission) == filter
+}
+
+func (m *Model) visibleUsers() []string {
+	var users []string
+	for _, user := range m.users {
+		if m.view == viewCollaborators && !strings.Contains(strings.ToLower(user), strings.ToLower(m.search)) {
+			continue
+		}
+		for _, row := range m.grants[user] {
+			if m.matchesFilter(row) {
+				users = append(users, user)
+				break
+			}
+		}
+	}
+	return users
+}
+
+func (m *Model) visibleGrants() []report.Row {
+	var grants []report.Row
+	for _, row := range m.grants[m.user] {
+		if m.view == viewRepositories && !strings.Contains(strings.ToLower(row.Repository), strings.ToLower(m.search)) {
+			continue
+		}
+		if m.matchesFilter(row) {
+			grants = append(grants, row)
+		}
+	}
+	return grants
+}
+
+func (m *Model) listHeight() int {
+	return m.Height - headerLines - footerLines
+}
+
+// View renders the current screen as lines separated by newlines.
+func (m *Model) View() string {
+	var lines []string
+	var cursor int
+	var title, help string
+	switch m.view {
+	case viewCollaborators:
+		users := m.visibleUsers()
+		title = fmt.Sprintf("%s: %d collaborators", m.title, len(users))
+		for _, user := range users {
+			lines = append(lines, m.userLine(user))
+		}
+		cursor = m.userCursor
+		help = "↑/↓ move  enter open  / search  f filter  p plan  q quit"
+	case viewRepositories:
+		grants := m.visibleGrants()
+		title = fmt.Sprintf("%s: %d repositories", m.user, len(grants))
+		for _, row := range grants {
+			lines = append(lines, m.grantLine(row))
+		}
+		cursor = m.repoCursor
+		help = "↑/↓ move  d remove  1-5 read/triage/write/maintain/admin  u unmark  / search  f filter  p plan  esc back  q quit"
+	case viewPlan:
+		changes := m.Changes()
+		title = fmt.Sprintf("Pending changes: %d", len(changes))
+		for _, change := range changes {
+			lines = append(lines, change.String())
+		}
+		cursor = m.planCursor
+		help = "y apply  u unmark  esc back  q quit without applying"
+	}
+
+	var b strings.Builder
+	b.WriteString(title + "\n")
+	status := fmt.Sprintf("%d pending", len(m.changes))
+	if filter := permissionFilters[m.filter]; filter != "" && m.view != viewPlan {
+		status += ", filter: " + filter
+	}
+	if m.searching || m.search != "" {
+		status += ", search: " + m.search
+		if m.searching {
+			status += "_"
+		}
+	}
+	b.WriteString(status + "\n\n")
+
+	height := max(m.listHeight(), 1)
+	start := 0
+	if cursor >= height {
+		start = cursor - height + 1
+	}
+	for i := start; i < len(lines) && i < start+height; i++ {
+		if i == cursor {
+			b.WriteString("\x1b[7m> " + lines[i] + "\x1b[0m\n")
+		} else {
+			b.WriteString("  " + lines[i] + "\n")
+		}
+	}
+	if len(lines) == 0 {
+		b.WriteString("  (nothing to show)\n")
+	}
+	b.WriteString("\n" + m.message + "\n" + help)
+	return b.String()
+}
+
+func (m *Model) userLine(user string) string {
+	noun := "repos"
+	if len(m.grants[user]) == 1 {
+		noun = "repo"
+	}
+	line := fmt.Sprintf("%-30s %4d %s", user, len(m.grants[user]), noun)
+	pending := 0
+	for _, row := range m.grants[user] {
+		if _, ok := m.changes[changeKey(row)]; ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		line += fmt.Sprintf("  (%d pending)", pending)
+	}
+	return line
+}
+
+func (m *Model) grantLine(row report.Row) string {
+	line := fmt.Sprintf("%-40s %-10s %-10s", row.Repository, row.Visibility, utils.NormalizePermission(row.Permission))
+	if change, ok := m.changes[changeKey(row)]; ok {
+		if change.Removal() {
+			line += "  -> remove"
+		} else {
+			line += "  -> " + change.To
+		}
+	}
+	return line
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/report"
+)
+
+func testModel() *Model {
+	return NewModel("test-org", []report.Row{
+		{Repository: "repo1", Visibility: "PRIVATE", Username: "alice", Permission: "WRITE"},
+		{Repository: "repo3", Visibility: "PUBLIC", Username: "alice", Permission: "ADMIN"},
+		{Repository: "repo1", Visibility: "PRIVATE", Username: "bob", Permission: "READ"},
+		{Repository: "repo2", Visibility: "PRIVATE", Username: "carol", Permission: "ADMIN"},
+	})
+}
+
+func press(m *Model, input string) bool {
+	for _, key := range ParseKeys([]byte(input)) {
+		if m.Update(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestModelMarkAndApply(t *testing.T) {
+	m := testModel()
+
+	// Open alice, remove repo1 and lower repo3 to write
+	if press(m, "\rdj3") {
+		t.Fatal("Expected the review to continue")
+	}
+	if !strings.Contains(m.View(), "repo1") || !strings.Contains(m.View(), "-> remove") || !strings.Contains(m.View(), "-> write") {
+		t.Errorf("Expected marked grants in the view, got:\n%s", m.View())
+	}
+	// Back to the list, open bob and raise repo1 to admin
+	press(m, "\x1bj\r5")
+
+	if press(m, "p") || !strings.Contains(m.View(), "Pending changes: 3") {
+		t.Errorf("Expected the plan, got:\n%s", m.View())
+	}
+	if !press(m, "y") || !m.Apply() {
+		t.Fatal("Expected the plan to be confirmed")
+	}
+
+	expected := []Change{
+		{Repository: "repo1", Username: "alice", From: "write", To: "none"},
+		{Repository: "repo3", Username: "alice", From: "admin", To: "write"},
+		{Repository: "repo1", Username: "bob", From: "read", To: "admin"},
+	}
+	if !reflect.DeepEqual(m.Changes(), expected) {
+		t.Errorf("Expected %+v, got %+v", expected, m.Changes())
+	}
+}
+
+func TestModelUnmark(t *testing.T) {
+	m := testModel()
+
+	// Marking the same change twice, or the current permission, clears it
+	press(m, "\rdd3j5u")
+	if len(m.Changes()) != 0 {
+		t.Errorf("Expected no changes, got %+v", m.Changes())
+	}
+
+	press(m, "d")
+	press(m, "pu")
+	if len(m.Changes()) != 0 || !strings.Contains(m.View(), "collaborators") {
+		t.Errorf("Expected unmarking the last change to leave the plan, got:\n%s", m.View())
+	}
+}
+
+func TestModelSearchAndFilter(t *testing.T) {
+	m := testModel()
+
+	press(m, "/car\r")
+	if users := m.visibleUsers(); !reflect.DeepEqual(users, []string{"carol"}) {
+		t.Errorf("Expected search to match carol, got %v", users)
+	}
+	press(m, "/\x1b")
+	if users := m.visibleUsers(); len(users) != 3 {
+		t.Errorf("Expected escape to clear the search, got %v", users)
+	}
+
+	// The first filter shows collaborators with admin grants
+	press(m, "f")
+	if users := m.visibleUsers(); !reflect.DeepEqual(users, []string{"alice", "carol"}) {
+		t.Errorf("Expected admin collaborators, got %v", users)
+	}
+	press(m, "\r")
+	if grants := m.visibleGrants(); len(grants) != 1 || grants[0].Repository != "repo3" {
+		t.Errorf("Expected only alice's admin grant, got %+v", grants)
+	}
+}
+
+func TestModelQuit(t *testing.T) {
+	m := testModel()
+	if press(m, "p") {
+		t.Error("Expected an empty plan not to end the review")
+	}
+	if !strings.Contains(m.View(), "No changes marked yet") {
+		t.Errorf("Expected a message, got:\n%s", m.View())
+	}
+	if !press(m, "q") || m.Apply() {
+		t.Error("Expected q to quit without applying")
+	}
+	if !press(testModel(), "\r\x03") {
+		t.Error("Expected ctrl-c to quit from any view")
+	}
+}
+
+func TestModelScrolls(t *testing.T) {
+	var rows []report.Row
+	for _, user := range []string{"u01", "u02", "u03", "u04", "u05", "u06", "u07", "u08", "u09", "u10"} {
+		rows = append(rows, report.Row{Repository: "repo1", Username: user, Permission: "READ"})
+	}
+	m := NewModel("test-org", rows)
+	m.Height = 10
+
+	press(m, "\x1b[6~")
+	view := m.View()
+	if !strings.Contains(view, "> u05") || strings.Contains(view, "u01") {
+		t.Errorf("Expected the list to scroll to the cursor, got:\n%s", view)
+	}
+}
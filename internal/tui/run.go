@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned when the review is started without a terminal
+var ErrNotTerminal = errors.New("interactive review requires a terminal")
+
+// Run shows the model full screen on the terminal until the review is
+// confirmed or quit, restoring the terminal afterwards.
+func Run(in *os.File, out io.Writer, m *Model) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return ErrNotTerminal
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+
+	// Use the alternate screen so the shell is left as it was
+	_, _ = io.WriteString(out, "\x1b[?1049h\x1b[?25l")
+	defer func() { _, _ = io.WriteString(out, "\x1b[?25h\x1b[?1049l") }()
+
+	buf := make([]byte, 64)
+	for {
+		if _, height, err := term.GetSize(fd); err == nil && height > 0 {
+			m.Height = height
+		}
+		// Raw mode does not translate newlines
+		screen := strings.ReplaceAll(m.View(), "\n", "\x1b[K\r\n")
+		if _, err := io.WriteString(out, "\x1b[H"+screen+"\x1b[K\x1b[J"); err != nil {
+			return err
+		}
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return fmt.Errorf("failed to read from terminal: %w", err)
+		}
+		for _, key := range ParseKeys(buf[:n]) {
+			if m.Update(key) {
+				return nil
+			}
+		}
+	}
+}
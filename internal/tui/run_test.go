@@ -0,0 +1,20 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRunRequiresTerminal(t *testing.T) {
+	in, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = in.Close() }()
+
+	if err := Run(in, io.Discard, NewModel("test-org", nil)); !errors.Is(err, ErrNotTerminal) {
+		t.Errorf("Expected ErrNotTerminal, got %v", err)
+	}
+}
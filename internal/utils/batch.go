@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+const (
+	// DefaultBatchSize is the number of users put in the first batched query
+	DefaultBatchSize = 10
+	// MaxBatchSize caps the users in one query whatever its cost
+	MaxBatchSize = 50
+	// DefaultBatchCost is the rate limit cost each batched query aims for
+	DefaultBatchCost = 50
+	// BatchCollaboratorEdges is the number of search matches read for each
+	// user on a repository, other logins sharing the user's prefix can
+	// rank ahead of the user
+	BatchCollaboratorEdges = 50
+)
+
+// BatchAlias returns the field alias and variable name used for the user at
+// index i of a batch.
+func BatchAlias(i int) string {
+	return fmt.Sprintf("u%d", i)
+}
+
+// BuildRepoPermissionsBatchQuery returns a query checking n users against a
+// page of the organization's repositories. Each user gets an aliased
+// collaborators field on every repository, so one request covers the whole
+// batch instead of one request per user. Only direct collaborators are
+// matched, a member's access through teams or the base permission is not a
+// grant on the repository. The collaborators field is a search that also
+// matches logins and names starting with the user's login, so BatchPermission
+// picks the user's own edge from the matches.
+func BuildRepoPermissionsBatchQuery(n int) string {
+	var query strings.Builder
+	query.WriteString("query getOrganizationRepoPermissionsBatch($owner: String!, $endCursor: String")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&query, ", $%s: String!", BatchAlias(i))
+	}
+	query.WriteString(") {\n")
+	query.WriteString("  rateLimit { cost remaining }\n")
+	query.WriteString("  organization(login: $owner) {\n")
+	query.WriteString("    repositories(first: 100, after: $endCursor) {\n")
+	query.WriteString("      pageInfo { endCursor hasNextPage }\n")
+	query.WriteString("      nodes {\n")
+	query.WriteString("        databaseId\n        name\n        visibility\n")
+	for i := 0; i < n; i++ {
+		alias := BatchAlias(i)
+		fmt.Fprintf(&query, "        %s: collaborators(first: %d, affiliation: DIRECT, query: $%s) { edges { permission node { login } } }\n", alias, BatchCollaboratorEdges, alias)
+	}
+	query.WriteString("      }\n    }\n  }\n}")
+	return query.String()
+}
+
+// BatchPermission returns the direct permission of the user at index i of a
+// batch on the repository, and whether the user is a collaborator on it.
+func BatchPermission(repo data.BatchRepoInfo, i int, username string) (string, bool) {
+	for _, edge := range repo.Collaborators[BatchAlias(i)] {
+		if strings.EqualFold(edge.Node.Login, username) {
+			return edge.Permission, true
+		}
+	}
+	return "", false
+}
+
+func (g *APIGetter) GetOrgRepositoryPermissionsBatch(ctx context.Context, owner string, users []string, endCursor *string) (*data.BatchRepoPermissionsQuery, error) {
+	variables := map[string]interface{}{
+		"endCursor": endCursor,
+		"owner":     owner,
+	}
+	for i, user := range users {
+		variables[BatchAlias(i)] = user
+	}
+	query := new(data.BatchRepoPermissionsQuery)
+	err := g.gqlClient.DoWithContext(ctx, BuildRepoPermissionsBatchQuery(len(users)), variables, query)
+	return query, err
+}
+
+// BatchSizer picks how many users go in the next batched query from the cost
+// GitHub reported for the previous ones, keeping each query near a target
+// cost.
+type BatchSizer struct {
+	size       int
+	max        int
+	targetCost int
+}
+
+func NewBatchSizer(initial int, max int, targetCost int) *BatchSizer {
+	if max < 1 {
+		max = 1
+	}
+	if initial < 1 {
+		initial = 1
+	}
+	if initial > max {
+		initial = max
+	}
+	return &BatchSizer{size: initial, max: max, targetCost: targetCost}
+}
+
+func (b *BatchSizer) Size() int {
+	return b.size
+}
+
+// Observe records the cost of a query for the given number of users. A cost
+// of zero, as returned for cached responses, leaves the size unchanged.
+func (b *BatchSizer) Observe(users int, cost int) {
+	if users < 1 || cost < 1 || b.targetCost < 1 {
+		return
+	}
+	size := b.targetCost * users / cost
+	if size < 1 {
+		size = 1
+	}
+	if size > b.max {
+		size = b.max
+	}
+	b.size = size
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+)
+
+func TestBuildRepoPermissionsBatchQuery(t *testing.T) {
+	query := BuildRepoPermissionsBatchQuery(3)
+
+	for _, expected := range []string{
+		"query getOrganizationRepoPermissionsBatch($owner: String!, $endCursor: String, $u0: String!, $u1: String!, $u2: String!)",
+		"rateLimit { cost remaining }",
+		"repositories(first: 100, after: $endCursor)",
+		"u0: collaborators(first: 50, affiliation: DIRECT, query: $u0)",
+		"u2: collaborators(first: 50, affiliation: DIRECT, query: $u2)",
+	} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("Expected query to contain %q, got:\n%s", expected, query)
+		}
+	}
+	if strings.Contains(query, "$u3") {
+		t.Errorf("Expected only three users in the query, got:\n%s", query)
+	}
+}
+
+func TestGetOrgRepositoryPermissionsBatch(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request: replay.Request{
+			Method:    "POST",
+			Path:      "/graphql",
+			Variables: map[string]interface{}{"owner": "test-org", "endCursor": nil, "u0": "alice", "u1": "bob"},
+		},
+		Response: replay.Response{
+			Status: 200,
+			Body: []byte(`{"data": {
+				"rateLimit": {"cost": 2, "remaining": 4998},
+				"organization": {"repositories": {
+					"pageInfo": {"endCursor": "abc", "hasNextPage": true},
+					"nodes": [{"databaseId": 101, "name": "repo1", "visibility": "PRIVATE",
+						"u0": {"edges": [{"permission": "WRITE", "node": {"login": "alice"}}]},
+						"u1": {"edges": []}}]
+				}}
+			}}`),
+		},
+	})
+
+	client, err := api.NewGraphQLClient(api.ClientOptions{Host: "github.com", AuthToken: "test-token", Transport: server.Transport()})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	g := NewAPIGetter(client, nil)
+
+	page, err := g.GetOrgRepositoryPermissionsBatch(context.Background(), "test-org", []string{"alice", "bob"}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server.AssertAllUsed()
+
+	if page.RateLimit.Cost != 2 {
+		t.Errorf("Expected cost 2, got %d", page.RateLimit.Cost)
+	}
+	repos := page.Organization.Repositories
+	if !repos.PageInfo.HasNextPage || repos.PageInfo.EndCursor != "abc" || len(repos.Nodes) != 1 {
+		t.Fatalf("Unexpected page %+v", repos)
+	}
+	if edges := repos.Nodes[0].Collaborators[BatchAlias(0)]; len(edges) != 1 || edges[0].Permission != "WRITE" {
+		t.Errorf("Expected alice to have WRITE, got %v", edges)
+	}
+	if edges := repos.Nodes[0].Collaborators[BatchAlias(1)]; len(edges) != 0 {
+		t.Errorf("Expected bob to have no access, got %v", edges)
+	}
+}
+
+func TestBatchPermission(t *testing.T) {
+	edge := func(login string, permission string) data.Edge {
+		var e data.Edge
+		e.Node.Login = login
+		e.Permission = permission
+		return e
+	}
+	// The search for bob ranks bobby first, bobby has no match of his own
+	repo := data.BatchRepoInfo{Collaborators: map[string][]data.Edge{
+		BatchAlias(0): {edge("bobby", "ADMIN"), edge("bob", "READ")},
+		BatchAlias(1): {edge("bob", "READ")},
+	}}
+
+	if permission, ok := BatchPermission(repo, 0, "Bob"); !ok || permission != "READ" {
+		t.Errorf("Expected bob to have READ, got %q %v", permission, ok)
+	}
+	if permission, ok := BatchPermission(repo, 1, "bobby"); ok {
+		t.Errorf("Expected bobby to have no access, got %q", permission)
+	}
+	if _, ok := BatchPermission(repo, 2, "carol"); ok {
+		t.Error("Expected no access for a user without edges")
+	}
+}
+
+func TestBatchSizer(t *testing.T) {
+	sizer := NewBatchSizer(10, 50, 50)
+	if sizer.Size() != 10 {
+		t.Fatalf("Expected initial size 10, got %d", sizer.Size())
+	}
+
+	// 10 users cost 5 points, so 100 would reach the target but the size is
+	// capped
+	sizer.Observe(10, 5)
+	if sizer.Size() != 50 {
+		t.Errorf("Expected size capped at 50, got %d", sizer.Size())
+	}
+
+	sizer.Observe(50, 100)
+	if sizer.Size() != 25 {
+		t.Errorf("Expected size 25 after an expensive query, got %d", sizer.Size())
+	}
+
+	// Cached responses report no cost
+	sizer.Observe(25, 0)
+	if sizer.Size() != 25 {
+		t.Errorf("Expected size unchanged without a cost, got %d", sizer.Size())
+	}
+
+	sizer.Observe(1, 500)
+	if sizer.Size() != 1 {
+		t.Errorf("Expected size of at least 1, got %d", sizer.Size())
+	}
+
+	if sizer := NewBatchSizer(100, 50, 50); sizer.Size() != 50 {
+		t.Errorf("Expected initial size capped at 50, got %d", sizer.Size())
+	}
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"io"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"go.uber.org/zap"
+)
+
+// DryRun wraps a Getter so its mutating calls succeed without sending a
+// request, reads still go to the API so results show what would change.
+func DryRun(g Getter) Getter {
+	return &dryRunGetter{Getter: g}
+}
+
+type dryRunGetter struct {
+	Getter
+}
+
+func (g *dryRunGetter) AddRepoCollaborator(ctx context.Context, owner string, repo string, username string, body io.Reader) (*data.RepoInvitation, error) {
+	grantLogger(owner, repo, username).Debug("Dry run: not adding repository collaborator")
+	return nil, nil
+}
+
+func (g *dryRunGetter) RemoveRepoCollaborator(ctx context.Context, owner string, repo string, username string) error {
+	grantLogger(owner, repo, username).Debug("Dry run: not removing repository collaborator")
+	return nil
+}
+
+func (g *dryRunGetter) RemoveRepoInvitation(ctx context.Context, owner string, repo string, invitationID int64) error {
+	grantLogger(owner, repo, "").Debug("Dry run: not removing repository invitation", zap.Int64("invitation_id", invitationID))
+	return nil
+}
+
+func (g *dryRunGetter) AddTeamMember(ctx context.Context, owner string, teamSlug string, username string) error {
+	grantLogger(owner, "", username).Debug("Dry run: not adding team member", zap.String("team", teamSlug))
+	return nil
+}
+
+func (g *dryRunGetter) ConvertToOutsideCollaborator(ctx context.Context, owner string, username string) error {
+	grantLogger(owner, "", username).Debug("Dry run: not converting to an outside collaborator")
+	return nil
+}
+
+func (g *dryRunGetter) CreateOrgInvitation(ctx context.Context, owner string, inviteeID int, teamIDs []int) error {
+	grantLogger(owner, "", "").Debug("Dry run: not inviting user", zap.Int("invitee_id", inviteeID))
+	return nil
+}
+
+func (g *dryRunGetter) CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (*data.Issue, error) {
+	grantLogger(owner, repo, "").Debug("Dry run: not creating issue", zap.String("title", title))
+	return &data.Issue{Title: title, Body: body}, nil
+}
+
+func (g *dryRunGetter) CreateIssueComment(ctx context.Context, owner string, repo string, number int, body string) (*data.IssueComment, error) {
+	grantLogger(owner, repo, "").Debug("Dry run: not commenting on issue", zap.Int("issue", number))
+	return &data.IssueComment{Body: body}, nil
+}
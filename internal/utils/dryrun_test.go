@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/replay"
+)
+
+func TestDryRunSkipsMutations(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/alice"},
+		Response: replay.Response{Body: []byte(`{"state": "active", "role": "member"}`)},
+	})
+	g := DryRun(newReplayGetter(t, server))
+	ctx := context.Background()
+
+	for name, mutate := range map[string]func() error{
+		"AddRepoCollaborator": func() error {
+			_, err := g.AddRepoCollaborator(ctx, "test-org", "repo1", "alice", strings.NewReader(`{"permission":"push"}`))
+			return err
+		},
+		"RemoveRepoCollaborator":       func() error { return g.RemoveRepoCollaborator(ctx, "test-org", "repo1", "alice") },
+		"RemoveRepoInvitation":         func() error { return g.RemoveRepoInvitation(ctx, "test-org", "repo1", 1) },
+		"AddTeamMember":                func() error { return g.AddTeamMember(ctx, "test-org", "dev", "alice") },
+		"ConvertToOutsideCollaborator": func() error { return g.ConvertToOutsideCollaborator(ctx, "test-org", "alice") },
+		"CreateOrgInvitation":          func() error { return g.CreateOrgInvitation(ctx, "test-org", 1, nil) },
+		"CreateIssue": func() error {
+			_, err := g.CreateIssue(ctx, "test-org", "repo1", "Review access", "")
+			return err
+		},
+		"CreateIssueComment": func() error {
+			_, err := g.CreateIssueComment(ctx, "test-org", "repo1", 7, "Access changed")
+			return err
+		},
+	} {
+		if err := mutate(); err != nil {
+			t.Errorf("Expected %s to succeed without a request, got %v", name, err)
+		}
+	}
+
+	// Reads still reach the API
+	if _, err := g.GetOrgMembership(ctx, "test-org", "alice"); err != nil {
+		t.Errorf("Expected membership read, got %v", err)
+	}
+	server.AssertAllUsed()
+	if len(server.Requests()) != 1 {
+		t.Errorf("Expected only the read to be sent, got %d requests", len(server.Requests()))
+	}
+}
@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultConcurrency = 4
+
+	// GitHub's secondary limits allow no more than 80 content creating
+	// requests per minute and 500 per hour.
+	DefaultMutationsPerMinute = 80
+	DefaultMutationsPerHour   = 500
+
+	DefaultMaxAttempts = 3
+
+	// secondaryLimitDelay is how long GitHub asks clients to wait after a
+	// secondary rate limit response without a Retry-After header.
+	secondaryLimitDelay = time.Minute
+)
+
+type ExecutorOptions struct {
+	// Concurrency is the number of rows processed at once, at least 1
+	Concurrency int
+	// PerMinute caps mutating requests per minute, the hourly cap applies
+	// along with it. Zero disables rate limiting entirely.
+	PerMinute int
+	PerHour   int
+	// MaxAttempts is how many times a row failing with a transient error
+	// is tried, at least 1
+	MaxAttempts int
+}
+
+// Executor runs a task per row on a pool of workers, retrying rows that fail
+// with transient errors and collecting results in input order.
+type Executor struct {
+	opts     ExecutorOptions
+	limiters []*RateLimiter
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func NewExecutor(opts ExecutorOptions) *Executor {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	e := &Executor{opts: opts, sleep: sleepContext}
+	if opts.PerMinute > 0 {
+		e.limiters = append(e.limiters, NewRateLimiter(opts.PerMinute, time.Minute))
+		if opts.PerHour > 0 {
+			e.limiters = append(e.limiters, NewRateLimiter(opts.PerHour, time.Hour))
+		}
+	}
+	return e
+}
+
+// Run calls task for each of the n rows and returns the results in row
+// order, however the rows were scheduled. onResult, when set, sees each
+// row's final result as it completes, one call at a time. Once ctx is done
+// no further rows are started, they are returned as failed without being
+// passed to onResult, naming the repository and user that row returns.
+func (e *Executor) Run(ctx context.Context, n int, row func(i int) data.MutationResult, task func(i int) data.MutationResult, onResult func(result data.MutationResult)) []data.MutationResult {
+	results := make([]data.MutationResult, n)
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	workers := e.opts.Concurrency
+	if workers > n {
+		workers = n
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rows {
+				results[i] = e.runWithRetry(ctx, task, i)
+				if onResult != nil {
+					mu.Lock()
+					onResult(results[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	started := 0
+dispatch:
+	for ; started < n; started++ {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case rows <- started:
+		}
+	}
+	close(rows)
+	wg.Wait()
+
+	for i := started; i < n; i++ {
+		placeholder := row(i)
+		results[i] = data.MutationResult{
+			RepositoryName: placeholder.RepositoryName,
+			Username:       placeholder.Username,
+			Team:           placeholder.Team,
+			Status:         StatusFailed,
+			Reason:         fmt.Sprintf("not attempted: %v", ctx.Err()),
+			Err:            ctx.Err(),
+		}
+	}
+	return results
+}
+
+// CollabRows returns a row function naming the repository and user of
+// each collaborator, for Run.
+func CollabRows(collabs []data.ImportedRepoCollab) func(i int) data.MutationResult {
+	return func(i int) data.MutationResult {
+		return data.MutationResult{RepositoryName: collabs[i].RepositoryName, Username: collabs[i].Username}
+	}
+}
+
+func (e *Executor) runWithRetry(ctx context.Context, task func(i int) data.MutationResult, i int) data.MutationResult {
+	var result data.MutationResult
+	for attempt := 1; ; attempt++ {
+		result = task(i)
+		if result.Status != StatusFailed || attempt >= e.opts.MaxAttempts || !IsRetryable(result.Err) {
+			return result
+		}
+		delay := RetryDelay(result.Err, attempt)
+		zap.L().Warn("Retrying after failed attempt",
+			append(responseFields(nil, result.Err),
+				zap.String("repo", result.RepositoryName),
+				zap.String("user", result.Username),
+				zap.String("permission", result.Permission),
+				zap.Duration("delay", delay),
+				zap.Int("attempt", attempt),
+				zap.Error(result.Err))...)
+		if err := e.sleep(ctx, delay); err != nil {
+			return result
+		}
+	}
+}
+
+// RateLimited wraps a Getter so its mutating calls wait for the executor's
+// rate limits, reads are not limited.
+func (e *Executor) RateLimited(g Getter) Getter {
+	if len(e.limiters) == 0 {
+		return g
+	}
+	return &rateLimitedGetter{Getter: g, limiters: e.limiters}
+}
+
+type rateLimitedGetter struct {
+	Getter
+	limiters []*RateLimiter
+}
+
+func (g *rateLimitedGetter) wait(ctx context.Context) error {
+	for _, limiter := range g.limiters {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *rateLimitedGetter) AddRepoCollaborator(ctx context.Context, owner string, repo string, username string, body io.Reader) (*data.RepoInvitation, error) {
+	if err := g.wait(ctx); err != nil {
+		return nil, err
+	}
+	return g.Getter.AddRepoCollaborator(ctx, owner, repo, username, body)
+}
+
+func (g *rateLimitedGetter) RemoveRepoCollaborator(ctx context.Context, owner string, repo string, username string) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+	return g.Getter.RemoveRepoCollaborator(ctx, owner, repo, username)
+}
+
+func (g *rateLimitedGetter) RemoveRepoInvitation(ctx context.Context, owner string, repo string, invitationID int64) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+	return g.Getter.RemoveRepoInvitation(ctx, owner, repo, invitationID)
+}
+
+func (g *rateLimitedGetter) AddTeamMember(ctx context.Context, owner string, teamSlug string, username string) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+	return g.Getter.AddTeamMember(ctx, owner, teamSlug, username)
+}
+
+func (g *rateLimitedGetter) ConvertToOutsideCollaborator(ctx context.Context, owner string, username string) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+	return g.Getter.ConvertToOutsideCollaborator(ctx, owner, username)
+}
+
+func (g *rateLimitedGetter) CreateOrgInvitation(ctx context.Context, owner string, inviteeID int, teamIDs []int) error {
+	if err := g.wait(ctx); err != nil {
+		return err
+	}
+	return g.Getter.CreateOrgInvitation(ctx, owner, inviteeID, teamIDs)
+}
+
+func (g *rateLimitedGetter) CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (*data.Issue, error) {
+	if err := g.wait(ctx); err != nil {
+		return nil, err
+	}
+	return g.Getter.CreateIssue(ctx, owner, repo, title, body)
+}
+
+func (g *rateLimitedGetter) CreateIssueComment(ctx context.Context, owner string, repo string, number int, body string) (*data.IssueComment, error) {
+	if err := g.wait(ctx); err != nil {
+		return nil, err
+	}
+	return g.Getter.CreateIssueComment(ctx, owner, repo, number, body)
+}
+
+// RateLimiter is a token bucket holding up to limit tokens that refills
+// evenly over period.
+type RateLimiter struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	perToken time.Duration
+	last     time.Time
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func NewRateLimiter(limit int, period time.Duration) *RateLimiter {
+	return &RateLimiter{
+		tokens:   float64(limit),
+		capacity: float64(limit),
+		perToken: period / time.Duration(limit),
+		last:     time.Now(),
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Wait blocks until a token is available and takes it, or until ctx is
+// done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens = math.Min(l.capacity, l.tokens+float64(now.Sub(l.last))/float64(l.perToken))
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return nil
+	}
+
+	// Holding the lock while sleeping queues the other workers behind
+	// this one, which is the order they would get tokens in anyway
+	wait := time.Duration((1 - l.tokens) * float64(l.perToken))
+	if err := l.sleep(ctx, wait); err != nil {
+		return err
+	}
+	l.tokens = 0
+	l.last = l.now()
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// IsRetryable reports whether err is likely to succeed when retried: rate
+// limits, server errors and network failures.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		case http.StatusForbidden:
+			return isRateLimited(httpErr)
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RetryDelay picks how long to wait before retrying, following Retry-After
+// and rate limit reset headers when GitHub sends them.
+func RetryDelay(err error, attempt int) time.Duration {
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Headers != nil {
+		if seconds, parseErr := strconv.Atoi(httpErr.Headers.Get("Retry-After")); parseErr == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if httpErr.Headers.Get("X-RateLimit-Remaining") == "0" {
+			if reset, parseErr := strconv.ParseInt(httpErr.Headers.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+				if wait := time.Until(time.Unix(reset, 0)); wait > 0 {
+					return wait
+				}
+			}
+		}
+		if isRateLimited(httpErr) {
+			return secondaryLimitDelay
+		}
+	}
+	return time.Duration(1<<uint(attempt-1)) * time.Second
+}
+
+func isRateLimited(httpErr *api.HTTPError) bool {
+	if httpErr.Headers != nil && (httpErr.Headers.Get("Retry-After") != "" || httpErr.Headers.Get("X-RateLimit-Remaining") == "0") {
+		return true
+	}
+	return strings.Contains(strings.ToLower(httpErr.Message), "rate limit")
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+func newTestExecutor(opts ExecutorOptions) (*Executor, *[]time.Duration) {
+	e := NewExecutor(opts)
+	var delays []time.Duration
+	e.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return e, &delays
+}
+
+func noRow(int) data.MutationResult {
+	return data.MutationResult{}
+}
+
+func TestExecutorRunKeepsRowOrder(t *testing.T) {
+	e, _ := newTestExecutor(ExecutorOptions{Concurrency: 4})
+
+	var calls, reported int32
+	results := e.Run(context.Background(), 20, noRow, func(i int) data.MutationResult {
+		atomic.AddInt32(&calls, 1)
+		// Earlier rows finish last
+		time.Sleep(time.Duration(20-i) * time.Millisecond)
+		return data.MutationResult{RepositoryName: string(rune('a' + i)), Status: StatusAdded}
+	}, func(result data.MutationResult) {
+		reported++
+	})
+
+	if calls != 20 || reported != 20 {
+		t.Errorf("Expected 20 calls and 20 reports, got %d and %d", calls, reported)
+	}
+	for i, result := range results {
+		if result.RepositoryName != string(rune('a'+i)) {
+			t.Errorf("Expected result %d to be %s, got %s", i, string(rune('a'+i)), result.RepositoryName)
+		}
+	}
+}
+
+func TestExecutorRunEmpty(t *testing.T) {
+	e, _ := newTestExecutor(ExecutorOptions{Concurrency: 4})
+	if results := e.Run(context.Background(), 0, noRow, func(i int) data.MutationResult { return data.MutationResult{} }, nil); len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+}
+
+func TestExecutorRetriesTransientFailures(t *testing.T) {
+	e, delays := newTestExecutor(ExecutorOptions{Concurrency: 1, MaxAttempts: 3})
+
+	unavailable := &api.HTTPError{StatusCode: http.StatusServiceUnavailable, Message: "Service Unavailable"}
+	attempts := 0
+	results := e.Run(context.Background(), 1, noRow, func(i int) data.MutationResult {
+		attempts++
+		if attempts < 3 {
+			return failed(data.MutationResult{}, unavailable)
+		}
+		return data.MutationResult{Status: StatusAdded}
+	}, nil)
+
+	if attempts != 3 || results[0].Status != StatusAdded {
+		t.Errorf("Expected success on the third attempt, got %d attempts and %+v", attempts, results[0])
+	}
+	if len(*delays) != 2 || (*delays)[0] != time.Second || (*delays)[1] != 2*time.Second {
+		t.Errorf("Expected exponential backoff of 1s then 2s, got %v", *delays)
+	}
+}
+
+func TestExecutorDoesNotRetryPermanentFailures(t *testing.T) {
+	e, _ := newTestExecutor(ExecutorOptions{Concurrency: 1, MaxAttempts: 3})
+
+	attempts := 0
+	results := e.Run(context.Background(), 1, noRow, func(i int) data.MutationResult {
+		attempts++
+		return failed(data.MutationResult{}, &api.HTTPError{StatusCode: http.StatusNotFound, Message: "Not Found"})
+	}, nil)
+
+	if attempts != 1 || results[0].Status != StatusFailed {
+		t.Errorf("Expected a single failed attempt, got %d attempts and %+v", attempts, results[0])
+	}
+}
+
+func TestExecutorStopsWhenCanceled(t *testing.T) {
+	e, _ := newTestExecutor(ExecutorOptions{Concurrency: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var collabs []data.ImportedRepoCollab
+	for i := 0; i < 5; i++ {
+		collabs = append(collabs, data.ImportedRepoCollab{RepositoryName: fmt.Sprintf("repo%d", i), Username: "alice"})
+	}
+	var calls int32
+	results := e.Run(ctx, 5, CollabRows(collabs), func(i int) data.MutationResult {
+		atomic.AddInt32(&calls, 1)
+		return data.MutationResult{Status: StatusAdded}
+	}, func(result data.MutationResult) {
+		t.Errorf("Expected rows that never ran not to be reported, got %+v", result)
+	})
+
+	if calls != 0 {
+		t.Errorf("Expected no rows to start after cancellation, got %d", calls)
+	}
+	for i, result := range results {
+		if result.Status != StatusFailed || !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("Expected canceled rows to be failed, got %+v", result)
+		}
+		// The summary names the row that was never started
+		if result.RepositoryName != collabs[i].RepositoryName || result.Username != "alice" {
+			t.Errorf("Expected row %d to name %s and alice, got %+v", i, collabs[i].RepositoryName, result)
+		}
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := NewRateLimiter(1, time.Hour)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected first token immediately, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected wait to stop with the context, got %v", err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	var slept []time.Duration
+	l := NewRateLimiter(2, time.Minute)
+	l.last = now
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	// The bucket starts full, then refills one token every 30 seconds
+	_ = l.Wait(context.Background())
+	_ = l.Wait(context.Background())
+	if len(slept) != 0 {
+		t.Fatalf("Expected burst of 2 without waiting, got %v", slept)
+	}
+	_ = l.Wait(context.Background())
+	if len(slept) != 1 || slept[0] != 30*time.Second {
+		t.Errorf("Expected to wait 30s for the third token, got %v", slept)
+	}
+
+	now = now.Add(time.Hour)
+	_ = l.Wait(context.Background())
+	_ = l.Wait(context.Background())
+	if len(slept) != 1 {
+		t.Errorf("Expected refilled bucket to be capped at 2 tokens, got waits %v", slept)
+	}
+}
+
+func TestRateLimitedGetterOnlyLimitsMutations(t *testing.T) {
+	e := NewExecutor(ExecutorOptions{PerMinute: 1})
+	var waits int
+	for _, limiter := range e.limiters {
+		limiter.sleep = func(context.Context, time.Duration) error {
+			waits++
+			return nil
+		}
+	}
+
+	ctx := context.Background()
+	g := e.RateLimited(newFakePermissions(map[string]string{}))
+	for i := 0; i < 3; i++ {
+		if _, err := g.GetRepoCollaboratorPermission(ctx, "test-org", "repo1", "user1"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if waits != 0 {
+		t.Errorf("Expected reads not to be rate limited, got %d waits", waits)
+	}
+
+	_ = g.RemoveRepoCollaborator(ctx, "test-org", "repo1", "user1")
+	_ = g.RemoveRepoCollaborator(ctx, "test-org", "repo1", "user2")
+	if waits != 1 {
+		t.Errorf("Expected the second mutation to wait, got %d waits", waits)
+	}
+
+	if unlimited := NewExecutor(ExecutorOptions{}); unlimited.RateLimited(g) != g {
+		t.Error("Expected executor without limits to return the getter unchanged")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "nil", err: nil, retryable: false},
+		{name: "not found", err: &api.HTTPError{StatusCode: 404}, retryable: false},
+		{name: "validation", err: &api.HTTPError{StatusCode: 422}, retryable: false},
+		{name: "too many requests", err: &api.HTTPError{StatusCode: 429}, retryable: true},
+		{name: "bad gateway", err: &api.HTTPError{StatusCode: 502}, retryable: true},
+		{name: "forbidden", err: &api.HTTPError{StatusCode: 403, Message: "Must have admin rights"}, retryable: false},
+		{name: "secondary limit", err: &api.HTTPError{StatusCode: 403, Message: "You have exceeded a secondary rate limit"}, retryable: true},
+		{name: "primary limit", err: &api.HTTPError{StatusCode: 403, Headers: http.Header{"X-Ratelimit-Remaining": []string{"0"}}}, retryable: true},
+		{name: "other error", err: errors.New("boom"), retryable: false},
+		{name: "canceled", err: context.Canceled, retryable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("Expected IsRetryable to be %v, got %v", tt.retryable, got)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	retryAfter := &api.HTTPError{StatusCode: 403, Headers: http.Header{"Retry-After": []string{"7"}}}
+	if got := RetryDelay(retryAfter, 1); got != 7*time.Second {
+		t.Errorf("Expected Retry-After of 7s, got %v", got)
+	}
+
+	secondary := &api.HTTPError{StatusCode: 403, Message: "secondary rate limit", Headers: http.Header{}}
+	if got := RetryDelay(secondary, 1); got != time.Minute {
+		t.Errorf("Expected a minute for secondary limits, got %v", got)
+	}
+
+	if got := RetryDelay(&api.HTTPError{StatusCode: 502}, 3); got != 4*time.Second {
+		t.Errorf("Expected 4s backoff on third attempt, got %v", got)
+	}
+}
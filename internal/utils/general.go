@@ -1,24 +1,44 @@
 package utils
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
-	"strings"
+	"net/http"
+	"os"
 
 	"github.com/cli/go-gh/v2/pkg/api"
 	"github.com/katiem0/gh-collaborators/internal/data"
-	"github.com/shurcooL/graphql"
 	"go.uber.org/zap"
 )
 
 type Getter interface {
-	AddRepoCollaborator(owner string, repo string, username string, data io.Reader) error
+	AddRepoCollaborator(ctx context.Context, owner string, repo string, username string, data io.Reader) (*data.RepoInvitation, error)
+	AddTeamMember(ctx context.Context, owner string, teamSlug string, username string) error
+	ConvertToOutsideCollaborator(ctx context.Context, owner string, username string) error
+	CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (*data.Issue, error)
+	CreateIssueComment(ctx context.Context, owner string, repo string, number int, body string) (*data.IssueComment, error)
+	CreateOrgInvitation(ctx context.Context, owner string, inviteeID int, teamIDs []int) error
 	CreateRepoCollaboratorsList(filedata [][]string) []data.ImportedRepoCollab
 	CreateRepoPermData(permission string) *data.Permission
-	GetOrgGuestCollaborators(owner string) ([]byte, error)
-	GetOrgRepositoryPermissions(owner string, user string, endCursor *string) (*data.OrganizationUserQuery, error)
-	RemoveRepoCollaborator(owner string, repo string, username string) error
+	DeleteRepoCollaboratorsList(filedata [][]string) []data.ImportedRepoCollab
+	GetOrganization(ctx context.Context, owner string) (*data.Organization, error)
+	GetOrgMembership(ctx context.Context, owner string, username string) (*data.OrgMembership, error)
+	GetRepoCollaboratorPermission(ctx context.Context, owner string, repo string, username string) (string, error)
+	GetOrgRepositoryPermissionsBatch(ctx context.Context, owner string, users []string, endCursor *string) (*data.BatchRepoPermissionsQuery, error)
+	GetAuthenticatedUser(ctx context.Context) (*data.User, error)
+	GetUser(ctx context.Context, username string) (*data.User, error)
+	ListRepoCollaborators(ctx context.Context, owner string, repo string, affiliation string) ([]data.RepoCollaborator, error)
+	ListRepoInvitations(ctx context.Context, owner string, repo string) ([]data.RepoInvitation, error)
+	ListOrgAuditLog(ctx context.Context, owner string, phrase string) ([]data.AuditLogEvent, error)
+	ListOrgMembers(ctx context.Context, owner string) ([]data.User, error)
+	ListOrgOutsideCollaborators(ctx context.Context, owner string) ([]data.User, error)
+	ListOrgTeams(ctx context.Context, owner string) ([]data.Team, error)
+	ListTeamRepositories(ctx context.Context, owner string, teamSlug string) ([]data.TeamRepository, error)
+	RemoveRepoCollaborator(ctx context.Context, owner string, repo string, username string) error
+	RemoveRepoInvitation(ctx context.Context, owner string, repo string, invitationID int64) error
 }
 
 type APIGetter struct {
@@ -40,46 +60,33 @@ func NewAPIGetter(gqlClient *api.GraphQLClient, restClient *api.RESTClient) *API
 	return getter
 }
 
-func (g *APIGetter) GetOrgGuestCollaborators(owner string) ([]byte, error) {
-	url := fmt.Sprintf("orgs/%s/outside_collaborators", owner)
-	zap.S().Debugf("Reading in repository collaborators from %v", url)
-	resp, err := g.restClient.Request("GET", url, nil)
+// ReadCollaborators reads the rows of the CSV file at fileName, parsed into
+// collaborators by toList.
+func ReadCollaborators(fileName string, toList func([][]string) []data.ImportedRepoCollab) ([]data.ImportedRepoCollab, error) {
+	f, err := os.Open(fileName)
+	zap.S().Debugf("Opening up file %s", fileName)
 	if err != nil {
-		// Check for specific permission error
-		if strings.Contains(err.Error(), "403") && strings.Contains(err.Error(), "must be an owner") {
-			return nil, fmt.Errorf("insufficient permissions: you must be an owner of the organization '%s' to list outside collaborators", owner)
-		}
-		zap.S().Errorf("Error making request to %s: %v", url, err)
-		return nil, err
-	}
-
-	if resp != nil && resp.Body != nil {
-		defer func() {
-			closeErr := resp.Body.Close()
-			if closeErr != nil {
-				zap.S().Warnf("Error closing response body: %v", closeErr)
-			}
-		}()
+		zap.S().Errorf("Error arose opening repository collaborators csv file")
+		return nil, fmt.Errorf("failed to open %s: %w", fileName, err)
 	}
-
-	responseData, err := io.ReadAll(resp.Body)
+	defer func() {
+		closeErr := f.Close()
+		if closeErr != nil {
+			zap.S().Warnf("Error closing file: %v", closeErr)
+		}
+	}()
+	// read csv values using csv.Reader
+	csvReader := csv.NewReader(f)
+	collabData, err := csvReader.ReadAll()
+	zap.S().Debugf("Reading in all lines from csv file")
 	if err != nil {
-		zap.S().Errorf("Body read error: %v", err)
-		return nil, err
+		zap.S().Errorf("Error arose reading repository collaborators from csv file")
+		return nil, fmt.Errorf("failed to read %s: %w", fileName, err)
 	}
-	return responseData, nil
-}
-
-func (g *APIGetter) GetOrgRepositoryPermissions(owner string, user string, endCursor *string) (*data.OrganizationUserQuery, error) {
-	query := new(data.OrganizationUserQuery)
-	variables := map[string]interface{}{
-		"endCursor": (*graphql.String)(endCursor),
-		"owner":     graphql.String(owner),
-		"user":      graphql.String(user),
+	if len(collabData) == 0 {
+		return nil, fmt.Errorf("no rows found in %s", fileName)
 	}
-	err := g.gqlClient.Query("getOrganizationRepoPermissions", &query, variables)
-
-	return query, err
+	return toList(collabData), nil
 }
 
 func (g *APIGetter) CreateRepoCollaboratorsList(filedata [][]string) []data.ImportedRepoCollab {
@@ -110,20 +117,70 @@ func (g *APIGetter) CreateRepoCollaboratorsList(filedata [][]string) []data.Impo
 	return importRepoCollabs
 }
 
-func (g *APIGetter) AddRepoCollaborator(owner string, repo string, username string, data io.Reader) error {
+// AddRepoCollaborator grants the user access to the repository. GitHub
+// invites users who are not yet collaborators, answering 201 with the
+// invitation, which is returned; an existing collaborator gets 204 and nil.
+func (g *APIGetter) AddRepoCollaborator(ctx context.Context, owner string, repo string, username string, body io.Reader) (*data.RepoInvitation, error) {
 	url := fmt.Sprintf("repos/%s/%s/collaborators/%s", owner, repo, username)
+	logger := grantLogger(owner, repo, username)
 
-	resp, err := g.restClient.Request("PUT", url, data)
+	resp, err := g.restClient.RequestWithContext(ctx, "PUT", url, body)
+	logResponse(logger, "Added repository collaborator", resp, err)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	captureRequestID(ctx, resp)
 	defer func() {
 		closeErr := resp.Body.Close()
 		if closeErr != nil {
-			zap.S().Warnf("Error closing response body: %v", closeErr)
+			logger.Warn("Error closing response body", zap.Error(closeErr))
 		}
 	}()
-	return err
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, nil
+	}
+	invitation := new(data.RepoInvitation)
+	if err := json.NewDecoder(resp.Body).Decode(invitation); err != nil {
+		return nil, fmt.Errorf("failed to parse invitation for %s on %s: %w", username, repo, err)
+	}
+	if invitation.ID == 0 {
+		return nil, nil
+	}
+	return invitation, nil
+}
+
+func (g *APIGetter) GetRepoCollaboratorPermission(ctx context.Context, owner string, repo string, username string) (string, error) {
+	url := fmt.Sprintf("repos/%s/%s/collaborators/%s/permission", owner, repo, username)
+	logger := grantLogger(owner, repo, username)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	logResponse(logger, "Read current permission", resp, err)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		closeErr := resp.Body.Close()
+		if closeErr != nil {
+			logger.Warn("Error closing response body", zap.Error(closeErr))
+		}
+	}()
+
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var permission data.CollaboratorPermission
+	if err := json.Unmarshal(responseData, &permission); err != nil {
+		return "", fmt.Errorf("failed to parse permission for %s on %s: %w", username, repo, err)
+	}
+
+	// role_name distinguishes triage and maintain, which permission reports
+	// as read and write
+	if permission.RoleName != "" {
+		return NormalizePermission(permission.RoleName), nil
+	}
+	return NormalizePermission(permission.Permission), nil
 }
 
 func (g *APIGetter) CreateRepoPermData(permission string) *data.Permission {
@@ -155,17 +212,45 @@ func (g *APIGetter) DeleteRepoCollaboratorsList(filedata [][]string) []data.Impo
 	return importRepoCollabs
 }
 
-func (g *APIGetter) RemoveRepoCollaborator(owner string, repo string, username string) error {
+func (g *APIGetter) RemoveRepoCollaborator(ctx context.Context, owner string, repo string, username string) error {
 	url := fmt.Sprintf("repos/%s/%s/collaborators/%s", owner, repo, username)
+	logger := grantLogger(owner, repo, username)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "DELETE", url, nil)
+	logResponse(logger, "Removed repository collaborator", resp, err)
+	if err != nil {
+		return err
+	}
+	captureRequestID(ctx, resp)
+	defer func() {
+		closeErr := resp.Body.Close()
+		if closeErr != nil {
+			logger.Warn("Error closing response body", zap.Error(closeErr))
+		}
+	}()
+	return err
+}
+
+// ListRepoInvitations returns the repository's pending invitations.
+func (g *APIGetter) ListRepoInvitations(ctx context.Context, owner string, repo string) ([]data.RepoInvitation, error) {
+	return getPages[data.RepoInvitation](ctx, g.restClient, fmt.Sprintf("repos/%s/%s/invitations", owner, repo))
+}
+
+// RemoveRepoInvitation withdraws a pending invitation to the repository.
+func (g *APIGetter) RemoveRepoInvitation(ctx context.Context, owner string, repo string, invitationID int64) error {
+	url := fmt.Sprintf("repos/%s/%s/invitations/%d", owner, repo, invitationID)
+	logger := grantLogger(owner, repo, "").With(zap.Int64("invitation_id", invitationID))
 
-	resp, err := g.restClient.Request("DELETE", url, nil)
+	resp, err := g.restClient.RequestWithContext(ctx, "DELETE", url, nil)
+	logResponse(logger, "Removed repository invitation", resp, err)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	captureRequestID(ctx, resp)
 	defer func() {
 		closeErr := resp.Body.Close()
 		if closeErr != nil {
-			zap.S().Warnf("Error closing response body: %v", closeErr)
+			logger.Warn("Error closing response body", zap.Error(closeErr))
 		}
 	}()
 	return err
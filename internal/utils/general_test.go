@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cli/go-gh/v2/pkg/api"
@@ -73,6 +75,34 @@ func TestDeleteRepoCollaboratorsList(t *testing.T) {
 	}
 }
 
+func TestReadCollaborators(t *testing.T) {
+	getter := &APIGetter{}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "remove.csv")
+	if err := os.WriteFile(path, []byte("RepositoryName,Username\nrepo1,user1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	collabs, err := ReadCollaborators(path, getter.DeleteRepoCollaboratorsList)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(collabs) != 1 || collabs[0].RepositoryName != "repo1" || collabs[0].Username != "user1" {
+		t.Errorf("Unexpected collaborators %+v", collabs)
+	}
+
+	empty := filepath.Join(dir, "empty.csv")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCollaborators(empty, getter.DeleteRepoCollaboratorsList); err == nil {
+		t.Error("Expected an error for a file without rows, got nil")
+	}
+	if _, err := ReadCollaborators(filepath.Join(dir, "missing.csv"), getter.DeleteRepoCollaboratorsList); err == nil {
+		t.Error("Expected an error for a missing file, got nil")
+	}
+}
+
 func TestCreateRepoPermData(t *testing.T) {
 	getter := &APIGetter{}
 	permission := "admin"
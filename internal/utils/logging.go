@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"go.uber.org/zap"
+)
+
+// requestIDHeader is GitHub's ID for a request, support asks for it when
+// investigating a failed call
+const requestIDHeader = "X-GitHub-Request-Id"
+
+// grantLogger returns the global logger with the fields identifying one
+// collaborator grant, empty values are left out.
+func grantLogger(owner string, repo string, username string) *zap.Logger {
+	var fields []zap.Field
+	for _, field := range []struct{ key, value string }{{"org", owner}, {"repo", repo}, {"user", username}} {
+		if field.value != "" {
+			fields = append(fields, zap.String(field.key, field.value))
+		}
+	}
+	return zap.L().With(fields...)
+}
+
+// responseFields returns the HTTP status and request ID of a REST call, read
+// from the error when the request failed.
+func responseFields(resp *http.Response, err error) []zap.Field {
+	var status int
+	var header http.Header
+	var httpErr *api.HTTPError
+	switch {
+	case errors.As(err, &httpErr):
+		status, header = httpErr.StatusCode, httpErr.Headers
+	case resp != nil:
+		status, header = resp.StatusCode, resp.Header
+	default:
+		return nil
+	}
+	fields := []zap.Field{zap.Int("http_status", status)}
+	if requestID := header.Get(requestIDHeader); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	return fields
+}
+
+// logResponse logs a finished REST call at debug level with its error, if
+// any. Callers deciding a failure matters log it again at a higher level.
+func logResponse(logger *zap.Logger, msg string, resp *http.Response, err error) {
+	if err != nil {
+		logger.Debug(msg, errorFields(err)...)
+		return
+	}
+	logger.Debug(msg, responseFields(resp, nil)...)
+}
+
+// errorFields returns the fields logged with a failed REST call.
+func errorFields(err error) []zap.Field {
+	return append(responseFields(nil, err), zap.Error(err))
+}
+
+type requestIDKey struct{}
+
+// withRequestIDSink returns a context that captures the request ID of the
+// next REST call made with it, read through the returned pointer.
+func withRequestIDSink(ctx context.Context) (context.Context, *string) {
+	requestID := new(string)
+	return context.WithValue(ctx, requestIDKey{}, requestID), requestID
+}
+
+// captureRequestID stores the request ID of a response in the context's
+// sink, if it has one.
+func captureRequestID(ctx context.Context, resp *http.Response) {
+	if requestID, ok := ctx.Value(requestIDKey{}).(*string); ok && resp != nil {
+		*requestID = resp.Header.Get(requestIDHeader)
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.DebugLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	t.Cleanup(restore)
+	return logs
+}
+
+func TestGetterLogsStructuredFields(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "PUT", Path: "/repos/test-org/repo1/collaborators/alice"},
+			Response: replay.Response{Status: 201, Headers: map[string]string{"X-GitHub-Request-Id": "ABCD:1234"}, Body: []byte(`{}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "DELETE", Path: "/repos/test-org/repo1/collaborators/bob"},
+			Response: replay.Response{Status: 404, Headers: map[string]string{"X-GitHub-Request-Id": "ABCD:5678"}, Body: []byte(`{"message":"Not Found"}`)},
+		},
+	)
+	g := newReplayGetter(t, server)
+	logs := observeLogs(t)
+
+	if _, err := g.AddRepoCollaborator(context.Background(), "test-org", "repo1", "alice", bytes.NewReader([]byte(`{"permission":"push"}`))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := g.RemoveRepoCollaborator(context.Background(), "test-org", "repo1", "bob"); err == nil {
+		t.Fatal("Expected an error removing bob")
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("Expected a log entry per request, got %d", len(entries))
+	}
+	added := entries[0].ContextMap()
+	if added["org"] != "test-org" || added["repo"] != "repo1" || added["user"] != "alice" ||
+		added["http_status"] != int64(201) || added["request_id"] != "ABCD:1234" {
+		t.Errorf("Expected structured fields for the grant, got %v", added)
+	}
+	removed := entries[1].ContextMap()
+	if removed["user"] != "bob" || removed["http_status"] != int64(404) || removed["request_id"] != "ABCD:5678" || removed["error"] == nil {
+		t.Errorf("Expected the failed request's status, request ID and error, got %v", removed)
+	}
+}
+
+func TestGrantLoggerOmitsEmptyFields(t *testing.T) {
+	logs := observeLogs(t)
+	grantLogger("test-org", "", "alice").Info("message")
+
+	fields := logs.All()[0].ContextMap()
+	if _, ok := fields["repo"]; ok || fields["org"] != "test-org" || fields["user"] != "alice" {
+		t.Errorf("Expected only the org and user fields, got %v", fields)
+	}
+}
+
+func TestGrantRepoCollaboratorLogsPermission(t *testing.T) {
+	logs := observeLogs(t)
+	g := newFakePermissions(map[string]string{})
+	collab := data.ImportedRepoCollab{RepositoryName: "repo1", Username: "alice", Permission: "push"}
+
+	GrantRepoCollaborator(context.Background(), "test-org", collab, false, g)
+
+	assignments := logs.FilterMessage("Creating repository assignment").All()
+	if len(assignments) != 1 || assignments[0].ContextMap()["permission"] != "push" {
+		t.Errorf("Expected the new permission logged with the assignment, got %v", logs.All())
+	}
+}
+
+func TestGrantRepoCollaboratorRecordsRequestID(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/repos/test-org/repo1/collaborators/alice/permission"},
+			Response: replay.Response{Body: []byte(`{"permission":"none"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "PUT", Path: "/repos/test-org/repo1/collaborators/alice"},
+			Response: replay.Response{Status: 201, Headers: map[string]string{"X-GitHub-Request-Id": "ABCD:1234"}, Body: []byte(`{}`)},
+		},
+	)
+	g := newReplayGetter(t, server)
+
+	collab := data.ImportedRepoCollab{RepositoryName: "repo1", Username: "alice", Permission: "push"}
+	result := GrantRepoCollaborator(context.Background(), "test-org", collab, false, g)
+	if result.Status != StatusAdded || result.RequestID != "ABCD:1234" {
+		t.Errorf("Expected an added result with the request ID, got %+v", result)
+	}
+}
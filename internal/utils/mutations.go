@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/cache"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"go.uber.org/zap"
+)
+
+// RESTPermission maps role names read and write back to the pull and push
+// values the collaborators endpoint expects.
+func RESTPermission(permission string) string {
+	switch NormalizePermission(permission) {
+	case "read":
+		return "pull"
+	case "write":
+		return "push"
+	default:
+		return NormalizePermission(permission)
+	}
+}
+
+// DirectPermission returns the permission granted to the user directly on
+// the repository, none when they have no direct grant. Access through a team
+// or the base permission, and the read access everyone has to a public
+// repository, are not grants to skip, restore or remove. It is read from
+// GitHub, never the cache, since it decides the change made.
+func DirectPermission(ctx context.Context, owner string, repo string, username string, g Getter) (string, error) {
+	ctx = cache.Live(ctx)
+	// The effective permission is read first: a missing repository or user is
+	// an error rather than someone without a grant, and users without any
+	// access need no listing of the direct collaborators
+	permission, err := g.GetRepoCollaboratorPermission(ctx, owner, repo, username)
+	if err != nil || permission == PermissionNone {
+		return permission, err
+	}
+	collaborators, err := g.ListRepoCollaborators(ctx, owner, repo, "direct")
+	if err != nil {
+		return "", err
+	}
+	for _, collaborator := range collaborators {
+		if strings.EqualFold(collaborator.Login, username) {
+			return collaborator.RoleName, nil
+		}
+	}
+	return PermissionNone, nil
+}
+
+// GrantRepoCollaborator compares the requested permission with the current
+// one before changing anything, so reruns of the same file are no-ops and a
+// lower permission cannot silently replace a higher one.
+func GrantRepoCollaborator(ctx context.Context, owner string, collab data.ImportedRepoCollab, allowDowngrade bool, g Getter) data.MutationResult {
+	// A blank permission is not none, GitHub grants its default for it
+	if strings.TrimSpace(collab.Permission) == "" {
+		collab.Permission = DefaultGrantPermission
+	}
+	result := data.MutationResult{
+		RepositoryName: collab.RepositoryName,
+		Username:       collab.Username,
+		Permission:     collab.Permission,
+	}
+
+	logger := grantLogger(owner, collab.RepositoryName, collab.Username)
+	current, err := DirectPermission(ctx, owner, collab.RepositoryName, collab.Username, g)
+	if err != nil {
+		logger.Error("Error arose reading permission", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.PreviousPermission = current
+
+	desired := NormalizePermission(collab.Permission)
+	if current == desired {
+		logger.Debug("Collaborator already has permission", zap.String("permission", current))
+		result.Status = StatusSkipped
+		result.Reason = fmt.Sprintf("already has %s", current)
+		return result
+	}
+	if downgrade, reason := IsDowngrade(current, desired); downgrade && !allowDowngrade {
+		logger.Warn("Skipping downgrade", zap.String("permission", current), zap.String("new_permission", desired), zap.String("reason", reason))
+		result.Status = StatusBlocked
+		result.Reason = reason + ", use --allow-downgrade to apply"
+		return result
+	}
+
+	return putRepoCollaborator(ctx, owner, current, result, g)
+}
+
+// RevokeRepoCollaborator removes a collaborator from a repository, recording
+// the permission they held so the removal can be rolled back.
+func RevokeRepoCollaborator(ctx context.Context, owner string, collab data.ImportedRepoCollab, g Getter) data.MutationResult {
+	result := data.MutationResult{
+		RepositoryName: collab.RepositoryName,
+		Username:       collab.Username,
+		Permission:     PermissionNone,
+	}
+
+	current, err := DirectPermission(ctx, owner, collab.RepositoryName, collab.Username, g)
+	if err != nil {
+		grantLogger(owner, collab.RepositoryName, collab.Username).Error("Error arose reading permission", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.PreviousPermission = current
+
+	if current == PermissionNone {
+		result.Status = StatusSkipped
+		result.Reason = "not a collaborator"
+		return result
+	}
+	return deleteRepoCollaborator(ctx, owner, result, g)
+}
+
+// RestoreRepoCollaborator sets a collaborator back to target, but only when
+// their permission is still expected, the value a previous run left behind.
+// Anything changed since is reported as skipped rather than overwritten.
+func RestoreRepoCollaborator(ctx context.Context, owner string, repo string, username string, expected string, target string, g Getter) data.MutationResult {
+	result := data.MutationResult{
+		RepositoryName: repo,
+		Username:       username,
+		Permission:     NormalizePermission(target),
+	}
+
+	current, err := DirectPermission(ctx, owner, repo, username, g)
+	if err != nil {
+		grantLogger(owner, repo, username).Error("Error arose reading permission", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.PreviousPermission = current
+
+	switch {
+	case current == result.Permission:
+		result.Status = StatusSkipped
+		result.Reason = fmt.Sprintf("already has %s", current)
+		return result
+	case current != NormalizePermission(expected):
+		result.Status = StatusSkipped
+		result.Reason = fmt.Sprintf("changed since run, now %s", current)
+		return result
+	case result.Permission == PermissionNone:
+		return deleteRepoCollaborator(ctx, owner, result, g)
+	default:
+		result.Permission = RESTPermission(target)
+		return putRepoCollaborator(ctx, owner, current, result, g)
+	}
+}
+
+// WithdrawRepoInvitation undoes a grant that invited the user rather than
+// adding them. A pending invitation is deleted; once accepted the user is a
+// collaborator and the grant is restored like any other.
+func WithdrawRepoInvitation(ctx context.Context, owner string, repo string, username string, expected string, invitationID int64, g Getter) data.MutationResult {
+	result := data.MutationResult{
+		RepositoryName: repo,
+		Username:       username,
+		Permission:     PermissionNone,
+	}
+	logger := grantLogger(owner, repo, username).With(zap.Int64("invitation_id", invitationID))
+
+	invitations, err := g.ListRepoInvitations(cache.Live(ctx), owner, repo)
+	if err != nil {
+		logger.Error("Error arose reading invitations", errorFields(err)...)
+		return failed(result, err)
+	}
+	for _, invitation := range invitations {
+		if invitation.ID != invitationID {
+			continue
+		}
+		result.PreviousPermission = NormalizePermission(invitation.Permissions)
+		logger.Debug("Withdrawing repository invitation")
+		ctx, requestID := withRequestIDSink(ctx)
+		if err := g.RemoveRepoInvitation(ctx, owner, repo, invitationID); err != nil {
+			logger.Error("Error arose withdrawing invitation", errorFields(err)...)
+			return failed(result, err)
+		}
+		result.RequestID = *requestID
+		result.Status = StatusRemoved
+		result.Reason = "invitation withdrawn"
+		return result
+	}
+	return RestoreRepoCollaborator(ctx, owner, repo, username, expected, PermissionNone, g)
+}
+
+func putRepoCollaborator(ctx context.Context, owner string, current string, result data.MutationResult, g Getter) data.MutationResult {
+	assignRepo, err := json.Marshal(g.CreateRepoPermData(result.Permission))
+	if err != nil {
+		return failed(result, err)
+	}
+	logger := grantLogger(owner, result.RepositoryName, result.Username).With(zap.String("permission", result.Permission))
+	logger.Debug("Creating repository assignment")
+
+	ctx, requestID := withRequestIDSink(ctx)
+	invitation, err := g.AddRepoCollaborator(ctx, owner, result.RepositoryName, result.Username, bytes.NewReader(assignRepo))
+	if err != nil {
+		logger.Error("Error arose creating permission", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.RequestID = *requestID
+	if invitation != nil {
+		result.InvitationID = invitation.ID
+		result.Reason = "invited"
+	}
+
+	if current == PermissionNone {
+		result.Status = StatusAdded
+	} else {
+		result.Status = StatusUpdated
+	}
+	return result
+}
+
+func deleteRepoCollaborator(ctx context.Context, owner string, result data.MutationResult, g Getter) data.MutationResult {
+	logger := grantLogger(owner, result.RepositoryName, result.Username).With(zap.String("permission", result.PreviousPermission))
+	logger.Debug("Removing repository assignment")
+	ctx, requestID := withRequestIDSink(ctx)
+	err := g.RemoveRepoCollaborator(ctx, owner, result.RepositoryName, result.Username)
+	if err != nil {
+		logger.Error("Error arose removing permission", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.RequestID = *requestID
+	result.Status = StatusRemoved
+	return result
+}
+
+// InviteOrgMember invites an outside collaborator to become a member of the
+// organization and of the given teams.
+func InviteOrgMember(ctx context.Context, owner string, username string, inviteeID int, teamIDs []int, g Getter) data.MutationResult {
+	result := data.MutationResult{
+		Username:           username,
+		Permission:         RoleMember,
+		PreviousPermission: RoleOutside,
+		Reason:             "invited",
+	}
+	logger := grantLogger(owner, "", username)
+	logger.Debug("Inviting to organization")
+	ctx, requestID := withRequestIDSink(ctx)
+	if err := g.CreateOrgInvitation(ctx, owner, inviteeID, teamIDs); err != nil {
+		logger.Error("Error arose inviting to organization", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.RequestID = *requestID
+	result.Status = StatusAdded
+	return result
+}
+
+// AddTeamMembership adds an organization member to a team.
+func AddTeamMembership(ctx context.Context, owner string, teamSlug string, username string, g Getter) data.MutationResult {
+	result := data.MutationResult{
+		Username:           username,
+		Team:               teamSlug,
+		Permission:         RoleMember,
+		PreviousPermission: PermissionNone,
+	}
+	logger := grantLogger(owner, "", username).With(zap.String("team", teamSlug))
+	logger.Debug("Adding team membership")
+	ctx, requestID := withRequestIDSink(ctx)
+	if err := g.AddTeamMember(ctx, owner, teamSlug, username); err != nil {
+		logger.Error("Error arose adding team membership", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.RequestID = *requestID
+	result.Status = StatusAdded
+	return result
+}
+
+// ConvertOrgMember converts an organization member holding role to an
+// outside collaborator.
+func ConvertOrgMember(ctx context.Context, owner string, username string, role string, g Getter) data.MutationResult {
+	result := data.MutationResult{
+		Username:           username,
+		Permission:         RoleOutside,
+		PreviousPermission: role,
+	}
+	logger := grantLogger(owner, "", username)
+	logger.Debug("Converting to outside collaborator")
+	ctx, requestID := withRequestIDSink(ctx)
+	if err := g.ConvertToOutsideCollaborator(ctx, owner, username); err != nil {
+		logger.Error("Error arose converting to outside collaborator", errorFields(err)...)
+		return failed(result, err)
+	}
+	result.RequestID = *requestID
+	result.Status = StatusUpdated
+	return result
+}
+
+func failed(result data.MutationResult, err error) data.MutationResult {
+	result.Status = StatusFailed
+	result.Reason = err.Error()
+	result.Err = err
+	return result
+}
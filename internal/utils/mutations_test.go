@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+// fakePermissions is a Getter backed by a map of repo/user to permission.
+// The permission is also the direct grant unless direct says otherwise, as
+// for public repositories or access through a team.
+type fakePermissions struct {
+	*APIGetter
+	permissions map[string]string
+	direct      map[string]string
+	calls       []string
+}
+
+func newFakePermissions(permissions map[string]string) *fakePermissions {
+	return &fakePermissions{APIGetter: &APIGetter{}, permissions: permissions}
+}
+
+func (f *fakePermissions) GetRepoCollaboratorPermission(ctx context.Context, owner string, repo string, username string) (string, error) {
+	if repo == "missing" {
+		return "", fmt.Errorf("HTTP 404: Not Found")
+	}
+	if permission, ok := f.permissions[repo+"/"+username]; ok {
+		return permission, nil
+	}
+	return PermissionNone, nil
+}
+
+func (f *fakePermissions) ListRepoCollaborators(ctx context.Context, owner string, repo string, affiliation string) ([]data.RepoCollaborator, error) {
+	var collaborators []data.RepoCollaborator
+	for key, permission := range f.permissions {
+		if direct, ok := f.direct[key]; ok {
+			permission = direct
+		}
+		if login, found := strings.CutPrefix(key, repo+"/"); found && permission != PermissionNone {
+			collaborators = append(collaborators, data.RepoCollaborator{Login: login, RoleName: permission})
+		}
+	}
+	return collaborators, nil
+}
+
+func (f *fakePermissions) AddRepoCollaborator(ctx context.Context, owner string, repo string, username string, body io.Reader) (*data.RepoInvitation, error) {
+	content, _ := io.ReadAll(body)
+	f.calls = append(f.calls, fmt.Sprintf("PUT %s/%s %s", repo, username, content))
+	if repo == "invite" {
+		return &data.RepoInvitation{ID: 7, Invitee: data.User{Login: username}}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakePermissions) RemoveRepoCollaborator(ctx context.Context, owner string, repo string, username string) error {
+	f.calls = append(f.calls, fmt.Sprintf("DELETE %s/%s", repo, username))
+	return nil
+}
+
+func TestRESTPermission(t *testing.T) {
+	tests := map[string]string{
+		"read":     "pull",
+		"write":    "push",
+		"pull":     "pull",
+		"Maintain": "maintain",
+		"security": "security",
+	}
+
+	for input, expected := range tests {
+		if got := RESTPermission(input); got != expected {
+			t.Errorf("Expected RESTPermission(%q) to be %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestGrantRepoCollaborator(t *testing.T) {
+	tests := []struct {
+		name           string
+		collab         data.ImportedRepoCollab
+		allowDowngrade bool
+		status         string
+		calls          int
+	}{
+		{name: "new", collab: data.ImportedRepoCollab{RepositoryName: "repo1", Username: "user1", Permission: "push"}, status: StatusAdded, calls: 1},
+		{name: "upgrade", collab: data.ImportedRepoCollab{RepositoryName: "repo2", Username: "user1", Permission: "admin"}, status: StatusUpdated, calls: 1},
+		{name: "identical", collab: data.ImportedRepoCollab{RepositoryName: "repo2", Username: "user1", Permission: "push"}, status: StatusSkipped},
+		{name: "downgrade", collab: data.ImportedRepoCollab{RepositoryName: "repo3", Username: "user1", Permission: "pull"}, status: StatusBlocked},
+		{name: "allowed downgrade", collab: data.ImportedRepoCollab{RepositoryName: "repo3", Username: "user1", Permission: "pull"}, allowDowngrade: true, status: StatusUpdated, calls: 1},
+		{name: "read error", collab: data.ImportedRepoCollab{RepositoryName: "missing", Username: "user1", Permission: "pull"}, status: StatusFailed},
+		{name: "public repository", collab: data.ImportedRepoCollab{RepositoryName: "public", Username: "user1", Permission: "pull"}, status: StatusAdded, calls: 1},
+		// admin through a team neither blocks a lower direct grant nor hides
+		// one already made
+		{name: "team access only", collab: data.ImportedRepoCollab{RepositoryName: "team", Username: "user1", Permission: "push"}, status: StatusAdded, calls: 1},
+		{name: "team access over direct grant", collab: data.ImportedRepoCollab{RepositoryName: "team", Username: "user2", Permission: "push"}, status: StatusSkipped},
+		{name: "direct upgrade under team access", collab: data.ImportedRepoCollab{RepositoryName: "team", Username: "user2", Permission: "maintain"}, status: StatusUpdated, calls: 1},
+		{name: "blank permission", collab: data.ImportedRepoCollab{RepositoryName: "repo1", Username: "user1", Permission: " "}, status: StatusAdded, calls: 1},
+		{name: "blank permission already held", collab: data.ImportedRepoCollab{RepositoryName: "repo2", Username: "user1"}, status: StatusSkipped},
+		{name: "invitation", collab: data.ImportedRepoCollab{RepositoryName: "invite", Username: "user1", Permission: "pull"}, status: StatusAdded, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newFakePermissions(map[string]string{"repo2/user1": "write", "repo3/user1": "admin", "public/user1": "read", "team/user1": "admin", "team/user2": "admin"})
+			g.direct = map[string]string{"public/user1": PermissionNone, "team/user1": PermissionNone, "team/user2": "write"}
+			result := GrantRepoCollaborator(context.Background(), "test-org", tt.collab, tt.allowDowngrade, g)
+			if result.Status != tt.status {
+				t.Errorf("Expected status %s, got %s (%s)", tt.status, result.Status, result.Reason)
+			}
+			if len(g.calls) != tt.calls {
+				t.Errorf("Expected %d mutations, got %v", tt.calls, g.calls)
+			}
+			if tt.name == "blank permission" && g.calls[0] != `PUT repo1/user1 {"permission":"push"}` {
+				t.Errorf("Expected GitHub's default permission to be granted, got %v", g.calls)
+			}
+			// An invitation is kept so rollback can withdraw it
+			if invited := tt.collab.RepositoryName == "invite"; invited != (result.InvitationID == 7) {
+				t.Errorf("Expected invitation ID only for invitations, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestRevokeRepoCollaborator(t *testing.T) {
+	g := newFakePermissions(map[string]string{"repo1/user1": "maintain"})
+
+	result := RevokeRepoCollaborator(context.Background(), "test-org", data.ImportedRepoCollab{RepositoryName: "repo1", Username: "user1"}, g)
+	if result.Status != StatusRemoved || result.PreviousPermission != "maintain" {
+		t.Errorf("Expected removal recording previous permission, got %+v", result)
+	}
+
+	result = RevokeRepoCollaborator(context.Background(), "test-org", data.ImportedRepoCollab{RepositoryName: "repo2", Username: "user1"}, g)
+	if result.Status != StatusSkipped {
+		t.Errorf("Expected non collaborator to be skipped, got %+v", result)
+	}
+
+	// Reading a public repository is not a grant to remove
+	g.permissions["public/user1"] = "read"
+	g.permissions["team/user1"] = "admin"
+	g.direct = map[string]string{"public/user1": PermissionNone, "team/user1": PermissionNone}
+	result = RevokeRepoCollaborator(context.Background(), "test-org", data.ImportedRepoCollab{RepositoryName: "public", Username: "user1"}, g)
+	if result.Status != StatusSkipped {
+		t.Errorf("Expected public repository reader to be skipped, got %+v", result)
+	}
+	// Nor is access through a team
+	result = RevokeRepoCollaborator(context.Background(), "test-org", data.ImportedRepoCollab{RepositoryName: "team", Username: "user1"}, g)
+	if result.Status != StatusSkipped {
+		t.Errorf("Expected team member without a direct grant to be skipped, got %+v", result)
+	}
+
+	if len(g.calls) != 1 || g.calls[0] != "DELETE repo1/user1" {
+		t.Errorf("Expected a single delete, got %v", g.calls)
+	}
+}
+
+func TestRestoreRepoCollaborator(t *testing.T) {
+	g := newFakePermissions(map[string]string{"repo1/user1": "write", "repo2/user1": "admin"})
+
+	// Restoring an upgrade puts back the previous permission by its REST name
+	result := RestoreRepoCollaborator(context.Background(), "test-org", "repo1", "user1", "push", "read", g)
+	if result.Status != StatusUpdated || result.Permission != "pull" {
+		t.Errorf("Expected restore to pull, got %+v", result)
+	}
+
+	// A collaborator changed since the run is left alone
+	result = RestoreRepoCollaborator(context.Background(), "test-org", "repo2", "user1", "push", "read", g)
+	if result.Status != StatusSkipped {
+		t.Errorf("Expected changed collaborator to be skipped, got %+v", result)
+	}
+
+	// Restoring a new grant removes it
+	result = RestoreRepoCollaborator(context.Background(), "test-org", "repo1", "user1", "write", PermissionNone, g)
+	if result.Status != StatusRemoved {
+		t.Errorf("Expected grant to be removed, got %+v", result)
+	}
+
+	// Restoring a removal re-adds the collaborator
+	result = RestoreRepoCollaborator(context.Background(), "test-org", "repo3", "user1", PermissionNone, "maintain", g)
+	if result.Status != StatusAdded || result.Permission != "maintain" {
+		t.Errorf("Expected collaborator to be re-added, got %+v", result)
+	}
+
+	expected := []string{`PUT repo1/user1 {"permission":"pull"}`, "DELETE repo1/user1", `PUT repo3/user1 {"permission":"maintain"}`}
+	if fmt.Sprint(g.calls) != fmt.Sprint(expected) {
+		t.Errorf("Expected calls %v, got %v", expected, g.calls)
+	}
+}
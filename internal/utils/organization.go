@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"go.uber.org/zap"
+)
+
+// restPageSize is the largest page the REST API returns
+const restPageSize = 100
+
+func (g *APIGetter) GetUser(ctx context.Context, username string) (*data.User, error) {
+	user := new(data.User)
+	err := g.restClient.DoWithContext(ctx, "GET", fmt.Sprintf("users/%s", username), nil, user)
+	logResponse(grantLogger("", "", username), "Read user", nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetAuthenticatedUser returns the user the token belongs to.
+func (g *APIGetter) GetAuthenticatedUser(ctx context.Context) (*data.User, error) {
+	user := new(data.User)
+	err := g.restClient.DoWithContext(ctx, "GET", "user", nil, user)
+	logResponse(zap.L(), "Read authenticated user", nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (g *APIGetter) GetOrganization(ctx context.Context, owner string) (*data.Organization, error) {
+	org := new(data.Organization)
+	err := g.restClient.DoWithContext(ctx, "GET", fmt.Sprintf("orgs/%s", owner), nil, org)
+	logResponse(grantLogger(owner, "", ""), "Read organization", nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrgMembership returns the user's membership of the organization, nil
+// when they are neither a member nor invited.
+func (g *APIGetter) GetOrgMembership(ctx context.Context, owner string, username string) (*data.OrgMembership, error) {
+	membership := new(data.OrgMembership)
+	err := g.restClient.DoWithContext(ctx, "GET", fmt.Sprintf("orgs/%s/memberships/%s", owner, username), nil, membership)
+	logResponse(grantLogger(owner, "", username), "Read organization membership", nil, err)
+	var httpErr *api.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// CreateOrgInvitation invites a user to the organization as a member, they
+// join the given teams once they accept.
+func (g *APIGetter) CreateOrgInvitation(ctx context.Context, owner string, inviteeID int, teamIDs []int) error {
+	body, err := json.Marshal(data.OrgInvitation{InviteeID: inviteeID, Role: "direct_member", TeamIDs: teamIDs})
+	if err != nil {
+		return err
+	}
+	logger := grantLogger(owner, "", "").With(zap.Int("invitee_id", inviteeID), zap.Ints("team_ids", teamIDs))
+	return g.mutateOrg(ctx, logger, "Invited user", "POST", fmt.Sprintf("orgs/%s/invitations", owner), bytes.NewReader(body))
+}
+
+func (g *APIGetter) CreateIssue(ctx context.Context, owner string, repo string, title string, body string) (*data.Issue, error) {
+	content, err := json.Marshal(data.Issue{Title: title, Body: body})
+	if err != nil {
+		return nil, err
+	}
+	issue := new(data.Issue)
+	err = g.restClient.DoWithContext(ctx, "POST", fmt.Sprintf("repos/%s/%s/issues", owner, repo), bytes.NewReader(content), issue)
+	logResponse(grantLogger(owner, repo, ""), "Created issue", nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+func (g *APIGetter) CreateIssueComment(ctx context.Context, owner string, repo string, number int, body string) (*data.IssueComment, error) {
+	content, err := json.Marshal(data.IssueComment{Body: body})
+	if err != nil {
+		return nil, err
+	}
+	comment := new(data.IssueComment)
+	err = g.restClient.DoWithContext(ctx, "POST", fmt.Sprintf("repos/%s/%s/issues/%d/comments", owner, repo, number), bytes.NewReader(content), comment)
+	logResponse(grantLogger(owner, repo, "").With(zap.Int("issue", number)), "Created issue comment", nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (g *APIGetter) AddTeamMember(ctx context.Context, owner string, teamSlug string, username string) error {
+	body := bytes.NewReader([]byte(`{"role":"member"}`))
+	logger := grantLogger(owner, "", username).With(zap.String("team", teamSlug))
+	return g.mutateOrg(ctx, logger, "Added team member", "PUT", fmt.Sprintf("orgs/%s/teams/%s/memberships/%s", owner, teamSlug, username), body)
+}
+
+// ConvertToOutsideCollaborator removes a member from the organization while
+// keeping the repository access their teams gave them as direct grants.
+func (g *APIGetter) ConvertToOutsideCollaborator(ctx context.Context, owner string, username string) error {
+	return g.mutateOrg(ctx, grantLogger(owner, "", username), "Converted to outside collaborator", "PUT", fmt.Sprintf("orgs/%s/outside_collaborators/%s", owner, username), nil)
+}
+
+// mutateOrg makes a membership change, capturing its request ID for the
+// journal like the repository grants do.
+func (g *APIGetter) mutateOrg(ctx context.Context, logger *zap.Logger, msg string, method string, path string, body io.Reader) error {
+	resp, err := g.restClient.RequestWithContext(ctx, method, path, body)
+	logResponse(logger, msg, resp, err)
+	if err != nil {
+		return err
+	}
+	captureRequestID(ctx, resp)
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		logger.Warn("Error closing response body", zap.Error(closeErr))
+	}
+	return nil
+}
+
+// ListOrgAuditLog returns the organization's audit log events matching an
+// audit log search phrase, such as action:repo.add_member.
+func (g *APIGetter) ListOrgAuditLog(ctx context.Context, owner string, phrase string) ([]data.AuditLogEvent, error) {
+	return getPages[data.AuditLogEvent](ctx, g.restClient, fmt.Sprintf("orgs/%s/audit-log?phrase=%s", owner, url.QueryEscape(phrase)))
+}
+
+// ListOrgMembers returns every member of the organization, paging through
+// them.
+func (g *APIGetter) ListOrgMembers(ctx context.Context, owner string) ([]data.User, error) {
+	return getPages[data.User](ctx, g.restClient, fmt.Sprintf("orgs/%s/members", owner))
+}
+
+// ListOrgOutsideCollaborators returns every outside collaborator of the
+// organization, paging through them.
+func (g *APIGetter) ListOrgOutsideCollaborators(ctx context.Context, owner string) ([]data.User, error) {
+	return getPages[data.User](ctx, g.restClient, fmt.Sprintf("orgs/%s/outside_collaborators", owner))
+}
+
+func (g *APIGetter) ListOrgTeams(ctx context.Context, owner string) ([]data.Team, error) {
+	return getPages[data.Team](ctx, g.restClient, fmt.Sprintf("orgs/%s/teams", owner))
+}
+
+func (g *APIGetter) ListTeamRepositories(ctx context.Context, owner string, teamSlug string) ([]data.TeamRepository, error) {
+	return getPages[data.TeamRepository](ctx, g.restClient, fmt.Sprintf("orgs/%s/teams/%s/repos", owner, teamSlug))
+}
+
+// ListRepoCollaborators lists a repository's collaborators with the given
+// affiliation: outside, direct or all.
+func (g *APIGetter) ListRepoCollaborators(ctx context.Context, owner string, repo string, affiliation string) ([]data.RepoCollaborator, error) {
+	collaborators, err := getPages[data.RepoCollaborator](ctx, g.restClient, fmt.Sprintf("repos/%s/%s/collaborators?affiliation=%s", owner, repo, affiliation))
+	if err != nil {
+		return nil, err
+	}
+	for i, collaborator := range collaborators {
+		collaborators[i].RoleName = collaboratorRole(collaborator)
+	}
+	return collaborators, nil
+}
+
+// collaboratorRole returns the collaborator's role, read from the
+// permissions flags on servers that do not return role_name.
+func collaboratorRole(collaborator data.RepoCollaborator) string {
+	if collaborator.RoleName != "" {
+		return NormalizePermission(collaborator.RoleName)
+	}
+	for _, permission := range []string{"admin", "maintain", "push", "triage", "pull"} {
+		if collaborator.Permissions[permission] {
+			return NormalizePermission(permission)
+		}
+	}
+	return PermissionNone
+}
+
+// getPages requests pages of a REST list until one comes back short.
+func getPages[T any](ctx context.Context, client api.RESTClient, url string) ([]T, error) {
+	var items []T
+	for page := 1; ; page++ {
+		var pageItems []T
+		separator := "?"
+		if strings.Contains(url, "?") {
+			separator = "&"
+		}
+		pageURL := fmt.Sprintf("%s%sper_page=%d&page=%d", url, separator, restPageSize, page)
+		err := client.DoWithContext(ctx, "GET", pageURL, nil, &pageItems)
+		logResponse(zap.L().With(zap.String("url", pageURL)), "Read page", nil, err)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pageItems...)
+		if len(pageItems) < restPageSize {
+			return items, nil
+		}
+	}
+}
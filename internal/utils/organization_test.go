@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+)
+
+func newReplayGetter(t *testing.T, server *replay.Server) *APIGetter {
+	t.Helper()
+	restClient, err := api.NewRESTClient(api.ClientOptions{Host: "github.com", AuthToken: "test-token", Transport: server.Transport()})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return NewAPIGetter(nil, restClient)
+}
+
+func TestGetOrgMembership(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/alice"},
+			Response: replay.Response{Body: []byte(`{"state":"pending","role":"member"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/bob"},
+			Response: replay.Response{Status: 404, Body: []byte(`{"message":"Not Found"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/carol"},
+			Response: replay.Response{Status: 500, Body: []byte(`{"message":"Server Error"}`)},
+		},
+	)
+	g := newReplayGetter(t, server)
+	ctx := context.Background()
+
+	membership, err := g.GetOrgMembership(ctx, "test-org", "alice")
+	if err != nil || membership == nil || membership.State != "pending" {
+		t.Errorf("Expected pending membership, got %+v, %v", membership, err)
+	}
+	membership, err = g.GetOrgMembership(ctx, "test-org", "bob")
+	if err != nil || membership != nil {
+		t.Errorf("Expected no membership, got %+v, %v", membership, err)
+	}
+	if _, err := g.GetOrgMembership(ctx, "test-org", "carol"); err == nil {
+		t.Error("Expected server error")
+	}
+}
+
+func TestOrganizationRequests(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/user"},
+			Response: replay.Response{Body: []byte(`{"login":"admin","id":1}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/users/alice"},
+			Response: replay.Response{Body: []byte(`{"login":"alice","id":42}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org"},
+			Response: replay.Response{Body: []byte(`{"login":"test-org","default_repository_permission":"read"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "POST", Path: "/orgs/test-org/invitations", Body: []byte(`{"invitee_id":42,"role":"direct_member","team_ids":[7]}`)},
+			Response: replay.Response{Status: 201, Body: []byte(`{"id":1}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "PUT", Path: "/orgs/test-org/teams/dev/memberships/alice", Body: []byte(`{"role":"member"}`)},
+			Response: replay.Response{Body: []byte(`{"state":"active"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "PUT", Path: "/orgs/test-org/outside_collaborators/alice"},
+			Response: replay.Response{Status: 204},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "POST", Path: "/repos/test-org/repo1/issues", Body: []byte(`{"title":"Review access","body":"alice has admin"}`)},
+			Response: replay.Response{Status: 201, Body: []byte(`{"number":7,"title":"Review access","html_url":"https://github.com/test-org/repo1/issues/7"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "POST", Path: "/repos/test-org/repo1/issues/7/comments", Body: []byte(`{"body":"alice was removed"}`)},
+			Response: replay.Response{Status: 201, Body: []byte(`{"id":99,"body":"alice was removed"}`)},
+		},
+	)
+	g := newReplayGetter(t, server)
+	ctx := context.Background()
+
+	actor, err := g.GetAuthenticatedUser(ctx)
+	if err != nil || actor.Login != "admin" {
+		t.Errorf("Expected authenticated user admin, got %+v, %v", actor, err)
+	}
+	user, err := g.GetUser(ctx, "alice")
+	if err != nil || user.ID != 42 {
+		t.Errorf("Expected user 42, got %+v, %v", user, err)
+	}
+	org, err := g.GetOrganization(ctx, "test-org")
+	if err != nil || org.DefaultRepositoryPermission != "read" {
+		t.Errorf("Expected base permission read, got %+v, %v", org, err)
+	}
+	if err := g.CreateOrgInvitation(ctx, "test-org", 42, []int{7}); err != nil {
+		t.Errorf("Expected invitation, got %v", err)
+	}
+	if err := g.AddTeamMember(ctx, "test-org", "dev", "alice"); err != nil {
+		t.Errorf("Expected team membership, got %v", err)
+	}
+	if err := g.ConvertToOutsideCollaborator(ctx, "test-org", "alice"); err != nil {
+		t.Errorf("Expected conversion, got %v", err)
+	}
+	issue, err := g.CreateIssue(ctx, "test-org", "repo1", "Review access", "alice has admin")
+	if err != nil || issue.Number != 7 {
+		t.Errorf("Expected issue 7, got %+v, %v", issue, err)
+	}
+	comment, err := g.CreateIssueComment(ctx, "test-org", "repo1", 7, "alice was removed")
+	if err != nil || comment.ID != 99 {
+		t.Errorf("Expected comment 99, got %+v, %v", comment, err)
+	}
+	server.AssertAllUsed()
+}
+
+func TestListOrgTeamsPaginates(t *testing.T) {
+	var firstPage []string
+	for i := 0; i < restPageSize; i++ {
+		firstPage = append(firstPage, fmt.Sprintf(`{"id":%d,"slug":"team-%d"}`, i, i))
+	}
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/teams", Query: "per_page=100&page=1"},
+			Response: replay.Response{Body: []byte("[" + strings.Join(firstPage, ",") + "]")},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/teams", Query: "per_page=100&page=2"},
+			Response: replay.Response{Body: []byte(`[{"id":100,"slug":"last"}]`)},
+		},
+	)
+	g := newReplayGetter(t, server)
+
+	teams, err := g.ListOrgTeams(context.Background(), "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(teams) != restPageSize+1 || teams[restPageSize].Slug != "last" {
+		t.Errorf("Expected both pages of teams, got %d", len(teams))
+	}
+	server.AssertAllUsed()
+}
+
+func TestListOrgOutsideCollaboratorsPaginates(t *testing.T) {
+	var firstPage []string
+	for i := 0; i < restPageSize; i++ {
+		firstPage = append(firstPage, fmt.Sprintf(`{"id":%d,"login":"user-%d"}`, i, i))
+	}
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/outside_collaborators", Query: "per_page=100&page=1"},
+			Response: replay.Response{Body: []byte("[" + strings.Join(firstPage, ",") + "]")},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/outside_collaborators", Query: "per_page=100&page=2"},
+			Response: replay.Response{Body: []byte(`[{"id":100,"login":"last"}]`)},
+		},
+	)
+	g := newReplayGetter(t, server)
+
+	users, err := g.ListOrgOutsideCollaborators(context.Background(), "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(users) != restPageSize+1 || users[restPageSize].Login != "last" {
+		t.Errorf("Expected both pages of outside collaborators, got %d", len(users))
+	}
+	server.AssertAllUsed()
+}
+
+func TestListOrgMembers(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/members", Query: "per_page=100&page=1"},
+		Response: replay.Response{Body: []byte(`[{"id":1,"login":"dave"}]`)},
+	})
+	g := newReplayGetter(t, server)
+
+	users, err := g.ListOrgMembers(context.Background(), "test-org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(users) != 1 || users[0].Login != "dave" {
+		t.Errorf("Expected dave, got %v", users)
+	}
+	server.AssertAllUsed()
+}
+
+func TestListRepoCollaborators(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request: replay.Request{Method: "GET", Path: "/repos/test-org/repo1/collaborators", Query: "affiliation=outside&per_page=100&page=1"},
+		Response: replay.Response{Body: []byte(`[
+			{"login": "alice", "id": 1, "role_name": "write", "permissions": {"push": true, "pull": true}},
+			{"login": "bob", "id": 2, "permissions": {"triage": true, "pull": true}}
+		]`)},
+	})
+	g := newReplayGetter(t, server)
+
+	collaborators, err := g.ListRepoCollaborators(context.Background(), "test-org", "repo1", "outside")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(collaborators) != 2 || collaborators[0].RoleName != "write" || collaborators[1].RoleName != "triage" {
+		t.Errorf("Expected roles read from role_name or permissions, got %+v", collaborators)
+	}
+	server.AssertAllUsed()
+}
+
+func TestListOrgAuditLog(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/audit-log", Query: "phrase=action%3Arepo.add_member+created%3A%3E%3D2026-01-01&per_page=100&page=1"},
+		Response: replay.Response{Body: []byte(`[{"action":"repo.add_member","actor":"admin","user":"alice","repo":"test-org/repo1","permission":"write","@timestamp":1767225600000}]`)},
+	})
+	g := newReplayGetter(t, server)
+
+	events, err := g.ListOrgAuditLog(context.Background(), "test-org", "action:repo.add_member created:>=2026-01-01")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(events) != 1 || events[0].User != "alice" || events[0].Repo != "test-org/repo1" || events[0].Timestamp != 1767225600000 {
+		t.Errorf("Expected one add_member event for alice, got %+v", events)
+	}
+	server.AssertAllUsed()
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+const (
+	PermissionNone = "none"
+	// DefaultGrantPermission is what GitHub grants when a collaborator is
+	// added without a permission
+	DefaultGrantPermission = "push"
+
+	// Organization and team roles journaled for membership changes, which
+	// carry no repository
+	RoleMember  = "member"
+	RoleOutside = "outside_collaborator"
+
+	StatusAdded   = "added"
+	StatusUpdated = "updated"
+	StatusRemoved = "removed"
+	StatusSkipped = "skipped"
+	StatusBlocked = "blocked"
+	StatusFailed  = "failed"
+)
+
+// Rank of the built in repository roles, custom roles are not ranked.
+var permissionRanks = map[string]int{
+	PermissionNone: 0,
+	"read":         1,
+	"triage":       2,
+	"write":        3,
+	"maintain":     4,
+	"admin":        5,
+}
+
+// NormalizePermission maps the REST API aliases pull and push to the role
+// names returned when reading permissions.
+func NormalizePermission(permission string) string {
+	permission = strings.ToLower(strings.TrimSpace(permission))
+	switch permission {
+	case "pull":
+		return "read"
+	case "push":
+		return "write"
+	case "":
+		return PermissionNone
+	default:
+		return permission
+	}
+}
+
+// IsBuiltInPermission reports whether permission is none or one of the
+// built in repository roles.
+func IsBuiltInPermission(permission string) bool {
+	_, ok := permissionRanks[NormalizePermission(permission)]
+	return ok
+}
+
+// CoversPermission reports whether having one permission gives at least the
+// access of another. Custom roles only cover themselves.
+func CoversPermission(have string, want string) bool {
+	have = NormalizePermission(have)
+	want = NormalizePermission(want)
+	if have == want {
+		return true
+	}
+	haveRank, haveKnown := permissionRanks[have]
+	wantRank, wantKnown := permissionRanks[want]
+	return haveKnown && wantKnown && haveRank >= wantRank
+}
+
+// IsDowngrade reports whether moving from current to desired lowers access.
+// Custom roles cannot be ranked, so any change away from one is treated as
+// a possible downgrade.
+func IsDowngrade(current string, desired string) (bool, string) {
+	current = NormalizePermission(current)
+	desired = NormalizePermission(desired)
+	currentRank, currentKnown := permissionRanks[current]
+	desiredRank, desiredKnown := permissionRanks[desired]
+
+	switch {
+	case current == PermissionNone || current == desired:
+		return false, ""
+	case !currentKnown:
+		return true, fmt.Sprintf("current role %s cannot be compared with %s", current, desired)
+	case !desiredKnown:
+		return false, ""
+	case desiredRank < currentRank:
+		return true, fmt.Sprintf("would downgrade %s to %s", current, desired)
+	default:
+		return false, ""
+	}
+}
+
+// CountResults tallies mutation results by status.
+func CountResults(results []data.MutationResult) map[string]int {
+	counts := map[string]int{}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// ResultTotals describes the number of results with each status, such as
+// "1 added, 2 skipped".
+func ResultTotals(results []data.MutationResult) string {
+	counts := CountResults(results)
+	var totals []string
+	for _, status := range []string{StatusAdded, StatusUpdated, StatusRemoved, StatusSkipped, StatusBlocked, StatusFailed} {
+		if counts[status] > 0 {
+			totals = append(totals, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(totals) == 0 {
+		return "no changes"
+	}
+	return strings.Join(totals, ", ")
+}
+
+// WriteResultsSummary prints totals per status followed by every row that
+// was not applied, so skipped, blocked and failed rows can be followed up.
+func WriteResultsSummary(w io.Writer, results []data.MutationResult) {
+	_, _ = fmt.Fprintf(w, "Summary: %s\n", ResultTotals(results))
+
+	for _, result := range results {
+		switch result.Status {
+		case StatusSkipped, StatusBlocked, StatusFailed:
+			_, _ = fmt.Fprintf(w, "  %s: %s on %s (%s)\n", result.Status, result.Username, result.RepositoryName, result.Reason)
+		}
+	}
+}
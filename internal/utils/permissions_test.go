@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+)
+
+func TestNormalizePermission(t *testing.T) {
+	tests := map[string]string{
+		"pull":     "read",
+		"push":     "write",
+		"ADMIN":    "admin",
+		" triage ": "triage",
+		"":         PermissionNone,
+		"security": "security",
+	}
+
+	for input, expected := range tests {
+		if got := NormalizePermission(input); got != expected {
+			t.Errorf("Expected NormalizePermission(%q) to be %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestCoversPermission(t *testing.T) {
+	tests := []struct {
+		have   string
+		want   string
+		covers bool
+	}{
+		{have: "admin", want: "write", covers: true},
+		{have: "push", want: "write", covers: true},
+		{have: "read", want: "triage", covers: false},
+		{have: "security", want: "security", covers: true},
+		{have: "admin", want: "security", covers: false},
+		{have: "none", want: "read", covers: false},
+	}
+
+	for _, tt := range tests {
+		if got := CoversPermission(tt.have, tt.want); got != tt.covers {
+			t.Errorf("Expected CoversPermission(%q, %q) to be %v, got %v", tt.have, tt.want, tt.covers, got)
+		}
+	}
+}
+
+func TestIsDowngrade(t *testing.T) {
+	tests := []struct {
+		current   string
+		desired   string
+		downgrade bool
+	}{
+		{current: "none", desired: "pull", downgrade: false},
+		{current: "read", desired: "push", downgrade: false},
+		{current: "admin", desired: "push", downgrade: true},
+		{current: "maintain", desired: "triage", downgrade: true},
+		{current: "write", desired: "push", downgrade: false},
+		{current: "write", desired: "security", downgrade: false},
+		{current: "security", desired: "admin", downgrade: true},
+	}
+
+	for _, tt := range tests {
+		downgrade, reason := IsDowngrade(tt.current, tt.desired)
+		if downgrade != tt.downgrade {
+			t.Errorf("Expected IsDowngrade(%s, %s) to be %v, got %v", tt.current, tt.desired, tt.downgrade, downgrade)
+		}
+		if downgrade && reason == "" {
+			t.Errorf("Expected a reason for downgrade from %s to %s", tt.current, tt.desired)
+		}
+	}
+}
+
+func TestWriteResultsSummary(t *testing.T) {
+	results := []data.MutationResult{
+		{RepositoryName: "repo1", Username: "user1", Status: StatusAdded},
+		{RepositoryName: "repo2", Username: "user2", Status: StatusSkipped, Reason: "already has write"},
+		{RepositoryName: "repo3", Username: "user3", Status: StatusBlocked, Reason: "would downgrade admin to read"},
+	}
+
+	var buf bytes.Buffer
+	WriteResultsSummary(&buf, results)
+	output := buf.String()
+
+	if !strings.HasPrefix(output, "Summary: 1 added, 1 skipped, 1 blocked\n") {
+		t.Errorf("Unexpected totals line: %s", output)
+	}
+	if strings.Contains(output, "user1") {
+		t.Errorf("Expected applied rows to be left out of the details, got %s", output)
+	}
+	if !strings.Contains(output, "blocked: user3 on repo3 (would downgrade admin to read)") {
+		t.Errorf("Expected blocked row in details, got %s", output)
+	}
+
+	buf.Reset()
+	WriteResultsSummary(&buf, nil)
+	if buf.String() != "Summary: no changes\n" {
+		t.Errorf("Expected empty summary, got %q", buf.String())
+	}
+}
+
+func TestGetRepoCollaboratorPermission(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/repos/test-org/repo1/collaborators/user1/permission"},
+			Response: replay.Response{Body: []byte(`{"permission":"write","role_name":"maintain"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/repos/test-org/repo1/collaborators/user2/permission"},
+			Response: replay.Response{Body: []byte(`{"permission":"none","role_name":""}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/repos/test-org/missing/collaborators/user1/permission"},
+			Response: replay.Response{Status: 404, Body: []byte(`{"message":"Not Found"}`)},
+		},
+	)
+	restClient, err := api.NewRESTClient(api.ClientOptions{Host: "github.com", AuthToken: "test-token", Transport: server.Transport()})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	g := NewAPIGetter(nil, restClient)
+	ctx := context.Background()
+
+	permission, err := g.GetRepoCollaboratorPermission(ctx, "test-org", "repo1", "user1")
+	if err != nil || permission != "maintain" {
+		t.Errorf("Expected role name 'maintain', got %q, %v", permission, err)
+	}
+	permission, err = g.GetRepoCollaboratorPermission(ctx, "test-org", "repo1", "user2")
+	if err != nil || permission != PermissionNone {
+		t.Errorf("Expected 'none', got %q, %v", permission, err)
+	}
+	if _, err := g.GetRepoCollaboratorPermission(ctx, "test-org", "missing", "user1"); err == nil {
+		t.Error("Expected error for missing repository, got nil")
+	}
+	server.AssertAllUsed()
+}
+
+func TestIsBuiltInPermission(t *testing.T) {
+	for permission, expected := range map[string]bool{"none": true, "pull": true, "Maintain": true, "security": false, "owner": false} {
+		if got := IsBuiltInPermission(permission); got != expected {
+			t.Errorf("Expected IsBuiltInPermission(%q) to be %v, got %v", permission, expected, got)
+		}
+	}
+}
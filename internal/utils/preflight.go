@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+// ChangeSummary describes a bulk change before it runs, such as "About to
+// remove 57 grants for 12 users across 30 repos".
+func ChangeSummary(action string, collabs []data.ImportedRepoCollab) string {
+	users := map[string]bool{}
+	repos := map[string]bool{}
+	for _, collab := range collabs {
+		users[collab.Username] = true
+		repos[collab.RepositoryName] = true
+	}
+	return fmt.Sprintf("About to %s %s for %s across %s", action,
+		CountOf(len(collabs), "grant", "grants"), CountOf(len(users), "user", "users"), CountOf(len(repos), "repo", "repos"))
+}
+
+// CountOf returns n with the singular or plural noun, such as "1 grant".
+func CountOf(n int, singular string, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
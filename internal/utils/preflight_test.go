@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+func TestChangeSummary(t *testing.T) {
+	collabs := []data.ImportedRepoCollab{
+		{RepositoryName: "repo1", Username: "alice"},
+		{RepositoryName: "repo2", Username: "alice"},
+		{RepositoryName: "repo1", Username: "bob"},
+	}
+	if summary := ChangeSummary("remove", collabs); summary != "About to remove 3 grants for 2 users across 2 repos" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+	if summary := ChangeSummary("add or update", collabs[:1]); summary != "About to add or update 1 grant for 1 user across 1 repo" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+// TeamAccess is the repository access one team grants, permissions keyed by
+// repository name.
+type TeamAccess struct {
+	Team         data.Team
+	Repositories map[string]string
+}
+
+// GetTeamAccess reads every team in the organization with the repositories
+// it grants access to.
+func GetTeamAccess(ctx context.Context, owner string, g Getter) ([]TeamAccess, error) {
+	teams, err := g.ListOrgTeams(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams in %s: %w", owner, err)
+	}
+	access := make([]TeamAccess, 0, len(teams))
+	for _, team := range teams {
+		repos, err := g.ListTeamRepositories(ctx, owner, team.Slug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of team %s: %w", team.Slug, err)
+		}
+		teamAccess := TeamAccess{Team: team, Repositories: map[string]string{}}
+		for _, repo := range repos {
+			teamAccess.Repositories[repo.Name] = NormalizePermission(repo.RoleName)
+		}
+		access = append(access, teamAccess)
+	}
+	return access, nil
+}
+
+// CoveringTeams picks the teams a collaborator with the given grants could
+// join without gaining access they do not already have: every repository a
+// team grants must be one of theirs, at no more than their permission. It
+// returns those teams, by name, and the repositories where a team gives the
+// same access as the direct grant, making the grant redundant.
+func CoveringTeams(grants map[string]string, teams []TeamAccess) ([]TeamAccess, map[string]bool) {
+	var selected []TeamAccess
+	covered := map[string]bool{}
+	for _, team := range teams {
+		if len(team.Repositories) == 0 {
+			continue
+		}
+		eligible := true
+		var teamCovers []string
+		for repo, permission := range team.Repositories {
+			grant, ok := grants[repo]
+			if !ok || !CoversPermission(grant, permission) {
+				eligible = false
+				break
+			}
+			if CoversPermission(permission, grant) {
+				teamCovers = append(teamCovers, repo)
+			}
+		}
+		if !eligible || len(teamCovers) == 0 {
+			continue
+		}
+		selected = append(selected, team)
+		for _, repo := range teamCovers {
+			covered[repo] = true
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Team.Slug < selected[j].Team.Slug })
+	return selected, covered
+}
+
+// TeamSuggestion is a team that could replace direct grants: an existing
+// team, or a new one for collaborators sharing the same repository
+// permissions.
+type TeamSuggestion struct {
+	TeamAccess
+	Existing      bool
+	Collaborators []string
+}
+
+// SuggestTeams groups collaborators holding identical direct grants, keyed by
+// username then repository. A group becomes a suggestion when an existing
+// team grants exactly that access, or as a new team when it has at least
+// minCollaborators members. Collaborators left over are matched to the
+// existing teams they could join without gaining access. Suggestions are
+// ordered by collaborators, largest first.
+func SuggestTeams(grants map[string]map[string]string, teams []TeamAccess, minCollaborators int) []TeamSuggestion {
+	groups := map[string][]string{}
+	for username, userGrants := range grants {
+		if len(userGrants) == 0 {
+			continue
+		}
+		key := accessKey(userGrants)
+		groups[key] = append(groups[key], username)
+	}
+
+	existing := map[string]*TeamSuggestion{}
+	var suggestions []*TeamSuggestion
+	suggestExisting := func(team TeamAccess, usernames ...string) {
+		suggestion, ok := existing[team.Team.Slug]
+		if !ok {
+			suggestion = &TeamSuggestion{TeamAccess: team, Existing: true}
+			existing[team.Team.Slug] = suggestion
+			suggestions = append(suggestions, suggestion)
+		}
+		suggestion.Collaborators = append(suggestion.Collaborators, usernames...)
+	}
+
+	placed := map[string]bool{}
+	for _, usernames := range groups {
+		access := grants[usernames[0]]
+		var match *TeamAccess
+		for i := range teams {
+			if len(teams[i].Repositories) > 0 && accessKey(teams[i].Repositories) == accessKey(access) {
+				match = &teams[i]
+				break
+			}
+		}
+		switch {
+		case match != nil:
+			suggestExisting(*match, usernames...)
+		case len(usernames) >= minCollaborators:
+			repositories := make(map[string]string, len(access))
+			for repo, permission := range access {
+				repositories[repo] = NormalizePermission(permission)
+			}
+			suggestions = append(suggestions, &TeamSuggestion{
+				TeamAccess:    TeamAccess{Repositories: repositories},
+				Collaborators: usernames,
+			})
+		default:
+			continue
+		}
+		for _, username := range usernames {
+			placed[username] = true
+		}
+	}
+
+	for username, userGrants := range grants {
+		if placed[username] {
+			continue
+		}
+		covering, _ := CoveringTeams(userGrants, teams)
+		for _, team := range covering {
+			suggestExisting(team, username)
+		}
+	}
+
+	for _, suggestion := range suggestions {
+		sort.Strings(suggestion.Collaborators)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if len(a.Collaborators) != len(b.Collaborators) {
+			return len(a.Collaborators) > len(b.Collaborators)
+		}
+		if a.Existing != b.Existing {
+			return a.Existing
+		}
+		if a.Team.Slug != b.Team.Slug {
+			return a.Team.Slug < b.Team.Slug
+		}
+		return a.Collaborators[0] < b.Collaborators[0]
+	})
+
+	result := make([]TeamSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		result = append(result, *suggestion)
+	}
+	return result
+}
+
+// accessKey identifies a set of repository permissions regardless of order
+// and permission aliases.
+func accessKey(access map[string]string) string {
+	entries := make([]string, 0, len(access))
+	for repo, permission := range access {
+		entries = append(entries, repo+"="+NormalizePermission(permission))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n")
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/katiem0/gh-collaborators/internal/data"
+)
+
+// fakeTeams is a Getter serving teams and their repositories from maps.
+type fakeTeams struct {
+	*APIGetter
+	teams []data.Team
+	repos map[string][]data.TeamRepository
+}
+
+func (f *fakeTeams) ListOrgTeams(ctx context.Context, owner string) ([]data.Team, error) {
+	return f.teams, nil
+}
+
+func (f *fakeTeams) ListTeamRepositories(ctx context.Context, owner string, teamSlug string) ([]data.TeamRepository, error) {
+	if teamSlug == "broken" {
+		return nil, fmt.Errorf("HTTP 500")
+	}
+	return f.repos[teamSlug], nil
+}
+
+func TestGetTeamAccess(t *testing.T) {
+	g := &fakeTeams{
+		APIGetter: &APIGetter{},
+		teams:     []data.Team{{ID: 1, Slug: "dev"}},
+		repos:     map[string][]data.TeamRepository{"dev": {{Name: "repo1", RoleName: "push"}}},
+	}
+
+	access, err := GetTeamAccess(context.Background(), "test-org", g)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(access) != 1 || access[0].Repositories["repo1"] != "write" {
+		t.Errorf("Expected dev to grant write on repo1, got %+v", access)
+	}
+
+	g.teams = append(g.teams, data.Team{ID: 2, Slug: "broken"})
+	if _, err := GetTeamAccess(context.Background(), "test-org", g); err == nil {
+		t.Error("Expected error for a team whose repositories cannot be read")
+	}
+}
+
+func TestCoveringTeams(t *testing.T) {
+	grants := map[string]string{"repo1": "write", "repo2": "read", "repo3": "admin"}
+	teams := []TeamAccess{
+		// Same access on a subset of the grants
+		{Team: data.Team{Slug: "dev"}, Repositories: map[string]string{"repo1": "write", "repo2": "read"}},
+		// Less access than the grant, joining is safe but the grant stays
+		{Team: data.Team{Slug: "readers"}, Repositories: map[string]string{"repo3": "read"}},
+		// A repository the collaborator cannot access today
+		{Team: data.Team{Slug: "platform"}, Repositories: map[string]string{"repo1": "write", "repo9": "read"}},
+		// More access than the grant
+		{Team: data.Team{Slug: "admins"}, Repositories: map[string]string{"repo2": "admin"}},
+		{Team: data.Team{Slug: "empty"}, Repositories: map[string]string{}},
+		{Team: data.Team{Slug: "ops"}, Repositories: map[string]string{"repo3": "admin"}},
+	}
+
+	selected, covered := CoveringTeams(grants, teams)
+	var slugs []string
+	for _, team := range selected {
+		slugs = append(slugs, team.Team.Slug)
+	}
+	if !reflect.DeepEqual(slugs, []string{"dev", "ops"}) {
+		t.Errorf("Expected dev and ops, got %v", slugs)
+	}
+	expected := map[string]bool{"repo1": true, "repo2": true, "repo3": true}
+	if !reflect.DeepEqual(covered, expected) {
+		t.Errorf("Expected %v covered, got %v", expected, covered)
+	}
+}
+
+func TestSuggestTeams(t *testing.T) {
+	grants := map[string]map[string]string{
+		// Shared access matching no team
+		"alice": {"repo1": "WRITE", "repo2": "READ"},
+		"bob":   {"repo1": "push", "repo2": "pull"},
+		"carol": {"repo1": "write", "repo2": "read"},
+		// Access an existing team grants exactly
+		"dave": {"repo3": "admin"},
+		// Nobody shares this, but the ops team fits within it
+		"erin": {"repo3": "admin", "repo4": "write"},
+		// Unique access with no team to join
+		"frank": {"repo5": "read"},
+	}
+	teams := []TeamAccess{
+		{Team: data.Team{ID: 1, Slug: "ops"}, Repositories: map[string]string{"repo3": "admin"}},
+		{Team: data.Team{ID: 2, Slug: "platform"}, Repositories: map[string]string{"repo1": "write", "repo9": "read"}},
+	}
+
+	suggestions := SuggestTeams(grants, teams, 2)
+	if len(suggestions) != 2 {
+		t.Fatalf("Expected 2 suggestions, got %+v", suggestions)
+	}
+
+	if suggestions[0].Existing || !reflect.DeepEqual(suggestions[0].Collaborators, []string{"alice", "bob", "carol"}) {
+		t.Errorf("Expected a new team for alice, bob and carol, got %+v", suggestions[0])
+	}
+	if suggestions[0].Repositories["repo1"] != "write" || len(suggestions[0].Repositories) != 2 {
+		t.Errorf("Expected the new team to grant the shared access, got %v", suggestions[0].Repositories)
+	}
+	if !suggestions[1].Existing || suggestions[1].Team.Slug != "ops" || !reflect.DeepEqual(suggestions[1].Collaborators, []string{"dave", "erin"}) {
+		t.Errorf("Expected ops for dave and erin, got %+v", suggestions[1])
+	}
+
+	if suggestions := SuggestTeams(grants, nil, 4); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions below the minimum group size, got %+v", suggestions)
+	}
+}
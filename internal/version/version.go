@@ -0,0 +1,19 @@
+package version
+
+import "runtime/debug"
+
+// Version is the release version, set at build time with
+// -ldflags "-X github.com/katiem0/gh-collaborators/internal/version.Version=v1.2.3".
+var Version string
+
+// String returns the version of the running binary, falling back to the
+// module version recorded by go install and then to "dev".
+func String() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
@@ -0,0 +1,19 @@
+package version
+
+import "testing"
+
+func TestString(t *testing.T) {
+	original := Version
+	defer func() { Version = original }()
+
+	Version = "v1.2.3"
+	if got := String(); got != "v1.2.3" {
+		t.Errorf("Expected v1.2.3, got %s", got)
+	}
+
+	// Test binaries have no module version
+	Version = ""
+	if got := String(); got != "dev" {
+		t.Errorf("Expected dev, got %s", got)
+	}
+}
@@ -0,0 +1,257 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/katiem0/gh-collaborators/internal/cache"
+	"github.com/katiem0/gh-collaborators/internal/data"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/policy"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	SignatureHeader = "X-Hub-Signature-256"
+	EventHeader     = "X-GitHub-Event"
+	DeliveryHeader  = "X-GitHub-Delivery"
+
+	// maxPayloadSize is the largest payload GitHub delivers
+	maxPayloadSize = 25 << 20
+)
+
+// Sign returns the X-Hub-Signature-256 value GitHub sends for payload.
+func Sign(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature was made for payload with the
+// webhook's secret, in constant time.
+func VerifySignature(secret []byte, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
+
+type account struct {
+	Login string `json:"login"`
+}
+
+type repository struct {
+	Name  string  `json:"name"`
+	Owner account `json:"owner"`
+}
+
+type change struct {
+	To string `json:"to"`
+}
+
+// memberEvent is sent when a collaborator is added to a repository, or
+// their permission is edited.
+type memberEvent struct {
+	Action  string  `json:"action"`
+	Member  account `json:"member"`
+	Changes struct {
+		Permission change `json:"permission"`
+		RoleName   change `json:"role_name"`
+	} `json:"changes"`
+	Repository repository `json:"repository"`
+}
+
+// repositoryEvent is sent when a repository is created or transferred into
+// the organization, possibly bringing its collaborators along.
+type repositoryEvent struct {
+	Action     string     `json:"action"`
+	Repository repository `json:"repository"`
+}
+
+// Handler checks collaborator grants reported by member and repository
+// webhooks against the policy, for one organization.
+type Handler struct {
+	Secret []byte
+	Owner  string
+	Policy *policy.Policy
+	Getter utils.Getter
+	// OpenJournal starts the journal recording a revert, a nil journal
+	// records nothing.
+	OpenJournal func(ctx context.Context) (*journal.Journal, error)
+	// Out receives a line for every grant checked
+	Out io.Writer
+
+	mu sync.Mutex
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	if !VerifySignature(h.Secret, payload, r.Header.Get(SignatureHeader)) {
+		zap.L().Warn("Rejected webhook with an invalid signature", zap.String("delivery", r.Header.Get(DeliveryHeader)))
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	// Finish handling a delivery even when GitHub stops waiting for it, a
+	// revert should not be left half done. Grants are checked against
+	// GitHub's current answer, never the cache.
+	ctx := cache.Live(context.WithoutCancel(r.Context()))
+	event := r.Header.Get(EventHeader)
+	var handleErr error
+	switch event {
+	case "ping":
+		_, _ = fmt.Fprintln(w, "pong")
+		return
+	case "member":
+		handleErr = h.handleMember(ctx, payload)
+	case "repository":
+		handleErr = h.handleRepository(ctx, payload)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = fmt.Fprintf(w, "ignored %s event\n", event)
+		return
+	}
+	if handleErr != nil {
+		zap.L().Error("Failed to handle webhook", zap.String("event", event), zap.String("delivery", r.Header.Get(DeliveryHeader)), zap.Error(handleErr))
+		http.Error(w, handleErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = fmt.Fprintln(w, "ok")
+}
+
+func (h *Handler) handleMember(ctx context.Context, payload []byte) error {
+	var event memberEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to parse member event: %w", err)
+	}
+	if (event.Action != "added" && event.Action != "edited") || !strings.EqualFold(event.Repository.Owner.Login, h.Owner) {
+		return nil
+	}
+
+	membership, err := h.Getter.GetOrgMembership(ctx, h.Owner, event.Member.Login)
+	if err != nil {
+		return fmt.Errorf("failed to read membership of %s: %w", event.Member.Login, err)
+	}
+	if membership != nil && membership.State == "active" {
+		return nil
+	}
+
+	permission := event.Changes.RoleName.To
+	if permission == "" {
+		permission = event.Changes.Permission.To
+	}
+	if permission == "" {
+		permission, err = h.Getter.GetRepoCollaboratorPermission(ctx, h.Owner, event.Repository.Name, event.Member.Login)
+		if err != nil {
+			return fmt.Errorf("failed to read permission of %s on %s: %w", event.Member.Login, event.Repository.Name, err)
+		}
+	}
+	return h.checkGrant(ctx, event.Repository.Name, event.Member.Login, permission)
+}
+
+func (h *Handler) handleRepository(ctx context.Context, payload []byte) error {
+	var event repositoryEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to parse repository event: %w", err)
+	}
+	if (event.Action != "created" && event.Action != "transferred") || !strings.EqualFold(event.Repository.Owner.Login, h.Owner) {
+		return nil
+	}
+
+	collaborators, err := h.Getter.ListRepoCollaborators(ctx, h.Owner, event.Repository.Name, "outside")
+	if err != nil {
+		return fmt.Errorf("failed to list collaborators of %s: %w", event.Repository.Name, err)
+	}
+	for _, collaborator := range collaborators {
+		if err := h.checkGrant(ctx, event.Repository.Name, collaborator.Login, collaborator.RoleName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGrant applies the policy to an outside collaborator's permission on
+// a repository.
+func (h *Handler) checkGrant(ctx context.Context, repo string, username string, permission string) error {
+	permission = utils.NormalizePermission(permission)
+	decision := h.Policy.Check(repo, username, permission)
+	logger := zap.L().With(zap.String("org", h.Owner), zap.String("repo", repo), zap.String("user", username), zap.String("permission", permission))
+	if decision.Allowed {
+		logger.Debug("Grant allowed by policy")
+		h.printf("%s has %s on %s: allowed\n", username, permission, repo)
+		return nil
+	}
+	logger.Warn("Grant breaks policy", zap.String("max_permission", decision.MaxPermission), zap.String("action", decision.Action))
+	violation := fmt.Sprintf("%s has %s on %s, above the policy's %s", username, permission, repo, decision.MaxPermission)
+
+	switch decision.Action {
+	case policy.ActionIssue:
+		issue, err := h.Getter.CreateIssue(ctx, h.Owner, repo,
+			fmt.Sprintf("Outside collaborator %s has %s access", username, permission),
+			fmt.Sprintf("@%s was given `%s` access to this repository, the collaborator policy allows outside collaborators at most `%s` here. "+
+				"Please review the grant and lower or remove it.", username, permission, decision.MaxPermission))
+		if err != nil {
+			return fmt.Errorf("failed to open an issue on %s: %w", repo, err)
+		}
+		h.printf("%s: opened issue #%d\n", violation, issue.Number)
+	case policy.ActionRevert:
+		return h.revert(ctx, repo, username, violation)
+	default:
+		h.printf("%s\n", violation)
+	}
+	return nil
+}
+
+// revert removes the collaborator from the repository, journaled so the
+// removal can be rolled back.
+func (h *Handler) revert(ctx context.Context, repo string, username string, violation string) error {
+	var j *journal.Journal
+	if h.OpenJournal != nil {
+		var err error
+		if j, err = h.OpenJournal(ctx); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		if closeErr := j.Close(); closeErr != nil {
+			zap.L().Warn("Error closing journal", zap.Error(closeErr))
+		}
+	}()
+
+	result := utils.RevokeRepoCollaborator(ctx, h.Owner, data.ImportedRepoCollab{RepositoryName: repo, Username: username}, h.Getter)
+	if err := j.Record(result); err != nil {
+		return err
+	}
+	if result.Status == utils.StatusFailed {
+		return fmt.Errorf("failed to remove %s from %s: %w", username, repo, result.Err)
+	}
+	if j != nil {
+		h.printf("%s: %s, run ID %s\n", violation, result.Status, j.RunID)
+	} else {
+		h.printf("%s: %s\n", violation, result.Status)
+	}
+	return nil
+}
+
+func (h *Handler) printf(format string, args ...any) {
+	if h.Out == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, _ = fmt.Fprintf(h.Out, format, args...)
+}
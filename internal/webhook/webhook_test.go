@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-collaborators/internal/journal"
+	"github.com/katiem0/gh-collaborators/internal/policy"
+	"github.com/katiem0/gh-collaborators/internal/replay"
+	"github.com/katiem0/gh-collaborators/internal/utils"
+)
+
+var testSecret = []byte("webhook-secret")
+
+func newTestHandler(t *testing.T, server *replay.Server, out *bytes.Buffer) *Handler {
+	t.Helper()
+	restClient, err := api.NewRESTClient(api.ClientOptions{Host: "github.com", AuthToken: "test-token", Transport: server.Transport()})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	p, err := policy.Load("testdata/policy.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	journalDir := t.TempDir()
+	return &Handler{
+		Secret: testSecret,
+		Owner:  "test-org",
+		Policy: p,
+		Getter: utils.NewAPIGetter(nil, restClient),
+		OpenJournal: func(ctx context.Context) (*journal.Journal, error) {
+			return journal.Open(journalDir, "serve-webhooks", "test-org")
+		},
+		Out: out,
+	}
+}
+
+// deliver posts a canned payload signed with secret as GitHub would.
+func deliver(t *testing.T, handler http.Handler, event string, fixture string, secret []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, "delivery-1")
+	req.Header.Set(SignatureHeader, Sign(secret, payload))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"zen":"Keep it logically awesome."}`)
+	signature := Sign(testSecret, payload)
+	if !strings.HasPrefix(signature, "sha256=") || !VerifySignature(testSecret, payload, signature) {
+		t.Errorf("Expected signature %s to verify", signature)
+	}
+	if VerifySignature([]byte("other"), payload, signature) || VerifySignature(testSecret, payload, "") {
+		t.Error("Expected signatures from another secret, or none, to fail")
+	}
+}
+
+func TestHandlerRejectsInvalidSignature(t *testing.T) {
+	server := replay.NewServer(t)
+	handler := newTestHandler(t, server, &bytes.Buffer{})
+
+	if rec := deliver(t, handler, "member", "member_added.json", []byte("wrong-secret")); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", rec.Code)
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no API requests for a rejected delivery, got %d", len(server.Requests()))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestHandlerOpensIssue(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/alice"},
+			Response: replay.Response{Status: 404, Body: []byte(`{"message":"Not Found"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "POST", Path: "/repos/test-org/repo1/issues"},
+			Response: replay.Response{Status: 201, Body: []byte(`{"number":12,"title":"Outside collaborator alice has admin access"}`)},
+		},
+	)
+	var out bytes.Buffer
+	handler := newTestHandler(t, server, &out)
+
+	if rec := deliver(t, handler, "member", "member_added.json", testSecret); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	server.AssertAllUsed()
+	if out.String() != "alice has admin on repo1, above the policy's write: opened issue #12\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestHandlerRevertsGrant(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/alice"},
+			Response: replay.Response{Status: 404, Body: []byte(`{"message":"Not Found"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/repos/test-org/secret-keys/collaborators/alice/permission"},
+			Response: replay.Response{Body: []byte(`{"permission":"read","role_name":"read"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/repos/test-org/secret-keys/collaborators/alice/permission"},
+			Response: replay.Response{Body: []byte(`{"permission":"read","role_name":"read"}`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "GET", Path: "/repos/test-org/secret-keys/collaborators", Query: "affiliation=direct&per_page=100&page=1"},
+			Response: replay.Response{Body: []byte(`[{"login":"alice","role_name":"read"}]`)},
+		},
+		replay.Interaction{
+			Request:  replay.Request{Method: "DELETE", Path: "/repos/test-org/secret-keys/collaborators/alice"},
+			Response: replay.Response{Status: 204},
+		},
+	)
+	var out bytes.Buffer
+	handler := newTestHandler(t, server, &out)
+
+	if rec := deliver(t, handler, "member", "member_added_secret.json", testSecret); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	server.AssertAllUsed()
+	if !strings.HasPrefix(out.String(), "alice has read on secret-keys, above the policy's none: removed, run ID ") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestHandlerSkipsMembers(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request:  replay.Request{Method: "GET", Path: "/orgs/test-org/memberships/alice"},
+		Response: replay.Response{Body: []byte(`{"state":"active","role":"member"}`)},
+	})
+	var out bytes.Buffer
+	handler := newTestHandler(t, server, &out)
+
+	if rec := deliver(t, handler, "member", "member_added.json", testSecret); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	server.AssertAllUsed()
+	if out.Len() != 0 {
+		t.Errorf("Expected organization members to be left alone, got %q", out.String())
+	}
+}
+
+func TestHandlerChecksTransferredRepository(t *testing.T) {
+	server := replay.NewServer(t)
+	server.Add(replay.Interaction{
+		Request: replay.Request{Method: "GET", Path: "/repos/test-org/docs/collaborators", Query: "affiliation=outside&per_page=100&page=1"},
+		Response: replay.Response{Body: []byte(`[
+			{"login":"alice","role_name":"read"},
+			{"login":"bob","permissions":{"pull":true,"push":true}}
+		]`)},
+	})
+	var out bytes.Buffer
+	handler := newTestHandler(t, server, &out)
+
+	if rec := deliver(t, handler, "repository", "repository_transferred.json", testSecret); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	server.AssertAllUsed()
+	expected := "alice has read on docs: allowed\nbob has write on docs, above the policy's read\n"
+	if out.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, out.String())
+	}
+}
+
+func TestHandlerIgnoresOtherEvents(t *testing.T) {
+	server := replay.NewServer(t)
+	handler := newTestHandler(t, server, &bytes.Buffer{})
+
+	if rec := deliver(t, handler, "ping", "member_added.json", testSecret); rec.Code != http.StatusOK || rec.Body.String() != "pong\n" {
+		t.Errorf("Expected pong, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := deliver(t, handler, "push", "member_added.json", testSecret); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 for an ignored event, got %d", rec.Code)
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("Expected no API requests, got %d", len(server.Requests()))
+	}
+}
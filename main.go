@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/katiem0/gh-collaborators/cmd"
 )
 
 func main() {
+	// Cancel the command on the first interrupt so it can write what it has,
+	// a second interrupt exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	// Instantiate and execute root command
 	cmd := cmd.NewCmdRoot()
-	if err := cmd.Execute(); err != nil {
+	if err := cmd.ExecuteContext(ctx); err != nil {
 		os.Exit(1)
 	}
 }